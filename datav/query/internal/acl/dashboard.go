@@ -10,6 +10,13 @@ import (
 	"github.com/xObserve/xObserve/query/pkg/models"
 )
 
+// the permissions needed to edit annotations, decided by the enableRole setting of dashboard
+var annotationPermissions = map[string]models.PermissionType{
+	models.ROLE_VIEWER: models.PermissionView,
+	models.ROLE_EDITOR: models.PermissionEdit,
+	models.ROLE_ADMIN:  models.PermissionAdmin,
+}
+
 func CanEditAnnotation(ctx context.Context, dashboardId string, u *models.User) error {
 	dash, err := models.QueryDashboard(ctx, dashboardId)
 	if err != nil {
@@ -30,25 +37,136 @@ func CanEditAnnotation(ctx context.Context, dashboardId string, u *models.User)
 		return fmt.Errorf("get annotation enable role err: %w", err)
 	}
 
-	teamMember, err := models.QueryTeamMember(ctx, dash.OwnedBy, u.Id)
+	needed, ok := annotationPermissions[enableRole]
+	if !ok {
+		needed = models.PermissionView
+	}
+
+	permission, err := DashboardPermission(ctx, dash, u)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		return err
+	}
+
+	if !permission.Contains(needed) {
+		if permission == "" {
 			return errors.New(e.NotTeamMember)
 		}
+		return fmt.Errorf("only %s of this dashboard can do this", needed)
+	}
 
-		return fmt.Errorf("query team member err: %w", err)
+	return nil
+
+}
+
+// DashboardPermission returns the highest permission of a user on a dashboard, it is the highest one among
+// the team role, the permissions granted on the folders of dashboard and the acl of dashboard
+func DashboardPermission(ctx context.Context, dash *models.Dashboard, u *models.User) (models.PermissionType, error) {
+	if u == nil {
+		return "", nil
 	}
 
-	if enableRole == models.ROLE_ADMIN {
-		if !teamMember.Role.IsAdmin() {
-			return errors.New(e.NeedTeamAdmin)
+	var permission models.PermissionType
+	var folder *models.Folder
+	if dash.FolderId != 0 {
+		var err error
+		folder, err = models.QueryFolder(ctx, dash.FolderId)
+		if err != nil && err != sql.ErrNoRows {
+			return "", fmt.Errorf("query dashboard folder err: %w", err)
 		}
 	}
 
-	return nil
+	if folder != nil {
+		// team role is included in folder permission
+		var err error
+		permission, err = FolderPermission(ctx, folder, u.Id)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		// dashboards not in folders or whose folder is missing are accessed with the team role
+		member, err := models.QueryTeamMember(ctx, dash.OwnedBy, u.Id)
+		if err != nil && err != sql.ErrNoRows {
+			return "", fmt.Errorf("query team member err: %w", err)
+		}
+		if member != nil {
+			permission = models.RolePermission(member.Role)
+		}
+	}
+
+	if permission.Contains(models.PermissionAdmin) {
+		return permission, nil
+	}
+
+	granted, err := models.QueryDashboardAclPermission(ctx, dash.Id, u.Id)
+	if err != nil {
+		return "", fmt.Errorf("query dashboard acl err: %w", err)
+	}
+	if !permission.Contains(granted) {
+		permission = granted
+	}
+
+	return permission, nil
+}
+
+// CanViewDashboard checks the visibility of dashboard first, then the permissions of user on it
+func CanViewDashboard(ctx context.Context, dash *models.Dashboard, u *models.User) error {
+	if dash.VisibleTo == models.AllVisible {
+		return nil
+	}
 
+	if u == nil {
+		return errors.New("you have to sign in to view this dashboard")
+	}
+
+	if dash.VisibleTo == models.TenantVisible {
+		tenantId, err := models.QueryTenantIdByTeamId(ctx, dash.OwnedBy)
+		if err != nil {
+			return fmt.Errorf("query tenant id error: %w", err)
+		}
+
+		in, err := models.IsUserInTenant(u.Id, tenantId)
+		if err != nil {
+			return fmt.Errorf("check user in tenant error: %w", err)
+		}
+
+		if in {
+			return nil
+		}
+	}
+
+	permission, err := DashboardPermission(ctx, dash, u)
+	if err != nil {
+		return err
+	}
+
+	if !permission.Contains(models.PermissionView) {
+		if dash.VisibleTo == models.TenantVisible {
+			return errors.New("you are not the tenant menber to view this dashboard")
+		}
+		return errors.New("you are not the team menber to view this dashboard")
+	}
+
+	return nil
 }
 
 func CanEditDashboard(ctx context.Context, dash *models.Dashboard, u *models.User) error {
-	return CanEditTeam(ctx, dash.OwnedBy, u.Id)
+	return checkDashboardPermission(ctx, dash, u, models.PermissionEdit)
+}
+
+// CanAdminDashboard checks whether the user can manage the acl of dashboard
+func CanAdminDashboard(ctx context.Context, dash *models.Dashboard, u *models.User) error {
+	return checkDashboardPermission(ctx, dash, u, models.PermissionAdmin)
+}
+
+func checkDashboardPermission(ctx context.Context, dash *models.Dashboard, u *models.User, needed models.PermissionType) error {
+	permission, err := DashboardPermission(ctx, dash, u)
+	if err != nil {
+		return err
+	}
+
+	if !permission.Contains(needed) {
+		return fmt.Errorf("you need the %s permission of this dashboard", needed)
+	}
+
+	return nil
 }
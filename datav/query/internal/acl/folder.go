@@ -0,0 +1,72 @@
+package acl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// FolderPermission returns the highest permission of a user on a folder,
+// team members have the permission of their team role on all the folders of their team,
+// others need the permissions granted on the folder or its ancestors
+func FolderPermission(ctx context.Context, folder *models.Folder, userId int64) (models.PermissionType, error) {
+	var permission models.PermissionType
+	member, err := models.QueryTeamMember(ctx, folder.TeamId, userId)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("query team member err: %w", err)
+	}
+	if member != nil {
+		permission = models.RolePermission(member.Role)
+		if permission.Contains(models.PermissionAdmin) {
+			return permission, nil
+		}
+	}
+
+	path, err := models.QueryFolderPath(ctx, folder.Id)
+	if err != nil {
+		return "", fmt.Errorf("query folder path err: %w", err)
+	}
+
+	granted, err := models.QueryGrantedFolders(ctx, userId)
+	if err != nil {
+		return "", fmt.Errorf("query granted folders err: %w", err)
+	}
+
+	for _, f := range path {
+		if !permission.Contains(granted[f.Id]) {
+			permission = granted[f.Id]
+		}
+	}
+
+	return permission, nil
+}
+
+func CanViewFolder(ctx context.Context, folder *models.Folder, userId int64) error {
+	permission, err := FolderPermission(ctx, folder, userId)
+	if err != nil {
+		return err
+	}
+
+	if !permission.Contains(models.PermissionView) {
+		return errors.New(e.NoPermission)
+	}
+
+	return nil
+}
+
+func CanEditFolder(ctx context.Context, folder *models.Folder, userId int64) error {
+	permission, err := FolderPermission(ctx, folder, userId)
+	if err != nil {
+		return err
+	}
+
+	if !permission.Contains(models.PermissionEdit) {
+		return errors.New(e.NoPermission)
+	}
+
+	return nil
+}
@@ -39,3 +39,21 @@ func CanEditTeam(ctx context.Context, teamId int64, userId int64) error {
 
 	return nil
 }
+
+// CanEditTeamDashboards checks whether the user can edit the dashboards and library panels of a team
+func CanEditTeamDashboards(ctx context.Context, teamId int64, userId int64) error {
+	member, err := models.QueryTeamMember(ctx, teamId, userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New(e.NotTeamMember)
+		}
+
+		return fmt.Errorf("query team member err: %w", err)
+	}
+
+	if !member.Role.IsEditor() {
+		return errors.New(e.NeedTeamEditor)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/internal/datasource"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// the usage apis of xobserve datasource, which can be queried across all telemetry tenants by website admin
+var usageAPIs = map[string]bool{
+	"getUsage":          true,
+	"getUsageBreakdown": true,
+}
+
+// QueryUsage returns the ingestion volume of all telemetry tenants in a xobserve datasource,
+// query and params are the same as the usage apis of the datasource
+func QueryUsage(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	if err := acl.CanViewWebsite(u); err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	if !usageAPIs[c.Query("query")] {
+		c.JSON(http.StatusBadRequest, common.RespError("unsupported usage query"))
+		return
+	}
+
+	dsID, _ := strconv.ParseInt(c.Param("datasourceId"), 10, 64)
+	ds, err := datasource.GetDatasource(c.Request.Context(), dsID)
+	if err != nil {
+		logger.Warn("query datasource error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(err.Error()))
+		return
+	}
+
+	if ds.Type != models.DatasourceXobserve {
+		c.JSON(http.StatusBadRequest, common.RespError("usage is only available in xobserve datasource"))
+		return
+	}
+
+	c.Set(models.TelemetryTenantsKey, []string{models.AllTelemetryTenants})
+	result := models.GetPlugin(ds.Type).Query(c, ds)
+	if result.Status != models.PluginStatusSuccess {
+		c.JSON(http.StatusInternalServerError, common.RespError(result.Error))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
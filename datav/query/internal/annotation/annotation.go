@@ -1,6 +1,7 @@
 package annotation
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -67,8 +68,8 @@ func SetAnnotation(c *gin.Context) {
 		}
 		anno.Id = id
 	} else {
-		_, err := db.Conn.ExecContext(c.Request.Context(), "UPDATE annotation SET text=?,tags=?,duration=?, updated=? WHERE id=?",
-			anno.Text, tags, anno.Duration, now, anno.Id)
+		_, err := db.Conn.ExecContext(c.Request.Context(), "UPDATE annotation SET text=?,tags=?,duration=?, updated=? WHERE id=? and namespace_id=?",
+			anno.Text, tags, anno.Duration, now, anno.Id, anno.NamespaceId)
 		if err != nil {
 			logger.Warn("update annotation err", "error", err)
 			c.JSON(http.StatusInternalServerError, common.RespError("update annotation err"))
@@ -83,6 +84,25 @@ func QueryNamespaceAnnotations(c *gin.Context) {
 	namespace := c.Param("namespace")
 	start := c.Query("start")
 	end := c.Query("end")
+
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := models.QueryDashboard(c.Request.Context(), namespace)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, common.RespError("dashboard not found"))
+			return
+		}
+		logger.Warn("query dashboard err", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError("query dashboard err"))
+		return
+	}
+
+	err = acl.CanViewDashboard(c.Request.Context(), dash, u)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
 	rows, err := db.Conn.QueryContext(c.Request.Context(), "SELECT id,text,time,duration,tags,group_id,userId,created FROM annotation WHERE namespace_id=? and time >= ? and time <= ?", namespace, start, end)
 	if err != nil {
 		logger.Warn("query annotation err", "error", err)
@@ -123,7 +143,7 @@ func RemoveAnnotation(c *gin.Context) {
 		return
 	}
 
-	_, err = db.Conn.ExecContext(c.Request.Context(), "DELETE FROM annotation WHERE id=?", id)
+	_, err = db.Conn.ExecContext(c.Request.Context(), "DELETE FROM annotation WHERE id=? and namespace_id=?", id, namespace)
 	if err != nil {
 		logger.Warn("delete annotation err", "error", err)
 		c.JSON(http.StatusInternalServerError, common.RespError("delete annotation err"))
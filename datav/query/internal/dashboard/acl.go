@@ -0,0 +1,143 @@
+package dashboard
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// GetAcl returns the acl entries of a dashboard, together with the permission of current user
+func GetAcl(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := QueryDashboard(c, u)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	permission, err := acl.DashboardPermission(c.Request.Context(), dash, u)
+	if err != nil {
+		logger.Warn("query dashboard permission error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	entries, err := models.QueryDashboardAcl(c.Request.Context(), dash.Id)
+	if err != nil {
+		logger.Warn("query dashboard acl error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(map[string]interface{}{
+		"permission": permission,
+		"acl":        entries,
+	}))
+}
+
+// UpdateAcl replaces the acl entries of a dashboard, the admin permission of dashboard is required
+func UpdateAcl(c *gin.Context) {
+	entries := make([]*models.DashboardAcl, 0)
+	err := c.Bind(&entries)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := QueryDashboard(c, u)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	err = acl.CanAdminDashboard(c.Request.Context(), dash, u)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	// permissions can only be granted to the users and teams in the tenant of dashboard
+	tenantId, err := models.QueryTenantIdByTeamId(c.Request.Context(), dash.OwnedBy)
+	if err != nil {
+		logger.Warn("query tenant id error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	for _, entry := range entries {
+		if !models.IsValidPermissionSubject(entry.SubjectType) || !entry.Permission.IsValid() || entry.SubjectId == 0 {
+			c.JSON(http.StatusBadRequest, common.RespError("invalid dashboard acl"))
+			return
+		}
+
+		if entry.SubjectType == models.PermissionSubjectTeam {
+			teamTenantId, err := models.QueryTenantIdByTeamId(c.Request.Context(), entry.SubjectId)
+			if err != nil || teamTenantId != tenantId {
+				c.JSON(http.StatusBadRequest, common.RespError(e.TeamNotExist))
+				return
+			}
+		} else {
+			_, err := models.QueryUserById(c.Request.Context(), entry.SubjectId)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, common.RespError(e.UserNotExist))
+				return
+			}
+			in, err := models.IsUserInTenant(entry.SubjectId, tenantId)
+			if err != nil {
+				logger.Warn("check user in tenant error", "error", err)
+				c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+				return
+			}
+			if !in {
+				c.JSON(http.StatusBadRequest, common.RespError("user is not in the tenant of dashboard"))
+				return
+			}
+		}
+	}
+
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		logger.Warn("start sql transaction error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(c.Request.Context(), "DELETE FROM dashboard_acl WHERE dashboard_id=?", dash.Id)
+	if err != nil {
+		logger.Warn("delete dashboard acl error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		_, err = tx.ExecContext(c.Request.Context(), "INSERT INTO dashboard_acl (dashboard_id,subject_type,subject_id,permission,created) VALUES (?,?,?,?,?)",
+			dash.Id, entry.SubjectType, entry.SubjectId, entry.Permission, now)
+		if err != nil {
+			if e.IsErrUniqueConstraint(err) {
+				c.JSON(http.StatusBadRequest, common.RespError("duplicate dashboard acl"))
+				return
+			}
+			logger.Warn("insert dashboard acl error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Warn("commit sql transaction error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
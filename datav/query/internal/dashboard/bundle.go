@@ -0,0 +1,603 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/internal/datasource"
+	"github.com/xObserve/xObserve/query/internal/variables"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+	"github.com/xObserve/xObserve/query/pkg/utils"
+)
+
+// Export exports dashboards as a bundle, one of the `id`, `folderId` and `teamId` query params should be given,
+// only the dashboards visible to current user are exported
+func Export(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	ctx := c.Request.Context()
+
+	var dashboards []*models.BundleDashboard
+	var teamId int64
+	var err error
+	if id := c.Query("id"); id != "" {
+		dash, err := models.QueryDashboard(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, common.RespError("dashboard not found"))
+				return
+			}
+			logger.Warn("query dashboard error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+		err = acl.CanViewDashboard(ctx, dash, u)
+		if err != nil {
+			c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+			return
+		}
+		teamId = dash.OwnedBy
+		dashboards = []*models.BundleDashboard{{Dashboard: dash}}
+	} else {
+		var folder *models.Folder
+		folderId, _ := strconv.ParseInt(c.Query("folderId"), 10, 64)
+		if folderId != 0 {
+			folder, err = models.QueryFolder(ctx, folderId)
+			if err == nil {
+				err = acl.CanViewFolder(ctx, folder, u.Id)
+				if err != nil {
+					c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+					return
+				}
+			}
+		} else {
+			teamId, _ = strconv.ParseInt(c.Query("teamId"), 10, 64)
+			err = acl.CanViewTeam(ctx, teamId, u.Id)
+			if err != nil {
+				c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+				return
+			}
+			folder, err = models.QueryRootFolder(ctx, teamId)
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, common.RespError("folder not found"))
+				return
+			}
+			logger.Warn("query folder error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+
+		teamId = folder.TeamId
+		dashboards, err = queryFolderDashboards(ctx, folder, u)
+		if err != nil {
+			logger.Warn("query folder dashboards error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+	}
+
+	bundle, err := buildBundle(ctx, teamId, dashboards)
+	if err != nil {
+		logger.Warn("build dashboard bundle error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(bundle))
+}
+
+// queryFolderDashboards returns the visible dashboards in the folder and its sub folders
+func queryFolderDashboards(ctx context.Context, folder *models.Folder, u *models.User) ([]*models.BundleDashboard, error) {
+	folders, err := models.QueryTeamFolders(ctx, folder.TeamId)
+	if err != nil {
+		return nil, err
+	}
+
+	// folder paths are relative to the exported folder, so the same structure can be created under the target folder
+	paths := map[int64][]string{folder.Id: nil}
+	ids := models.FolderDescendants(folders, folder.Id)
+	parents := make(map[int64]*models.Folder)
+	for _, f := range folders {
+		parents[f.Id] = f
+	}
+	for _, id := range ids[1:] {
+		f := parents[id]
+		paths[id] = append(append([]string{}, paths[f.ParentId]...), f.Title)
+	}
+
+	rows, err := db.Conn.QueryContext(ctx, "SELECT id FROM dashboard WHERE folder_id IN ("+joinIds(ids)+")")
+	if err != nil {
+		return nil, err
+	}
+	dashIds := make([]string, 0)
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		dashIds = append(dashIds, id)
+	}
+	rows.Close()
+
+	dashboards := make([]*models.BundleDashboard, 0, len(dashIds))
+	for _, id := range dashIds {
+		dash, err := models.QueryDashboard(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if acl.CanViewDashboard(ctx, dash, u) != nil {
+			continue
+		}
+		dashboards = append(dashboards, &models.BundleDashboard{Dashboard: dash, FolderPath: paths[dash.FolderId]})
+	}
+
+	return dashboards, nil
+}
+
+// buildBundle bundles the dashboards with the team variables and datasources they use
+func buildBundle(ctx context.Context, teamId int64, dashboards []*models.BundleDashboard) (*models.DashboardBundle, error) {
+	bundle := &models.DashboardBundle{
+		Version:     models.DashboardBundleVersion,
+		Exported:    time.Now(),
+		Dashboards:  make([]*models.BundleDashboard, 0, len(dashboards)),
+		Variables:   make([]*models.Variable, 0),
+		Datasources: make([]*models.BundleDatasource, 0),
+	}
+
+	teamVars, err := variables.GetTeamVariables(ctx, teamId)
+	if err != nil {
+		return nil, fmt.Errorf("query team variables error: %w", err)
+	}
+
+	dsIds := make(map[int64]bool)
+	usedVars := make(map[string]bool)
+	for _, d := range dashboards {
+		// library panels belong to the team, so they are exported as normal panels
+		err := models.ResolveLibraryPanels(ctx, teamId, d.Data)
+		if err != nil {
+			return nil, fmt.Errorf("resolve library panels error: %w", err)
+		}
+		models.UnlinkLibraryPanels(d.Data)
+
+		// only the content of dashboard is exported, team, folder and version are decided when importing
+		dash := &models.Dashboard{
+			Id:         d.Id,
+			Title:      d.Title,
+			VisibleTo:  d.VisibleTo,
+			Tags:       d.Tags,
+			Data:       d.Data,
+			SortWeight: d.SortWeight,
+		}
+		bundle.Dashboards = append(bundle.Dashboards, &models.BundleDashboard{Dashboard: dash, FolderPath: d.FolderPath})
+
+		walkDatasourceRefs(dash.Data.Interface(), func(id int64) int64 {
+			dsIds[id] = true
+			return id
+		})
+
+		raw, err := dash.Data.Encode()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range teamVars {
+			if isVariableUsed(string(raw), v.Name) {
+				usedVars[v.Name] = true
+			}
+		}
+	}
+
+	for _, v := range teamVars {
+		if !usedVars[v.Name] {
+			continue
+		}
+		v.Id = 0
+		v.TeamId = 0
+		bundle.Variables = append(bundle.Variables, v)
+		if v.Datasource != 0 {
+			dsIds[int64(v.Datasource)] = true
+		}
+	}
+
+	for id := range dsIds {
+		ds, err := datasource.GetDatasource(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				// the reference is broken already, nothing can be done when importing
+				continue
+			}
+			return nil, fmt.Errorf("query datasource error: %w", err)
+		}
+		bundle.Datasources = append(bundle.Datasources, &models.BundleDatasource{Id: id, Name: ds.Name, Type: ds.Type})
+	}
+
+	return bundle, nil
+}
+
+type ImportReq struct {
+	TeamId int64 `json:"teamId"`
+	// dashboards are imported into this folder, default to the root folder of team
+	FolderId int64                   `json:"folderId"`
+	Bundle   *models.DashboardBundle `json:"bundle"`
+	// name of datasource in bundle -> id of datasource in target team,
+	// datasources not in it are matched by name
+	DatasourceMapping map[string]int64 `json:"datasourceMapping"`
+	// how to handle the dashboards and variables which already exist: skip, overwrite or new,
+	// when empty, the import fails if there is any conflict
+	OnConflict string `json:"onConflict"`
+}
+
+type ImportResult struct {
+	// source dashboard id -> imported dashboard id
+	Imported          map[string]string `json:"imported"`
+	Skipped           []string          `json:"skipped"`
+	ImportedVariables []string          `json:"importedVariables"`
+	SkippedVariables  []string          `json:"skippedVariables"`
+}
+
+// Import imports a dashboard bundle into a team, the datasources referenced in bundle are remapped to the ones in team
+func Import(c *gin.Context) {
+	req := &ImportReq{}
+	err := c.Bind(&req)
+	if err != nil || req.Bundle == nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	bundle := req.Bundle
+	if bundle.Version != models.DashboardBundleVersion {
+		c.JSON(http.StatusBadRequest, common.RespError(fmt.Sprintf("unsupported bundle version %d", bundle.Version)))
+		return
+	}
+
+	switch req.OnConflict {
+	case "", models.ImportConflictSkip, models.ImportConflictOverwrite, models.ImportConflictNew:
+	default:
+		c.JSON(http.StatusBadRequest, common.RespError("invalid conflict policy"))
+		return
+	}
+
+	for _, dash := range bundle.Dashboards {
+		if dash.Dashboard == nil || dash.Title == "" || dash.Data == nil {
+			c.JSON(http.StatusBadRequest, common.RespError("invalid dashboard in bundle"))
+			return
+		}
+
+		// bundles exported by old versions are upgraded to current schema
+		err := models.CheckDashboardData(dash.Data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.RespErrorWithData(fmt.Sprintf("dashboard `%s`: %s", dash.Title, err), err))
+			return
+		}
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	ctx := c.Request.Context()
+	folder, err := queryTargetFolder(c, req.TeamId, req.FolderId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	err = acl.CanEditFolder(ctx, folder, u.Id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	dsMapping, missing, err := mapBundleDatasources(ctx, req.TeamId, bundle.Datasources, req.DatasourceMapping)
+	if err != nil {
+		logger.Warn("map bundle datasources error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, common.RespErrorWithData("datasources not found in target team", missing))
+		return
+	}
+
+	teamVars, err := variables.GetTeamVariables(ctx, req.TeamId)
+	if err != nil {
+		logger.Warn("query team variables error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+	existVars := make(map[string]*models.Variable)
+	for _, v := range teamVars {
+		existVars[v.Name] = v
+	}
+
+	// conflicts are checked before any change, so nothing is imported when the conflict policy is missing
+	conflicts := make([]string, 0)
+	existDashboards := make(map[string]*models.Dashboard)
+	for _, dash := range bundle.Dashboards {
+		exist, err := models.QueryDashboard(ctx, dash.Id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			logger.Warn("query dashboard error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+		existDashboards[dash.Id] = exist
+		conflicts = append(conflicts, dash.Id)
+	}
+	for _, v := range bundle.Variables {
+		if existVars[v.Name] != nil {
+			conflicts = append(conflicts, "variable:"+v.Name)
+		}
+	}
+	if len(conflicts) > 0 && req.OnConflict == "" {
+		c.JSON(http.StatusConflict, common.RespErrorWithData("dashboards or variables already exist", conflicts))
+		return
+	}
+
+	if len(bundle.Variables) > 0 {
+		// team variables are shared by all the dashboards of team
+		err = acl.CanEditTeam(ctx, req.TeamId, u.Id)
+		if err != nil {
+			c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+			return
+		}
+	}
+
+	if req.OnConflict == models.ImportConflictOverwrite {
+		for _, exist := range existDashboards {
+			if exist.OwnedBy != req.TeamId {
+				c.JSON(http.StatusBadRequest, common.RespError(fmt.Sprintf("dashboard `%s` belongs to another team, it can't be overwritten", exist.Id)))
+				return
+			}
+			err = acl.CanEditDashboard(ctx, exist, u)
+			if err != nil {
+				c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+				return
+			}
+			if !checkProvisioned(c, exist.Id) {
+				return
+			}
+		}
+	}
+
+	// folders are created before the transaction, they are harmless if the import fails
+	folders := make(map[string]*models.Folder)
+	for _, dash := range bundle.Dashboards {
+		key := fmt.Sprint(dash.FolderPath)
+		if folders[key] != nil {
+			continue
+		}
+		f, err := models.EnsureFolderPath(ctx, folder, dash.FolderPath, u.Id)
+		if err != nil {
+			logger.Warn("create folder error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+		folders[key] = f
+	}
+
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		logger.Warn("start sql transaction error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+	defer tx.Rollback()
+
+	result := &ImportResult{
+		Imported:          make(map[string]string),
+		Skipped:           make([]string, 0),
+		ImportedVariables: make([]string, 0),
+		SkippedVariables:  make([]string, 0),
+	}
+
+	now := time.Now()
+	for _, v := range bundle.Variables {
+		ds := int(mapDatasource(dsMapping, int64(v.Datasource)))
+
+		exist := existVars[v.Name]
+		if exist != nil && req.OnConflict != models.ImportConflictOverwrite {
+			// variables are referenced by name, so a new one can't be created for the `new` policy
+			result.SkippedVariables = append(result.SkippedVariables, v.Name)
+			continue
+		}
+
+		if exist != nil {
+			_, err = tx.ExecContext(ctx, "UPDATE variable SET type=?,value=?,default_selected=?,datasource=?,description=?,refresh=?,enableMulti=?,enableAll=?,regex=?,sort=?,updated=? WHERE id=?",
+				v.Type, v.Value, v.Default, ds, v.Desc, v.Refresh, v.EnableMulti, v.EnableAll, v.Regex, v.SortWeight, now, exist.Id)
+		} else {
+			_, err = tx.ExecContext(ctx, "INSERT INTO variable(name,type,value,default_selected,datasource,description,refresh,enableMulti,enableAll,regex,sort,team_id,created,updated) VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
+				v.Name, v.Type, v.Value, v.Default, ds, v.Desc, v.Refresh, v.EnableMulti, v.EnableAll, v.Regex, v.SortWeight, req.TeamId, now, now)
+		}
+		if err != nil {
+			logger.Warn("import variable error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+		result.ImportedVariables = append(result.ImportedVariables, v.Name)
+	}
+
+	histories := make([]*models.DashboardHistory, 0, len(bundle.Dashboards))
+	for _, d := range bundle.Dashboards {
+		dash := d.Dashboard
+		sourceId := dash.Id
+		exist := existDashboards[sourceId]
+		if exist != nil && req.OnConflict == models.ImportConflictSkip {
+			result.Skipped = append(result.Skipped, sourceId)
+			continue
+		}
+		if (exist != nil && req.OnConflict == models.ImportConflictNew) || dash.Id == "" {
+			dash.Id = models.DashboardIdPrefix + utils.GenerateShortUID()
+			exist = nil
+		}
+
+		walkDatasourceRefs(dash.Data.Interface(), func(id int64) int64 {
+			return mapDatasource(dsMapping, id)
+		})
+		jsonData, err := dash.Data.Encode()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+			return
+		}
+		tags, _ := json.Marshal(dash.Tags)
+		if dash.VisibleTo == "" {
+			dash.VisibleTo = models.TeamVisible
+		}
+
+		target := folders[fmt.Sprint(d.FolderPath)]
+		if exist != nil {
+			// the dashboard stays in its folder when overwritten
+			_, err = tx.ExecContext(ctx, `UPDATE dashboard SET title=?,tags=?,data=?,visible_to=?,updated=?,version=version+1,updated_by=? WHERE id=?`,
+				dash.Title, tags, jsonData, dash.VisibleTo, now, u.Id, dash.Id)
+		} else {
+			_, err = tx.ExecContext(ctx, `INSERT INTO dashboard (id,title, team_id,folder_id,visible_to, created_by,tags, data,created,updated,version,updated_by) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`,
+				dash.Id, dash.Title, req.TeamId, target.Id, dash.VisibleTo, u.Id, tags, jsonData, now, now, 1, u.Id)
+		}
+		if err != nil {
+			logger.Warn("import dashboard error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+
+		err = models.UpdateLibraryPanelConnections(ctx, tx, dash.Id, dash.Data)
+		if err != nil {
+			logger.Warn("update library panel connections error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+
+		err = models.UpdateDashboardSearch(ctx, tx, dash)
+		if err != nil {
+			logger.Warn("update dashboard search index error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+
+		dash.OwnedBy = req.TeamId
+		dash.Updated = &now
+		histories = append(histories, &models.DashboardHistory{
+			Dashboard: dash,
+			Changes:   "Imported from bundle",
+			Author:    u.Id,
+		})
+		result.Imported[sourceId] = dash.Id
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Warn("commit sql transaction error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+
+	for _, h := range histories {
+		h.Dashboard.Version, _, _, err = models.QueryDashboardVersion(ctx, h.Dashboard.Id)
+		if err != nil {
+			logger.Warn("query dashboard version error", "error", err)
+		}
+		historyCh <- h
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(result))
+}
+
+// mapBundleDatasources returns the mapping from the datasource ids in bundle to the ones in team,
+// and the names of datasources which can't be found in team
+func mapBundleDatasources(ctx context.Context, teamId int64, dss []*models.BundleDatasource, mapping map[string]int64) (map[int64]int64, []string, error) {
+	teamDss, err := datasource.GetDatasourcesByTeamId(ctx, teamId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byName := make(map[string]int64)
+	byId := make(map[int64]bool)
+	for _, ds := range teamDss {
+		byName[ds.Name] = ds.Id
+		byId[ds.Id] = true
+	}
+
+	res := make(map[int64]int64)
+	missing := make([]string, 0)
+	for _, ds := range dss {
+		id, ok := mapping[ds.Name]
+		if !ok {
+			id, ok = byName[ds.Name]
+		}
+		if !ok || !byId[id] {
+			missing = append(missing, ds.Name)
+			continue
+		}
+		res[ds.Id] = id
+	}
+
+	return res, missing, nil
+}
+
+// mapDatasource keeps the references which are not in the bundle, they are broken before exporting
+func mapDatasource(mapping map[int64]int64, id int64) int64 {
+	if newId, ok := mapping[id]; ok {
+		return newId
+	}
+
+	return id
+}
+
+// walkDatasourceRefs calls fn with the datasource ids referenced in dashboard data and replaces them with the returned ones,
+// a reference is a `datasource` field which is either an id(e.g variable.datasource) or an object with an id(e.g panel.datasource.id)
+func walkDatasourceRefs(v interface{}, fn func(id int64) int64) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if k == "datasource" {
+				if id, ok := datasourceId(child); ok {
+					v[k] = json.Number(strconv.FormatInt(fn(id), 10))
+					continue
+				}
+				if ds, ok := child.(map[string]interface{}); ok {
+					if id, ok := datasourceId(ds["id"]); ok {
+						ds["id"] = json.Number(strconv.FormatInt(fn(id), 10))
+					}
+				}
+			}
+			walkDatasourceRefs(child, fn)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkDatasourceRefs(child, fn)
+		}
+	}
+}
+
+func datasourceId(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		id, err := v.Int64()
+		return id, err == nil && id != 0
+	case float64:
+		return int64(v), v != 0
+	}
+
+	return 0, false
+}
+
+// isVariableUsed checks whether a variable is referenced in the form of $name or ${name}
+func isVariableUsed(raw string, name string) bool {
+	r, err := regexp.Compile(`\$\{?` + regexp.QuoteMeta(name) + `([^a-zA-Z0-9_-]|$)`)
+	if err != nil {
+		return false
+	}
+
+	return r.MatchString(raw)
+}
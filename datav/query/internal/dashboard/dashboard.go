@@ -15,9 +15,9 @@ package dashboard
 import (
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -48,6 +48,14 @@ func SaveDashboard(c *gin.Context) {
 	}
 
 	dash := req.Dashboard
+	req.Author = u.Id
+
+	// dashboards saved by old ui are upgraded to current schema before validating
+	err = models.CheckDashboardData(dash.Data)
+	if err != nil {
+		respSchemaError(c, err)
+		return
+	}
 
 	now := time.Now()
 	isUpdate := dash.Id != ""
@@ -55,8 +63,16 @@ func SaveDashboard(c *gin.Context) {
 		dash.Id = "d-" + utils.GenerateShortUID()
 		dash.CreatedBy = u.Id
 		dash.Created = &now
+
+		folder, err := queryTargetFolder(c, dash.OwnedBy, dash.FolderId)
+		if err != nil {
+			c.JSON(400, common.RespError(err.Error()))
+			return
+		}
+		dash.FolderId = folder.Id
 	} else {
-		belongs, err := models.QueryDashboardBelongsTo(c.Request.Context(), dash.Id)
+		// dashboards are moved to other folders by the move api
+		belongs, folderId, err := models.QueryDashboardFolder(c.Request.Context(), dash.Id)
 		if err != nil {
 			logger.Error("query dashboarde owner error", "error", err)
 			c.JSON(500, common.RespInternalError())
@@ -64,6 +80,11 @@ func SaveDashboard(c *gin.Context) {
 		}
 
 		dash.OwnedBy = belongs
+		dash.FolderId = folderId
+
+		if !checkProvisioned(c, dash.Id) {
+			return
+		}
 	}
 
 	err = acl.CanEditDashboard(c.Request.Context(), dash, u)
@@ -74,6 +95,12 @@ func SaveDashboard(c *gin.Context) {
 
 	dash.Updated = &now
 
+	err = models.CheckLibraryPanelRefs(c.Request.Context(), dash.OwnedBy, dash.Data)
+	if err != nil {
+		c.JSON(400, common.RespError(err.Error()))
+		return
+	}
+
 	jsonData, err := dash.Data.Encode()
 	if err != nil {
 		logger.Warn("decode dashboard data error", "error", err)
@@ -88,8 +115,9 @@ func SaveDashboard(c *gin.Context) {
 		return
 	}
 	if !isUpdate {
-		_, err := db.Conn.ExecContext(c.Request.Context(), `INSERT INTO dashboard (id,title, team_id,visible_to, created_by,tags, data,created,updated) VALUES (?,?,?,?,?,?,?,?,?)`,
-			dash.Id, dash.Title, dash.OwnedBy, dash.VisibleTo, dash.CreatedBy, tags, jsonData, dash.Created, dash.Updated)
+		dash.Version = 1
+		_, err := db.Conn.ExecContext(c.Request.Context(), `INSERT INTO dashboard (id,title, team_id,folder_id,visible_to, created_by,tags, data,created,updated,version,updated_by) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`,
+			dash.Id, dash.Title, dash.OwnedBy, dash.FolderId, dash.VisibleTo, dash.CreatedBy, tags, jsonData, dash.Created, dash.Updated, dash.Version, u.Id)
 		if err != nil {
 			if e.IsErrUniqueConstraint(err) {
 				c.JSON(409, common.RespError("dashboard id already exists"))
@@ -100,8 +128,21 @@ func SaveDashboard(c *gin.Context) {
 			return
 		}
 	} else {
-		res, err := db.Conn.ExecContext(c.Request.Context(), `UPDATE dashboard SET title=?,tags=?,data=?,team_id=?,visible_to=?,updated=? WHERE id=?`,
-			dash.Title, tags, jsonData, dash.OwnedBy, dash.VisibleTo, dash.Updated, dash.Id)
+		// when force is true, the dashboard is overwritten no matter it has been updated by others or not
+		force := c.Query("force") == "true"
+		if !force && dash.Version == 0 {
+			c.JSON(http.StatusBadRequest, common.RespError("dashboard version is required when updating dashboard"))
+			return
+		}
+
+		query := `UPDATE dashboard SET title=?,tags=?,data=?,team_id=?,visible_to=?,updated=?,version=version+1,updated_by=? WHERE id=?`
+		args := []interface{}{dash.Title, tags, jsonData, dash.OwnedBy, dash.VisibleTo, dash.Updated, u.Id, dash.Id}
+		if !force {
+			query += " AND version=?"
+			args = append(args, dash.Version)
+		}
+
+		res, err := db.Conn.ExecContext(c.Request.Context(), query, args...)
 		if err != nil {
 			logger.Error("update dashboard error", "error", err)
 			c.JSON(500, common.RespInternalError())
@@ -109,14 +150,71 @@ func SaveDashboard(c *gin.Context) {
 		}
 		affected, _ := res.RowsAffected()
 		if affected == 0 {
-			c.JSON(http.StatusBadRequest, common.RespError("dashboard id not exist"))
+			respVersionConflict(c, dash)
 			return
 		}
+
+		dash.Version, _, _, err = models.QueryDashboardVersion(c.Request.Context(), dash.Id)
+		if err != nil {
+			logger.Warn("query dashboard version error", "error", err)
+		}
+	}
+
+	err = models.UpdateLibraryPanelConnections(c.Request.Context(), db.Conn, dash.Id, dash.Data)
+	if err != nil {
+		logger.Warn("update library panel connections error", "error", err)
+	}
+
+	err = models.UpdateDashboardSearch(c.Request.Context(), db.Conn, dash)
+	if err != nil {
+		logger.Warn("update dashboard search index error", "error", err)
 	}
 
 	historyCh <- req
 
-	c.JSON(200, common.RespSuccess(dash.Id))
+	c.JSON(200, common.RespSuccess(map[string]interface{}{
+		"id":      dash.Id,
+		"version": dash.Version,
+	}))
+}
+
+// respSchemaError returns the paths and reasons of invalid dashboard fields
+func respSchemaError(c *gin.Context, err error) {
+	if errs, ok := err.(models.DashboardSchemaErrors); ok {
+		c.JSON(http.StatusBadRequest, common.RespErrorWithData(errs.Error(), errs))
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+}
+
+// respVersionConflict is called when a dashboard can't be updated with the given version,
+// the newer version is returned, so users can choose to reload it or force overwrite it
+func respVersionConflict(c *gin.Context, dash *models.Dashboard) {
+	version, updatedBy, updated, err := models.QueryDashboardVersion(c.Request.Context(), dash.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, common.RespError("dashboard id not exist"))
+			return
+		}
+		logger.Warn("query dashboard version error", "error", err)
+		c.JSON(500, common.RespInternalError())
+		return
+	}
+
+	conflict := &models.DashboardVersionConflict{
+		Version:   version,
+		UpdatedBy: updatedBy,
+		Updated:   updated,
+	}
+	if updatedBy != 0 {
+		user, err := models.QueryUserById(c.Request.Context(), updatedBy)
+		if err == nil {
+			conflict.UpdatedByName = user.Username
+		}
+	}
+
+	c.JSON(http.StatusConflict, common.RespErrorWithData("dashboard has been updated by others", conflict))
 }
 
 func GetDashboard(c *gin.Context) {
@@ -142,34 +240,26 @@ func QueryDashboard(c *gin.Context, u *models.User) (*models.Dashboard, error) {
 		return nil, fmt.Errorf("query dashboard error" + err.Error())
 	}
 
-	if dash.VisibleTo == models.TeamVisible {
-		if u == nil {
-			return nil, errors.New("you are not the team menber to view this dashboard")
-		}
-
-		_, err := models.QueryTeamMember(c.Request.Context(), dash.OwnedBy, u.Id)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return nil, errors.New("you are not the team menber to view this dashboard")
-			}
-			return nil, fmt.Errorf("query team member error: %w", err)
-		}
+	err = acl.CanViewDashboard(c.Request.Context(), dash, u)
+	if err != nil {
+		return nil, err
+	}
 
-	} else if dash.VisibleTo == models.TenantVisible {
-		tenantId, err := models.QueryTenantIdByTeamId(c.Request.Context(), dash.OwnedBy)
-		if err != nil {
-			return nil, fmt.Errorf("query tenant id error: %w", err)
-		}
+	err = models.ResolveLibraryPanels(c.Request.Context(), dash.OwnedBy, dash.Data)
+	if err != nil {
+		return nil, fmt.Errorf("resolve library panels error: %w", err)
+	}
 
-		// check user is in tenant
-		in, err := models.IsUserInTenant(u.Id, tenantId)
-		if err != nil {
-			return nil, fmt.Errorf("check user in tenant error: %w", err)
-		}
+	// dashboard can be edited in ui only when the user has the edit permission
+	dash.Editable = acl.CanEditDashboard(c.Request.Context(), dash, u) == nil
 
-		if !in {
-			return nil, errors.New("you are not the tenant menber to view this dashboard")
-		}
+	provisioning, err := models.QueryDashboardProvisioning(c.Request.Context(), dash.Id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("query dashboard provisioning error: %w", err)
+	}
+	if provisioning != nil {
+		dash.Provisioned = true
+		dash.Editable = dash.Editable && provisioning.AllowUiUpdates
 	}
 
 	return dash, nil
@@ -196,7 +286,7 @@ func GetTeamDashboards(c *gin.Context) {
 
 	dashboards := make([]*models.Dashboard, 0)
 
-	rows, err := db.Conn.QueryContext(c.Request.Context(), "SELECT id,title, created, updated FROM dashboard WHERE team_id=?", teamId)
+	rows, err := db.Conn.QueryContext(c.Request.Context(), "SELECT id,title,folder_id, created, updated FROM dashboard WHERE team_id=?", teamId)
 	if err != nil {
 		logger.Warn("query team dashboards error", "error", err)
 		c.JSON(500, common.RespError(e.Internal))
@@ -205,7 +295,7 @@ func GetTeamDashboards(c *gin.Context) {
 	defer rows.Close()
 	for rows.Next() {
 		dash := &models.Dashboard{}
-		err = rows.Scan(&dash.Id, &dash.Title, &dash.Created, &dash.Updated)
+		err = rows.Scan(&dash.Id, &dash.Title, &dash.FolderId, &dash.Created, &dash.Updated)
 		if err != nil {
 			logger.Warn("scan dashboard error", "error", err)
 			c.JSON(500, common.RespError(e.Internal))
@@ -218,6 +308,8 @@ func GetTeamDashboards(c *gin.Context) {
 	c.JSON(200, common.RespSuccess(dashboards))
 }
 
+// Search returns the dashboards visible to current user in a tenant, they can be filtered by team, folder, tag and stars,
+// when `query` is given, only the dashboards whose titles, tags, panels or queries matching it are returned, ordered by relevance
 func Search(c *gin.Context) {
 	tenantId, _ := strconv.ParseInt(c.Param("tenantId"), 10, 64)
 	if tenantId == 0 {
@@ -237,13 +329,91 @@ func Search(c *gin.Context) {
 		c.JSON(500, common.RespError(e.Internal))
 		return
 	}
-	dashboards := make([]*models.Dashboard, 0)
-	teamIds := make([]string, 0)
-	for _, team := range teams {
-		teamIds = append(teamIds, strconv.FormatInt(team, 10))
+	dashboards := make([]*models.DashboardSearchResult, 0)
+
+	// dashboards in the folders granted to user are also visible
+	grantedFolders, err := queryGrantedFolderIds(c, tenantId, u.Id)
+	if err != nil {
+		logger.Warn("query granted folders error", "error", err)
+		c.JSON(500, common.RespError(e.Internal))
+		return
 	}
 
-	rows, err := db.Conn.QueryContext(c.Request.Context(), fmt.Sprintf("SELECT dashboard.id,dashboard.title, dashboard.team_id,team.name,dashboard.visible_to, dashboard.tags, dashboard.weight FROM dashboard INNER JOIN team ON dashboard.team_id = team.id WHERE dashboard.team_id in ('%s') ORDER BY dashboard.weight DESC,dashboard.created DESC", strings.Join(teamIds, "','")))
+	grantedDashboards, err := models.QueryGrantedDashboards(c.Request.Context(), u.Id)
+	if err != nil {
+		logger.Warn("query granted dashboards error", "error", err)
+		c.JSON(500, common.RespError(e.Internal))
+		return
+	}
+
+	where := fmt.Sprintf("(dashboard.team_id in (%s)", joinIds(teams))
+	args := make([]interface{}, 0)
+	if len(grantedFolders) > 0 {
+		where += fmt.Sprintf(" OR dashboard.folder_id in (%s)", joinIds(grantedFolders))
+	}
+	if len(grantedDashboards) > 0 {
+		for id := range grantedDashboards {
+			args = append(args, id)
+		}
+		where += fmt.Sprintf(" OR dashboard.id in (%s)", strings.TrimSuffix(strings.Repeat("?,", len(grantedDashboards)), ","))
+	}
+	// dashboards granted to user may belong to other tenants
+	where += ") AND team.tenant_id=?"
+	args = append(args, tenantId)
+
+	// search in a folder and its sub folders
+	folderId, _ := strconv.ParseInt(c.Query("folderId"), 10, 64)
+	if folderId != 0 {
+		folder, err := models.QueryFolder(c.Request.Context(), folderId)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, common.RespError("folder not found"))
+				return
+			}
+			logger.Warn("query folder error", "error", err)
+			c.JSON(500, common.RespError(e.Internal))
+			return
+		}
+
+		folders, err := models.QueryTeamFolders(c.Request.Context(), folder.TeamId)
+		if err != nil {
+			logger.Warn("query team folders error", "error", err)
+			c.JSON(500, common.RespError(e.Internal))
+			return
+		}
+		where += fmt.Sprintf(" AND dashboard.folder_id in (%s)", joinIds(models.FolderDescendants(folders, folder.Id)))
+	}
+
+	teamId, _ := strconv.ParseInt(c.Query("teamId"), 10, 64)
+	if teamId != 0 {
+		where += " AND dashboard.team_id=?"
+		args = append(args, teamId)
+	}
+
+	if c.Query("starred") == "true" {
+		where += " AND dashboard.id IN (SELECT dashboard_id FROM star_dashboard WHERE user_id=?)"
+		args = append(args, u.Id)
+	}
+
+	terms := models.ParseSearchTerms(c.Query("query"))
+	var scores map[string]float64
+	if len(terms) > 0 {
+		// only the dashboards visible in current scope are searched
+		scores, err = models.SearchDashboardIds(c.Request.Context(), terms, where, args...)
+		if err != nil {
+			logger.Warn("search dashboards error", "error", err)
+			c.JSON(500, common.RespError(e.Internal))
+			return
+		}
+		if len(scores) == 0 {
+			c.JSON(http.StatusOK, common.RespSuccess(dashboards))
+			return
+		}
+	}
+
+	tag := c.Query("tag")
+
+	rows, err := db.Conn.QueryContext(c.Request.Context(), fmt.Sprintf("SELECT dashboard.id,dashboard.title, dashboard.team_id,team.name,dashboard.folder_id,dashboard.visible_to, dashboard.tags, dashboard.weight FROM dashboard INNER JOIN team ON dashboard.team_id = team.id WHERE %s ORDER BY dashboard.weight DESC,dashboard.created DESC", where), args...)
 	if err != nil {
 		logger.Warn("query simple dashboards error", "error", err)
 		c.JSON(500, common.RespError(e.Internal))
@@ -253,7 +423,7 @@ func Search(c *gin.Context) {
 	for rows.Next() {
 		dash := &models.Dashboard{}
 		var rawTags []byte
-		err = rows.Scan(&dash.Id, &dash.Title, &dash.OwnedBy, &dash.OwnerName, &dash.VisibleTo, &rawTags, &dash.SortWeight)
+		err = rows.Scan(&dash.Id, &dash.Title, &dash.OwnedBy, &dash.OwnerName, &dash.FolderId, &dash.VisibleTo, &rawTags, &dash.SortWeight)
 		if err != nil {
 			logger.Warn("get simple dashboards scan error", "error", err)
 			c.JSON(500, common.RespError(e.Internal))
@@ -268,27 +438,119 @@ func Search(c *gin.Context) {
 		}
 		dash.Tags = tags
 
-		dashboards = append(dashboards, dash)
+		if tag != "" && !hasTag(tags, tag) {
+			continue
+		}
+		if len(terms) > 0 {
+			if _, ok := scores[dash.Id]; !ok {
+				continue
+			}
+		}
+
+		dashboards = append(dashboards, &models.DashboardSearchResult{Dashboard: dash, Score: scores[dash.Id]})
+	}
+
+	if len(terms) > 0 {
+		// dashboards with the same score are still ordered by weight and creation time
+		sort.SliceStable(dashboards, func(i, j int) bool {
+			return dashboards[i].Score > dashboards[j].Score
+		})
+
+		ids := make([]string, 0, len(dashboards))
+		for _, dash := range dashboards {
+			ids = append(ids, dash.Id)
+		}
+		docs, err := models.QueryDashboardSearchDocs(c.Request.Context(), ids)
+		if err != nil {
+			logger.Warn("query dashboard search docs error", "error", err)
+			c.JSON(500, common.RespError(e.Internal))
+			return
+		}
+		for _, dash := range dashboards {
+			dash.Highlights = models.HighlightDashboardSearch(docs[dash.Id], terms)
+		}
 	}
 
 	c.JSON(http.StatusOK, common.RespSuccess(dashboards))
 }
 
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// queryGrantedFolderIds returns the folders in the tenant which are granted to the user, including their sub folders
+func queryGrantedFolderIds(c *gin.Context, tenantId int64, userId int64) ([]int64, error) {
+	granted, err := models.QueryGrantedFolders(c.Request.Context(), userId)
+	if err != nil || len(granted) == 0 {
+		return nil, err
+	}
+
+	teamIds, err := models.QueryTenantAllTeamIds(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	tenantTeams := make(map[int64]bool)
+	for _, id := range teamIds {
+		tenantTeams[id] = true
+	}
+
+	teamFolders := make(map[int64][]*models.Folder)
+	ids := make([]int64, 0)
+	for folderId := range granted {
+		folder, err := models.QueryFolder(c.Request.Context(), folderId)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return nil, err
+		}
+		if !tenantTeams[folder.TeamId] {
+			continue
+		}
+
+		folders, ok := teamFolders[folder.TeamId]
+		if !ok {
+			folders, err = models.QueryTeamFolders(c.Request.Context(), folder.TeamId)
+			if err != nil {
+				return nil, err
+			}
+			teamFolders[folder.TeamId] = folders
+		}
+		ids = append(ids, models.FolderDescendants(folders, folder.Id)...)
+	}
+
+	return ids, nil
+}
+
+func joinIds(ids []int64) string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, strconv.FormatInt(id, 10))
+	}
+
+	return "'" + strings.Join(strs, "','") + "'"
+}
+
 func Star(c *gin.Context) {
 	id := c.Param("id")
 	u := c.MustGet("currentUser").(*models.User)
-	teamId, err := models.QueryDashboardBelongsTo(c.Request.Context(), id)
+	dash, err := models.QueryDashboard(c.Request.Context(), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(404, common.RespError(e.TeamNotExist))
+			c.JSON(404, common.RespError(e.NotFound))
 			return
 		}
-		logger.Warn("query dashboard belongs to error", "error", err)
+		logger.Warn("query dashboard error", "error", err)
 		c.JSON(500, common.RespError(e.Internal))
 		return
 	}
 
-	if err := acl.CanViewTeam(c.Request.Context(), teamId, u.Id); err != nil {
+	if err := acl.CanViewDashboard(c.Request.Context(), dash, u); err != nil {
 		c.JSON(403, common.RespError(err.Error()))
 		return
 	}
@@ -388,6 +650,10 @@ func Delete(c *gin.Context) {
 		return
 	}
 
+	if !checkProvisioned(c, id) {
+		return
+	}
+
 	tx, err := db.Conn.Begin()
 	if err != nil {
 		logger.Warn("start sql transaction error", "error", err)
@@ -415,6 +681,23 @@ func Delete(c *gin.Context) {
 	c.JSON(200, common.RespSuccess(nil))
 }
 
+// checkProvisioned responds with an error when the dashboard is provisioned and not allowed to be changed in ui
+func checkProvisioned(c *gin.Context, id string) bool {
+	readonly, err := models.IsDashboardReadonly(c.Request.Context(), id)
+	if err != nil {
+		logger.Warn("query dashboard provisioning error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return false
+	}
+
+	if readonly {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ProvisionedDashboard))
+		return false
+	}
+
+	return true
+}
+
 type DashboardReq struct {
 	Id     string `json:"id"`
 	Weight int    `json:"weight"`
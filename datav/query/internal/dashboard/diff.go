@@ -0,0 +1,173 @@
+package dashboard
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// diffDashboards compares two versions of a dashboard, panels are matched by id and variables by name,
+// the panels in rows are compared as well as top level panels
+func diffDashboards(from, to *models.Dashboard) *models.DashboardDiff {
+	diff := &models.DashboardDiff{
+		Fields: make([]*models.DashboardFieldChange, 0),
+	}
+
+	if from.Title != to.Title {
+		diff.Fields = append(diff.Fields, &models.DashboardFieldChange{Field: "title", From: from.Title, To: to.Title})
+	}
+	if !reflect.DeepEqual(from.Tags, to.Tags) {
+		diff.Fields = append(diff.Fields, &models.DashboardFieldChange{Field: "tags", From: from.Tags, To: to.Tags})
+	}
+	if from.VisibleTo != to.VisibleTo {
+		diff.Fields = append(diff.Fields, &models.DashboardFieldChange{Field: "visibleTo", From: from.VisibleTo, To: to.VisibleTo})
+	}
+
+	// histories saved without data are compared as empty dashboards
+	fromData := make(map[string]interface{})
+	if from.Data != nil {
+		fromData = from.Data.MustMap()
+	}
+	toData := make(map[string]interface{})
+	if to.Data != nil {
+		toData = to.Data.MustMap()
+	}
+	for _, key := range unionKeys(fromData, toData) {
+		if key == "panels" || key == "variables" {
+			continue
+		}
+		if !reflect.DeepEqual(fromData[key], toData[key]) {
+			diff.Fields = append(diff.Fields, &models.DashboardFieldChange{Field: "data." + key, From: fromData[key], To: toData[key]})
+		}
+	}
+
+	panelKey := func(panel map[string]interface{}) string { return fmt.Sprint(panel["id"]) }
+	fromPanels, fromPanelsOrder := indexItems(flattenPanels(fromData["panels"]), panelKey)
+	toPanels, toPanelsOrder := indexItems(flattenPanels(toData["panels"]), panelKey)
+	diff.Panels.Added = make([]*models.DashboardPanelRef, 0)
+	diff.Panels.Removed = make([]*models.DashboardPanelRef, 0)
+	diff.Panels.Changed = make([]*models.DashboardPanelRef, 0)
+	for _, key := range fromPanelsOrder {
+		if _, ok := toPanels[key]; !ok {
+			diff.Panels.Removed = append(diff.Panels.Removed, newPanelRef(fromPanels[key], nil))
+		}
+	}
+	for _, key := range toPanelsOrder {
+		fromPanel, ok := fromPanels[key]
+		if !ok {
+			diff.Panels.Added = append(diff.Panels.Added, newPanelRef(toPanels[key], nil))
+			continue
+		}
+		if fields := changedFields(fromPanel, toPanels[key]); len(fields) > 0 {
+			diff.Panels.Changed = append(diff.Panels.Changed, newPanelRef(toPanels[key], fields))
+		}
+	}
+
+	variableKey := func(variable map[string]interface{}) string { return fmt.Sprint(variable["name"]) }
+	fromVariables, fromVariablesOrder := indexItems(fromData["variables"], variableKey)
+	toVariables, toVariablesOrder := indexItems(toData["variables"], variableKey)
+	diff.Variables.Added = make([]*models.DashboardVariableRef, 0)
+	diff.Variables.Removed = make([]*models.DashboardVariableRef, 0)
+	diff.Variables.Changed = make([]*models.DashboardVariableRef, 0)
+	for _, key := range fromVariablesOrder {
+		if _, ok := toVariables[key]; !ok {
+			diff.Variables.Removed = append(diff.Variables.Removed, &models.DashboardVariableRef{Name: key})
+		}
+	}
+	for _, key := range toVariablesOrder {
+		fromVariable, ok := fromVariables[key]
+		if !ok {
+			diff.Variables.Added = append(diff.Variables.Added, &models.DashboardVariableRef{Name: key})
+			continue
+		}
+		if fields := changedFields(fromVariable, toVariables[key]); len(fields) > 0 {
+			diff.Variables.Changed = append(diff.Variables.Changed, &models.DashboardVariableRef{Name: key, Fields: fields})
+		}
+	}
+
+	return diff
+}
+
+// flattenPanels returns the panels and the panels in rows in a single list, rows are returned without
+// their panels, so a row is changed only when its own fields are changed
+func flattenPanels(items interface{}) []interface{} {
+	list, _ := items.([]interface{})
+	panels := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		panel, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		children, ok := panel["panels"].([]interface{})
+		if !ok {
+			panels = append(panels, panel)
+			continue
+		}
+
+		row := make(map[string]interface{}, len(panel))
+		for k, v := range panel {
+			if k != "panels" {
+				row[k] = v
+			}
+		}
+		panels = append(panels, row)
+		panels = append(panels, flattenPanels(children)...)
+	}
+
+	return panels
+}
+
+// indexItems indexes the objects in a json array by key, the order of keys is kept
+func indexItems(items interface{}, key func(map[string]interface{}) string) (map[string]map[string]interface{}, []string) {
+	index := make(map[string]map[string]interface{})
+	order := make([]string, 0)
+	list, _ := items.([]interface{})
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		k := key(m)
+		if _, ok := index[k]; ok {
+			continue
+		}
+		index[k] = m
+		order = append(order, k)
+	}
+
+	return index, order
+}
+
+func changedFields(from, to map[string]interface{}) []string {
+	fields := make([]string, 0)
+	for _, key := range unionKeys(from, to) {
+		if !reflect.DeepEqual(from[key], to[key]) {
+			fields = append(fields, key)
+		}
+	}
+
+	return fields
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func newPanelRef(panel map[string]interface{}, fields []string) *models.DashboardPanelRef {
+	title, _ := panel["title"].(string)
+	tp, _ := panel["type"].(string)
+	return &models.DashboardPanelRef{Id: panel["id"], Title: title, Type: tp, Fields: fields}
+}
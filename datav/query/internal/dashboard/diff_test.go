@@ -0,0 +1,221 @@
+package dashboard
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xObserve/xObserve/query/pkg/models"
+	"github.com/xObserve/xObserve/query/pkg/utils/simplejson"
+)
+
+func testDashboard(t *testing.T, title string, data string) *models.Dashboard {
+	t.Helper()
+	d, err := simplejson.NewJson([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Dashboard{Title: title, Tags: []string{}, Data: d}
+}
+
+func panelTitles(refs []*models.DashboardPanelRef) []string {
+	titles := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		titles = append(titles, ref.Title)
+	}
+	return titles
+}
+
+func TestDiffDashboards(t *testing.T) {
+	base := `{
+		"panels": [
+			{"id": 1, "type": "graph", "title": "p1", "gridPos": {"x": 0}},
+			{"id": 2, "type": "graph", "title": "p2"},
+			{"id": 3, "type": "row", "title": "r3", "collapsed": true, "panels": [
+				{"id": 4, "type": "graph", "title": "p4", "gridPos": {"x": 0}},
+				{"id": 5, "type": "graph", "title": "p5"}
+			]}
+		],
+		"variables": [{"name": "service", "type": "query"}],
+		"refresh": 0
+	}`
+
+	cases := []struct {
+		desc      string
+		title     string
+		data      string
+		fields    []string
+		added     []string
+		removed   []string
+		changed   []string
+		changedBy [][]string
+		variables []string
+	}{
+		{
+			desc:    "no changes",
+			title:   "dash",
+			data:    base,
+			fields:  []string{},
+			added:   []string{},
+			removed: []string{},
+			changed: []string{},
+		},
+		{
+			desc:  "title and data fields",
+			title: "new dash",
+			data: `{
+				"panels": [
+					{"id": 1, "type": "graph", "title": "p1", "gridPos": {"x": 0}},
+					{"id": 2, "type": "graph", "title": "p2"},
+					{"id": 3, "type": "row", "title": "r3", "collapsed": true, "panels": [
+						{"id": 4, "type": "graph", "title": "p4", "gridPos": {"x": 0}},
+						{"id": 5, "type": "graph", "title": "p5"}
+					]}
+				],
+				"variables": [{"name": "service", "type": "query"}],
+				"refresh": 10
+			}`,
+			fields:  []string{"title", "data.refresh"},
+			added:   []string{},
+			removed: []string{},
+			changed: []string{},
+		},
+		{
+			desc:  "top level panels",
+			title: "dash",
+			data: `{
+				"panels": [
+					{"id": 1, "type": "graph", "title": "p1", "gridPos": {"x": 12}},
+					{"id": 3, "type": "row", "title": "r3", "collapsed": true, "panels": [
+						{"id": 4, "type": "graph", "title": "p4", "gridPos": {"x": 0}},
+						{"id": 5, "type": "graph", "title": "p5"}
+					]},
+					{"id": 6, "type": "graph", "title": "p6"}
+				],
+				"variables": [{"name": "service", "type": "query"}],
+				"refresh": 0
+			}`,
+			fields:    []string{},
+			added:     []string{"p6"},
+			removed:   []string{"p2"},
+			changed:   []string{"p1"},
+			changedBy: [][]string{{"gridPos"}},
+		},
+		{
+			desc:  "panels in rows",
+			title: "dash",
+			data: `{
+				"panels": [
+					{"id": 1, "type": "graph", "title": "p1", "gridPos": {"x": 0}},
+					{"id": 2, "type": "graph", "title": "p2"},
+					{"id": 3, "type": "row", "title": "r3", "collapsed": true, "panels": [
+						{"id": 4, "type": "table", "title": "p4", "gridPos": {"x": 0}},
+						{"id": 7, "type": "graph", "title": "p7"}
+					]}
+				],
+				"variables": [{"name": "service", "type": "query"}],
+				"refresh": 0
+			}`,
+			fields:    []string{},
+			added:     []string{"p7"},
+			removed:   []string{"p5"},
+			changed:   []string{"p4"},
+			changedBy: [][]string{{"type"}},
+		},
+		{
+			desc:  "row itself",
+			title: "dash",
+			data: `{
+				"panels": [
+					{"id": 1, "type": "graph", "title": "p1", "gridPos": {"x": 0}},
+					{"id": 2, "type": "graph", "title": "p2"},
+					{"id": 3, "type": "row", "title": "r3", "collapsed": false},
+					{"id": 4, "type": "graph", "title": "p4", "gridPos": {"x": 0}},
+					{"id": 5, "type": "graph", "title": "p5"}
+				],
+				"variables": [{"name": "service", "type": "query"}],
+				"refresh": 0
+			}`,
+			fields:    []string{},
+			added:     []string{},
+			removed:   []string{},
+			changed:   []string{"r3"},
+			changedBy: [][]string{{"collapsed"}},
+		},
+		{
+			desc:  "variables",
+			title: "dash",
+			data: `{
+				"panels": [
+					{"id": 1, "type": "graph", "title": "p1", "gridPos": {"x": 0}},
+					{"id": 2, "type": "graph", "title": "p2"},
+					{"id": 3, "type": "row", "title": "r3", "collapsed": true, "panels": [
+						{"id": 4, "type": "graph", "title": "p4", "gridPos": {"x": 0}},
+						{"id": 5, "type": "graph", "title": "p5"}
+					]}
+				],
+				"variables": [{"name": "service", "type": "custom"}, {"name": "host", "type": "query"}],
+				"refresh": 0
+			}`,
+			fields:    []string{},
+			added:     []string{},
+			removed:   []string{},
+			changed:   []string{},
+			variables: []string{"host", "service"},
+		},
+	}
+
+	for _, c := range cases {
+		diff := diffDashboards(testDashboard(t, "dash", base), testDashboard(t, c.title, c.data))
+
+		fields := make([]string, 0, len(diff.Fields))
+		for _, f := range diff.Fields {
+			fields = append(fields, f.Field)
+		}
+		if !reflect.DeepEqual(fields, c.fields) {
+			t.Errorf("%s: expected changed fields %v, got %v", c.desc, c.fields, fields)
+		}
+
+		if titles := panelTitles(diff.Panels.Added); !reflect.DeepEqual(titles, c.added) {
+			t.Errorf("%s: expected added panels %v, got %v", c.desc, c.added, titles)
+		}
+		if titles := panelTitles(diff.Panels.Removed); !reflect.DeepEqual(titles, c.removed) {
+			t.Errorf("%s: expected removed panels %v, got %v", c.desc, c.removed, titles)
+		}
+		if titles := panelTitles(diff.Panels.Changed); !reflect.DeepEqual(titles, c.changed) {
+			t.Errorf("%s: expected changed panels %v, got %v", c.desc, c.changed, titles)
+		}
+		for i, fields := range c.changedBy {
+			if i < len(diff.Panels.Changed) && !reflect.DeepEqual(diff.Panels.Changed[i].Fields, fields) {
+				t.Errorf("%s: expected panel fields %v, got %v", c.desc, fields, diff.Panels.Changed[i].Fields)
+			}
+		}
+
+		if c.variables != nil {
+			variables := make([]string, 0)
+			for _, v := range diff.Variables.Added {
+				variables = append(variables, v.Name)
+			}
+			for _, v := range diff.Variables.Changed {
+				variables = append(variables, v.Name)
+			}
+			if !reflect.DeepEqual(variables, c.variables) {
+				t.Errorf("%s: expected added and changed variables %v, got %v", c.desc, c.variables, variables)
+			}
+		}
+	}
+}
+
+func TestDiffDashboardsWithoutData(t *testing.T) {
+	from := &models.Dashboard{Title: "dash", Tags: []string{}}
+	to := testDashboard(t, "dash", `{"panels": [{"id": 1, "type": "graph", "title": "p1"}]}`)
+
+	diff := diffDashboards(from, to)
+	if titles := panelTitles(diff.Panels.Added); !reflect.DeepEqual(titles, []string{"p1"}) {
+		t.Errorf("expected added panels [p1], got %v", titles)
+	}
+
+	diff = diffDashboards(to, from)
+	if titles := panelTitles(diff.Panels.Removed); !reflect.DeepEqual(titles, []string{"p1"}) {
+		t.Errorf("expected removed panels [p1], got %v", titles)
+	}
+}
@@ -0,0 +1,96 @@
+package dashboard
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// Move moves a dashboard to another folder of the same team,
+// both the edit permissions of the dashboard and the target folder are required
+func Move(c *gin.Context) {
+	req := struct {
+		Id       string `json:"id"`
+		FolderId int64  `json:"folderId"`
+	}{}
+	err := c.Bind(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := models.QueryDashboard(c.Request.Context(), req.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, common.RespError("dashboard not found"))
+			return
+		}
+		logger.Warn("query dashboard error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	err = acl.CanEditDashboard(c.Request.Context(), dash, u)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	// the folder of provisioned dashboards is decided by provisioning files
+	if !checkProvisioned(c, dash.Id) {
+		return
+	}
+
+	folder, err := queryTargetFolder(c, dash.OwnedBy, req.FolderId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	err = acl.CanEditFolder(c.Request.Context(), folder, u.Id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	_, err = db.Conn.ExecContext(c.Request.Context(), "UPDATE dashboard SET folder_id=? WHERE id=?", folder.Id, dash.Id)
+	if err != nil {
+		logger.Warn("move dashboard error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+// queryTargetFolder returns the folder which a dashboard is going to be put in, default to the root folder of team
+func queryTargetFolder(c *gin.Context, teamId int64, folderId int64) (*models.Folder, error) {
+	var folder *models.Folder
+	var err error
+	if folderId == 0 {
+		folder, err = models.QueryRootFolder(c.Request.Context(), teamId)
+	} else {
+		folder, err = models.QueryFolder(c.Request.Context(), folderId)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("folder not found")
+		}
+		logger.Warn("query folder error", "error", err)
+		return nil, errors.New(e.Internal)
+	}
+
+	if folder.TeamId != teamId {
+		return nil, errors.New("folder not in the team of dashboard")
+	}
+
+	return folder, nil
+}
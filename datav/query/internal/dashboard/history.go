@@ -13,6 +13,7 @@
 package dashboard
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -66,7 +67,15 @@ func InitHistory() {
 			continue
 		}
 
-		_, err = db.Conn.Exec("INSERT INTO dashboard_history (dashboard_id,version,changes,history) VALUES (?,?,?,?)", dash.Id, time.Now(), history.Changes, data)
+		version := dash.Version
+		if version == 0 {
+			version, _, _, err = models.QueryDashboardVersion(context.Background(), dash.Id)
+			if err != nil {
+				logger.Warn("query dashboard version error", "erorr", err)
+			}
+		}
+
+		_, err = db.Conn.Exec("INSERT INTO dashboard_history (dashboard_id,version,changes,history,author,dashboard_version) VALUES (?,?,?,?,?,?)", dash.Id, time.Now(), history.Changes, data, history.Author, version)
 		if err != nil {
 			logger.Warn("marshal history error", "erorr", err)
 			time.Sleep(1 * time.Second)
@@ -75,8 +84,14 @@ func InitHistory() {
 }
 
 func GetHistory(c *gin.Context) {
-	id := c.Param("id")
-	rows, err := db.Conn.QueryContext(c.Request.Context(), "SELECT history,version,changes FROM dashboard_history WHERE dashboard_id=? ORDER BY version DESC", id)
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := QueryDashboard(c, u)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	rows, err := db.Conn.QueryContext(c.Request.Context(), "SELECT history,version,changes FROM dashboard_history WHERE dashboard_id=? ORDER BY version DESC", dash.Id)
 	if err != nil {
 		logger.Warn("query dashboard history error", "error,err")
 		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
@@ -0,0 +1,230 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/internal/datasource"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+	"github.com/xObserve/xObserve/query/pkg/utils"
+	"github.com/xObserve/xObserve/query/pkg/utils/simplejson"
+)
+
+// max size of the data stored in a snapshot
+const maxSnapshotSize = 10 * 1024 * 1024
+
+type CreateSnapshotReq struct {
+	Title string               `json:"title"`
+	Data  *models.SnapshotData `json:"data"`
+	// seconds after which the snapshot expires, 0 means never
+	Expires int64 `json:"expires"`
+}
+
+// CreateSnapshot stores the dashboard and the query results of its panels captured in ui,
+// the dashboard must be visible to current user. The dashboard is read from db, only the panel results,
+// time range and variables are taken from the request
+func CreateSnapshot(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSnapshotSize)
+	req := &CreateSnapshotReq{}
+	err := c.Bind(&req)
+	if err != nil || req.Data == nil || req.Data.Dashboard == nil || req.Expires < 0 {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := models.QueryDashboard(c.Request.Context(), req.Data.Dashboard.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, common.RespError("dashboard not found"))
+			return
+		}
+		logger.Warn("query dashboard error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	err = acl.CanViewDashboard(c.Request.Context(), dash, u)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	data := req.Data
+	data.Dashboard = &models.Dashboard{
+		Id:        dash.Id,
+		Title:     dash.Title,
+		OwnedBy:   dash.OwnedBy,
+		OwnerName: dash.OwnerName,
+		VisibleTo: dash.VisibleTo,
+		Tags:      dash.Tags,
+		Data:      dash.Data,
+	}
+
+	// results of the panels not in the dashboard are dropped
+	panelIds := make(map[string]bool)
+	collectPanelIds(dash.Data.Get("panels"), panelIds)
+	for id := range data.PanelData {
+		if !panelIds[id] {
+			delete(data.PanelData, id)
+		}
+	}
+
+	// urls and secrets of datasources are not exposed in snapshot
+	data.Datasources = make([]*models.BundleDatasource, 0)
+	dsIds := make(map[int64]bool)
+	walkDatasourceRefs(data.Dashboard.Data.Interface(), func(id int64) int64 {
+		dsIds[id] = true
+		return id
+	})
+	for id := range dsIds {
+		ds, err := datasource.GetDatasource(c.Request.Context(), id)
+		if err != nil {
+			continue
+		}
+		data.Datasources = append(data.Datasources, &models.BundleDatasource{Id: id, Name: ds.Name, Type: ds.Type})
+	}
+
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+	if len(rawData) > maxSnapshotSize {
+		c.JSON(http.StatusBadRequest, common.RespError("snapshot is too large"))
+		return
+	}
+
+	key, err := utils.RandomHex(16)
+	if err != nil {
+		logger.Warn("generate snapshot key error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+
+	now := time.Now()
+	snapshot := &models.Snapshot{
+		Key:         key,
+		DashboardId: dash.Id,
+		TeamId:      dash.OwnedBy,
+		Title:       req.Title,
+		CreatedBy:   u.Id,
+		Created:     now,
+	}
+	if snapshot.Title == "" {
+		snapshot.Title = dash.Title
+	}
+	if req.Expires > 0 {
+		expires := now.Add(time.Duration(req.Expires) * time.Second)
+		snapshot.Expires = &expires
+	}
+
+	_, err = db.Conn.ExecContext(c.Request.Context(), "INSERT INTO snapshot (id,dashboard_id,team_id,title,data,created_by,expires,created) VALUES (?,?,?,?,?,?,?,?)",
+		snapshot.Key, snapshot.DashboardId, snapshot.TeamId, snapshot.Title, rawData, snapshot.CreatedBy, snapshot.Expires, snapshot.Created)
+	if err != nil {
+		logger.Warn("insert snapshot error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(snapshot))
+}
+
+// GetSnapshot returns a snapshot without authentication, the key is the only credential
+func GetSnapshot(c *gin.Context) {
+	snapshot, err := models.QuerySnapshot(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, common.RespError("snapshot not found"))
+			return
+		}
+		logger.Warn("query snapshot error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	if snapshot.IsExpired() {
+		c.JSON(http.StatusNotFound, common.RespError("snapshot has expired"))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(snapshot))
+}
+
+// GetSnapshots returns the snapshots of a dashboard
+func GetSnapshots(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := QueryDashboard(c, u)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	snapshots, err := models.QueryDashboardSnapshots(c.Request.Context(), dash.Id)
+	if err != nil {
+		logger.Warn("query snapshots error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(snapshots))
+}
+
+// DeleteSnapshot deletes a snapshot, only its creator and the editors of dashboard can do this
+func DeleteSnapshot(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	snapshot, err := models.QuerySnapshot(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, common.RespError("snapshot not found"))
+			return
+		}
+		logger.Warn("query snapshot error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	if snapshot.CreatedBy != u.Id {
+		dash, err := models.QueryDashboard(c.Request.Context(), snapshot.DashboardId)
+		if err != nil {
+			logger.Warn("query dashboard error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+
+		err = acl.CanEditDashboard(c.Request.Context(), dash, u)
+		if err != nil {
+			c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+			return
+		}
+	}
+
+	_, err = db.Conn.ExecContext(c.Request.Context(), "DELETE FROM snapshot WHERE id=?", snapshot.Key)
+	if err != nil {
+		logger.Warn("delete snapshot error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+// collectPanelIds collects the ids of panels, including the panels in rows
+func collectPanelIds(panels *simplejson.Json, ids map[string]bool) {
+	for i := range panels.MustArray() {
+		panel := panels.GetIndex(i)
+		id, err := panel.Get("id").Int64()
+		if err == nil {
+			ids[strconv.FormatInt(id, 10)] = true
+		}
+		collectPanelIds(panel.Get("panels"), ids)
+	}
+}
@@ -0,0 +1,299 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+const defaultVersionsPerPage = 20
+
+// GetVersions returns the metadata of dashboard histories, newest first
+func GetVersions(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := QueryDashboard(c, u)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	if page <= 0 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(c.Query("perPage"))
+	if perPage <= 0 {
+		perPage = defaultVersionsPerPage
+	}
+
+	var total int
+	err = db.Conn.QueryRowContext(c.Request.Context(), "SELECT count(1) FROM dashboard_history WHERE dashboard_id=?", dash.Id).Scan(&total)
+	if err != nil {
+		logger.Warn("query dashboard versions count error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	rows, err := db.Conn.QueryContext(c.Request.Context(), "SELECT dashboard_version,version,changes,author FROM dashboard_history WHERE dashboard_id=? ORDER BY version DESC LIMIT ? OFFSET ?", dash.Id, perPage, (page-1)*perPage)
+	if err != nil {
+		logger.Warn("query dashboard versions error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+	defer rows.Close()
+
+	authors := make(map[int64]string)
+	versions := make([]*models.DashboardVersion, 0)
+	for rows.Next() {
+		var v int64
+		var t time.Time
+		var changes sql.NullString
+		var author sql.NullInt64
+		err := rows.Scan(&v, &t, &changes, &author)
+		if err != nil {
+			logger.Warn("scan dashboard version error", "error", err)
+			continue
+		}
+
+		version := &models.DashboardVersion{
+			Version: v,
+			Created: &t,
+			Changes: changes.String,
+			Author:  author.Int64,
+		}
+		if version.Author != 0 {
+			name, ok := authors[version.Author]
+			if !ok {
+				user, err := models.QueryUserById(c.Request.Context(), version.Author)
+				if err == nil {
+					name = user.Username
+				}
+				authors[version.Author] = name
+			}
+			version.AuthorName = name
+		}
+
+		versions = append(versions, version)
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(map[string]interface{}{
+		"total":    total,
+		"versions": versions,
+	}))
+}
+
+func GetVersion(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := QueryDashboard(c, u)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	version, _ := strconv.ParseInt(c.Param("version"), 10, 64)
+	history, err := queryDashboardVersion(c.Request.Context(), dash.Id, version)
+	if err != nil {
+		respVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(history))
+}
+
+// DiffVersions compares two versions of a dashboard, when to is 0, the version is compared with current dashboard
+func DiffVersions(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	dash, err := QueryDashboard(c, u)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	fromVersion, _ := strconv.ParseInt(c.Query("from"), 10, 64)
+	toVersion, _ := strconv.ParseInt(c.Query("to"), 10, 64)
+
+	from, err := queryDashboardVersion(c.Request.Context(), dash.Id, fromVersion)
+	if err != nil {
+		respVersionError(c, err)
+		return
+	}
+
+	var to *models.DashboardHistory
+	if toVersion == 0 {
+		// histories keep the library panels unresolved, so current dashboard is compared as it is stored,
+		// otherwise the panels of library panels are always reported as changed
+		current, err := models.QueryDashboard(c.Request.Context(), dash.Id)
+		if err != nil {
+			logger.Warn("query dashboard error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+		to = &models.DashboardHistory{Dashboard: current}
+	} else {
+		to, err = queryDashboardVersion(c.Request.Context(), dash.Id, toVersion)
+		if err != nil {
+			respVersionError(c, err)
+			return
+		}
+	}
+
+	diff := diffDashboards(from.Dashboard, to.Dashboard)
+	diff.From = fromVersion
+	diff.To = toVersion
+
+	c.JSON(http.StatusOK, common.RespSuccess(diff))
+}
+
+// RestoreVersion saves a dashboard version as the newest one, the team and visibility of current dashboard are kept
+func RestoreVersion(c *gin.Context) {
+	u := c.MustGet("currentUser").(*models.User)
+	req := struct {
+		Message string `json:"message"`
+	}{}
+	c.ShouldBind(&req)
+
+	dash, err := QueryDashboard(c, u)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(err.Error()))
+		return
+	}
+
+	err = acl.CanEditDashboard(c.Request.Context(), dash, u)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	if !checkProvisioned(c, dash.Id) {
+		return
+	}
+
+	version, _ := strconv.ParseInt(c.Param("version"), 10, 64)
+	history, err := queryDashboardVersion(c.Request.Context(), dash.Id, version)
+	if err != nil {
+		respVersionError(c, err)
+		return
+	}
+
+	restored := history.Dashboard
+	if restored.Data == nil {
+		c.JSON(http.StatusBadRequest, common.RespError("dashboard version has no data"))
+		return
+	}
+
+	err = models.CheckDashboardData(restored.Data)
+	if err != nil {
+		respSchemaError(c, err)
+		return
+	}
+
+	now := time.Now()
+	restored.Id = dash.Id
+	restored.OwnedBy = dash.OwnedBy
+	restored.VisibleTo = dash.VisibleTo
+	restored.Updated = &now
+
+	jsonData, err := restored.Data.Encode()
+	if err != nil {
+		logger.Warn("encode dashboard data error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+
+	tags, err := json.Marshal(restored.Tags)
+	if err != nil {
+		logger.Warn("encode tags error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+
+	_, err = db.Conn.ExecContext(c.Request.Context(), `UPDATE dashboard SET title=?,tags=?,data=?,updated=?,version=version+1,updated_by=? WHERE id=?`,
+		restored.Title, tags, jsonData, restored.Updated, u.Id, restored.Id)
+	if err != nil {
+		logger.Error("restore dashboard error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+	restored.Version, restored.UpdatedBy, _, _ = models.QueryDashboardVersion(c.Request.Context(), restored.Id)
+
+	err = models.UpdateLibraryPanelConnections(c.Request.Context(), db.Conn, restored.Id, restored.Data)
+	if err != nil {
+		logger.Warn("update library panel connections error", "error", err)
+	}
+
+	err = models.UpdateDashboardSearch(c.Request.Context(), db.Conn, restored)
+	if err != nil {
+		logger.Warn("update dashboard search index error", "error", err)
+	}
+
+	changes := req.Message
+	if changes == "" {
+		changes = fmt.Sprintf("Restore to version %d", version)
+	}
+	historyCh <- &models.DashboardHistory{
+		Dashboard: restored,
+		Changes:   changes,
+		Author:    u.Id,
+	}
+
+	// the new version is returned as SaveDashboard does, otherwise the next save of ui would conflict with it
+	c.JSON(http.StatusOK, common.RespSuccess(map[string]interface{}{
+		"id":      restored.Id,
+		"version": restored.Version,
+	}))
+}
+
+// queryDashboardVersion returns the history saved with the version of dashboard, the latest one is returned
+// if there are several, e.g. the dashboard is saved by force
+func queryDashboardVersion(ctx context.Context, dashboardId string, version int64) (*models.DashboardHistory, error) {
+	var t time.Time
+	var changes sql.NullString
+	var author sql.NullInt64
+	var data []byte
+	err := db.Conn.QueryRowContext(ctx, "SELECT version,changes,author,history FROM dashboard_history WHERE dashboard_id=? AND dashboard_version=? ORDER BY version DESC LIMIT 1", dashboardId, version).Scan(&t, &changes, &author, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	var dash *models.Dashboard
+	err = json.Unmarshal(data, &dash)
+	if err != nil {
+		return nil, err
+	}
+	dash.Updated = &t
+	dash.Version = version
+	if dash.Data != nil {
+		// old versions are shown and restored in current schema
+		err = models.MigrateDashboardData(dash.Data)
+		if err != nil {
+			logger.Warn("migrate dashboard version error", "dashboard", dashboardId, "version", version, "error", err)
+		}
+	}
+
+	return &models.DashboardHistory{
+		Dashboard: dash,
+		Changes:   changes.String,
+		Author:    author.Int64,
+	}, nil
+}
+
+func respVersionError(c *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, common.RespError("dashboard version not found"))
+		return
+	}
+
+	logger.Warn("query dashboard version error", "error", err)
+	c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+}
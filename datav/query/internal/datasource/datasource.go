@@ -41,7 +41,7 @@ func InitDatasources() {
 
 		var rows *sql.Rows
 		var err error
-		rows, err = db.Conn.QueryContext(context.Background(), "SELECT id,name,type,url,team_id,data, created FROM datasource")
+		rows, err = db.Conn.QueryContext(context.Background(), "SELECT id,name,type,url,team_id,data,provisioned, created FROM datasource")
 
 		if err != nil {
 			logger.Warn("get datasource error", "error", err)
@@ -53,7 +53,7 @@ func InitDatasources() {
 		for rows.Next() {
 			ds := &models.Datasource{}
 			var rawdata []byte
-			err := rows.Scan(&ds.Id, &ds.Name, &ds.Type, &ds.URL, &ds.TeamId, &rawdata, &ds.Created)
+			err := rows.Scan(&ds.Id, &ds.Name, &ds.Type, &ds.URL, &ds.TeamId, &rawdata, &ds.Provisioned, &ds.Created)
 			if err != nil {
 				logger.Warn("scan datasource error", "error", err)
 				continue
@@ -160,6 +160,11 @@ func UpdateDatasource(c *gin.Context) {
 		return
 	}
 
+	if datasource.Provisioned {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ProvisionedDatasource))
+		return
+	}
+
 	_, err = db.Conn.ExecContext(c.Request.Context(), "UPDATE datasource SET name=?,type=?,url=?,data=?,updated=? WHERE id=?", ds.Name, ds.Type, ds.URL, data, now, ds.Id)
 	if err != nil {
 		if e.IsErrUniqueConstraint(err) {
@@ -202,7 +207,7 @@ func GetDatasourcesByTeamId(ctx context.Context, teamId int64) ([]*models.Dataso
 
 	var rows *sql.Rows
 
-	rows, err := db.Conn.QueryContext(ctx, "SELECT id,name,type,url,team_id,data, created FROM datasource WHERE team_id=?", teamId)
+	rows, err := db.Conn.QueryContext(ctx, "SELECT id,name,type,url,team_id,data,provisioned, created FROM datasource WHERE team_id=?", teamId)
 
 	if err != nil {
 		return nil, err
@@ -211,7 +216,7 @@ func GetDatasourcesByTeamId(ctx context.Context, teamId int64) ([]*models.Dataso
 	for rows.Next() {
 		ds := &models.Datasource{}
 		var rawdata []byte
-		err := rows.Scan(&ds.Id, &ds.Name, &ds.Type, &ds.URL, &ds.TeamId, &rawdata, &ds.Created)
+		err := rows.Scan(&ds.Id, &ds.Name, &ds.Type, &ds.URL, &ds.TeamId, &rawdata, &ds.Provisioned, &ds.Created)
 		if err != nil {
 			return nil, err
 		}
@@ -254,6 +259,11 @@ func DeleteDatasource(c *gin.Context) {
 		return
 	}
 
+	if ds.Provisioned {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ProvisionedDatasource))
+		return
+	}
+
 	_, err = db.Conn.ExecContext(c.Request.Context(), "DELETE FROM datasource WHERE id=?", id)
 	if err != nil {
 		logger.Warn("delete datasource error", "error", err)
@@ -267,9 +277,9 @@ func DeleteDatasource(c *gin.Context) {
 func GetDatasource(ctx context.Context, id int64) (*models.Datasource, error) {
 	ds, ok := datasources[id]
 	if !ok {
-		ds := &models.Datasource{}
+		ds := &models.Datasource{Id: id}
 		var rawdata []byte
-		err := db.Conn.QueryRowContext(ctx, "SELECT name,type,url,data, created FROM datasource WHERE id=?", id).Scan(&ds.Name, &ds.Type, &ds.URL, &rawdata, &ds.Created)
+		err := db.Conn.QueryRowContext(ctx, "SELECT name,type,url,team_id,data,provisioned, created FROM datasource WHERE id=?", id).Scan(&ds.Name, &ds.Type, &ds.URL, &ds.TeamId, &rawdata, &ds.Provisioned, &ds.Created)
 		if err != nil {
 			return nil, err
 		}
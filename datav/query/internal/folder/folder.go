@@ -0,0 +1,354 @@
+package folder
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/pkg/colorlog"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+var logger = colorlog.RootLogger.New("logger", "folder")
+
+// GetTeamFolders returns all the folders of a team, the tree can be built with the parentId of folders
+func GetTeamFolders(c *gin.Context) {
+	teamId, _ := strconv.ParseInt(c.Param("teamId"), 10, 64)
+	u := c.MustGet("currentUser").(*models.User)
+
+	err := acl.CanViewTeam(c.Request.Context(), teamId, u.Id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	folders, err := models.QueryTeamFolders(c.Request.Context(), teamId)
+	if err != nil {
+		logger.Warn("query team folders error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(folders))
+}
+
+// GetFolder returns the folder and its path from the root folder
+func GetFolder(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	folder, ok := getFolder(c, id, models.PermissionView)
+	if !ok {
+		return
+	}
+
+	path, err := models.QueryFolderPath(c.Request.Context(), folder.Id)
+	if err != nil {
+		logger.Warn("query folder path error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(map[string]interface{}{
+		"folder": folder,
+		"path":   path,
+	}))
+}
+
+func AddNewFolder(c *gin.Context) {
+	req := &models.Folder{}
+	err := c.Bind(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		c.JSON(http.StatusBadRequest, common.RespError("folder title can not be empty"))
+		return
+	}
+
+	parentId := req.ParentId
+	if parentId == 0 {
+		root, err := models.QueryRootFolder(c.Request.Context(), req.TeamId)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, common.RespError(e.TeamNotExist))
+				return
+			}
+			logger.Warn("query root folder error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+		parentId = root.Id
+	}
+
+	parent, ok := getFolder(c, parentId, models.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	now := time.Now()
+	res, err := db.Conn.ExecContext(c.Request.Context(), "INSERT INTO folder (team_id,parent_id,title,created_by,created,updated) VALUES (?,?,?,?,?,?)",
+		parent.TeamId, parent.Id, req.Title, u.Id, now, now)
+	if err != nil {
+		if e.IsErrUniqueConstraint(err) {
+			c.JSON(http.StatusBadRequest, common.RespError("folder title already exists"))
+			return
+		}
+		logger.Warn("insert folder error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	folder := &models.Folder{
+		TeamId:    parent.TeamId,
+		ParentId:  parent.Id,
+		Title:     req.Title,
+		CreatedBy: u.Id,
+		Created:   now,
+		Updated:   now,
+	}
+	folder.Id, _ = res.LastInsertId()
+
+	c.JSON(http.StatusOK, common.RespSuccess(folder))
+}
+
+// UpdateFolder renames a folder or moves it to another parent folder of the same team
+func UpdateFolder(c *gin.Context) {
+	req := &models.Folder{}
+	err := c.Bind(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		c.JSON(http.StatusBadRequest, common.RespError("folder title can not be empty"))
+		return
+	}
+
+	folder, ok := getFolder(c, req.Id, models.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	if req.ParentId == 0 {
+		req.ParentId = folder.ParentId
+	}
+
+	if req.ParentId != folder.ParentId {
+		if folder.IsRoot() {
+			c.JSON(http.StatusBadRequest, common.RespError("root folder can not be moved"))
+			return
+		}
+
+		parent, ok := getFolder(c, req.ParentId, models.PermissionEdit)
+		if !ok {
+			return
+		}
+		if parent.TeamId != folder.TeamId {
+			c.JSON(http.StatusBadRequest, common.RespError("folder can not be moved to another team"))
+			return
+		}
+
+		// a folder can't be moved into itself or its sub folders
+		path, err := models.QueryFolderPath(c.Request.Context(), parent.Id)
+		if err != nil {
+			logger.Warn("query folder path error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+		for _, f := range path {
+			if f.Id == folder.Id {
+				c.JSON(http.StatusBadRequest, common.RespError("folder can not be moved into its sub folder"))
+				return
+			}
+		}
+	}
+
+	_, err = db.Conn.ExecContext(c.Request.Context(), "UPDATE folder SET title=?,parent_id=?,updated=? WHERE id=?", req.Title, req.ParentId, time.Now(), folder.Id)
+	if err != nil {
+		if e.IsErrUniqueConstraint(err) {
+			c.JSON(http.StatusBadRequest, common.RespError("folder title already exists"))
+			return
+		}
+		logger.Warn("update folder error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+// DeleteFolder deletes an empty folder, the dashboards and sub folders in it should be moved or deleted first
+func DeleteFolder(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	folder, ok := getFolder(c, id, models.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	if folder.IsRoot() {
+		c.JSON(http.StatusBadRequest, common.RespError("root folder can not be deleted"))
+		return
+	}
+
+	var count int
+	err := db.Conn.QueryRowContext(c.Request.Context(), "SELECT (SELECT count(1) FROM folder WHERE parent_id=?) + (SELECT count(1) FROM dashboard WHERE folder_id=?)", folder.Id, folder.Id).Scan(&count)
+	if err != nil {
+		logger.Warn("query folder children error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, common.RespError("folder is not empty"))
+		return
+	}
+
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		logger.Warn("start sql transaction error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(c.Request.Context(), "DELETE FROM folder_permission WHERE folder_id=?", folder.Id)
+	if err != nil {
+		logger.Warn("delete folder permissions error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	_, err = tx.ExecContext(c.Request.Context(), "DELETE FROM folder WHERE id=?", folder.Id)
+	if err != nil {
+		logger.Warn("delete folder error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Warn("commit sql transaction error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+func GetFolderPermissions(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	folder, ok := getFolder(c, id, models.PermissionView)
+	if !ok {
+		return
+	}
+
+	permissions, err := models.QueryFolderPermissions(c.Request.Context(), folder.Id)
+	if err != nil {
+		logger.Warn("query folder permissions error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(permissions))
+}
+
+// UpdateFolderPermissions replaces the permissions of a folder, the admin permission of the folder is required
+func UpdateFolderPermissions(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	permissions := make([]*models.FolderPermission, 0)
+	err := c.Bind(&permissions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	folder, ok := getFolder(c, id, models.PermissionAdmin)
+	if !ok {
+		return
+	}
+
+	for _, p := range permissions {
+		if !models.IsValidPermissionSubject(p.SubjectType) || !p.Permission.IsValid() || p.SubjectId == 0 {
+			c.JSON(http.StatusBadRequest, common.RespError("invalid folder permission"))
+			return
+		}
+	}
+
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		logger.Warn("start sql transaction error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(c.Request.Context(), "DELETE FROM folder_permission WHERE folder_id=?", folder.Id)
+	if err != nil {
+		logger.Warn("delete folder permissions error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	now := time.Now()
+	for _, p := range permissions {
+		_, err = tx.ExecContext(c.Request.Context(), "INSERT INTO folder_permission (folder_id,subject_type,subject_id,permission,created) VALUES (?,?,?,?,?)",
+			folder.Id, p.SubjectType, p.SubjectId, p.Permission, now)
+		if err != nil {
+			if e.IsErrUniqueConstraint(err) {
+				c.JSON(http.StatusBadRequest, common.RespError("duplicate folder permission"))
+				return
+			}
+			logger.Warn("insert folder permission error", "error", err)
+			c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Warn("commit sql transaction error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespInternalError())
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+func getFolder(c *gin.Context, id int64, permission models.PermissionType) (*models.Folder, bool) {
+	folder, err := models.QueryFolder(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, common.RespError("folder not found"))
+			return nil, false
+		}
+		logger.Warn("query folder error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return nil, false
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	p, err := acl.FolderPermission(c.Request.Context(), folder, u.Id)
+	if err != nil {
+		logger.Warn("query folder permission error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return nil, false
+	}
+
+	if !p.Contains(permission) {
+		c.JSON(http.StatusForbidden, common.RespError(e.NoPermission))
+		return nil, false
+	}
+
+	return folder, true
+}
@@ -0,0 +1,264 @@
+package librarypanel
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/pkg/colorlog"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+var logger = colorlog.RootLogger.New("logger", "librarypanel")
+
+func AddLibraryPanel(c *gin.Context) {
+	p := &models.LibraryPanel{}
+	err := c.Bind(&p)
+	if err != nil {
+		logger.Warn("bind library panel error", "error", err)
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	if msg := validateLibraryPanel(p); msg != "" {
+		c.JSON(http.StatusBadRequest, common.RespError(msg))
+		return
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	err = acl.CanEditTeamDashboards(c.Request.Context(), p.TeamId, u.Id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	model, err := p.Model.Encode()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	now := time.Now()
+	res, err := db.Conn.ExecContext(c.Request.Context(), "INSERT INTO library_panel (team_id,title,description,model,version,created_by,updated_by,created,updated) VALUES (?,?,?,?,?,?,?,?,?)",
+		p.TeamId, p.Title, p.Description, model, 1, u.Id, u.Id, now, now)
+	if err != nil {
+		if e.IsErrUniqueConstraint(err) {
+			c.JSON(http.StatusBadRequest, common.RespError("library panel title already exists"))
+			return
+		}
+		logger.Warn("insert library panel error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	p.Id, _ = res.LastInsertId()
+	p.Version = 1
+	p.CreatedBy = u.Id
+	p.UpdatedBy = u.Id
+	p.Created = now
+	p.Updated = now
+
+	c.JSON(http.StatusOK, common.RespSuccess(p))
+}
+
+// UpdateLibraryPanel updates a library panel, the change is applied to all the dashboards using it
+// when they are loaded next time, the dashboards are returned so users know what has been affected
+func UpdateLibraryPanel(c *gin.Context) {
+	p := &models.LibraryPanel{}
+	err := c.Bind(&p)
+	if err != nil {
+		logger.Warn("bind library panel error", "error", err)
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	if msg := validateLibraryPanel(p); msg != "" {
+		c.JSON(http.StatusBadRequest, common.RespError(msg))
+		return
+	}
+
+	old, ok := getLibraryPanel(c, p.Id, true)
+	if !ok {
+		return
+	}
+
+	model, err := p.Model.Encode()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	// library panel can't be moved to another team, and the version is checked to avoid overwriting others' changes
+	u := c.MustGet("currentUser").(*models.User)
+	res, err := db.Conn.ExecContext(c.Request.Context(), "UPDATE library_panel SET title=?,description=?,model=?,version=version+1,updated_by=?,updated=? WHERE id=? AND version=?",
+		p.Title, p.Description, model, u.Id, time.Now(), old.Id, p.Version)
+	if err != nil {
+		if e.IsErrUniqueConstraint(err) {
+			c.JSON(http.StatusBadRequest, common.RespError("library panel title already exists"))
+			return
+		}
+		logger.Warn("update library panel error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		c.JSON(http.StatusConflict, common.RespError("library panel has been updated by others, please reload it"))
+		return
+	}
+
+	connections, err := models.QueryLibraryPanelConnections(c.Request.Context(), old.Id)
+	if err != nil {
+		logger.Warn("query library panel connections error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(map[string]interface{}{
+		"version":    p.Version + 1,
+		"dashboards": connections,
+	}))
+}
+
+// DeleteLibraryPanel deletes a library panel which is not used by any dashboard
+func DeleteLibraryPanel(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	p, ok := getLibraryPanel(c, id, true)
+	if !ok {
+		return
+	}
+
+	if p.Connections > 0 {
+		c.JSON(http.StatusBadRequest, common.RespError(fmt.Sprintf("library panel is used by %d dashboards, unlink it from them first", p.Connections)))
+		return
+	}
+
+	_, err := db.Conn.ExecContext(c.Request.Context(), "DELETE FROM library_panel WHERE id=?", p.Id)
+	if err != nil {
+		logger.Warn("delete library panel error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+func GetLibraryPanel(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	p, ok := getLibraryPanel(c, id, false)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(p))
+}
+
+func QueryTeamLibraryPanels(c *gin.Context) {
+	teamId, _ := strconv.ParseInt(c.Param("teamId"), 10, 64)
+	u := c.MustGet("currentUser").(*models.User)
+
+	err := acl.CanViewTeam(c.Request.Context(), teamId, u.Id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	panels, err := models.QueryTeamLibraryPanels(c.Request.Context(), teamId)
+	if err != nil {
+		logger.Warn("query library panels error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(panels))
+}
+
+// GetLibraryPanelConnections returns the dashboards using a library panel, which are visible to current user
+func GetLibraryPanelConnections(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	p, ok := getLibraryPanel(c, id, false)
+	if !ok {
+		return
+	}
+
+	connections, err := models.QueryLibraryPanelConnections(c.Request.Context(), p.Id)
+	if err != nil {
+		logger.Warn("query library panel connections error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	res := make([]*models.LibraryPanelConnection, 0, len(connections))
+	for _, conn := range connections {
+		dash := &models.Dashboard{Id: conn.DashboardId, OwnedBy: conn.TeamId, FolderId: conn.FolderId, VisibleTo: conn.VisibleTo}
+		if acl.CanViewDashboard(c.Request.Context(), dash, u) == nil {
+			res = append(res, conn)
+		}
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(res))
+}
+
+// getLibraryPanel returns the library panel if current user can view it, team editors are required when edit is true
+func getLibraryPanel(c *gin.Context, id int64, edit bool) (*models.LibraryPanel, bool) {
+	p, err := models.QueryLibraryPanel(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, common.RespError("library panel not found"))
+			return nil, false
+		}
+		logger.Warn("query library panel error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return nil, false
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	if edit {
+		err = acl.CanEditTeamDashboards(c.Request.Context(), p.TeamId, u.Id)
+	} else {
+		err = acl.CanViewTeam(c.Request.Context(), p.TeamId, u.Id)
+	}
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return nil, false
+	}
+
+	return p, true
+}
+
+func validateLibraryPanel(p *models.LibraryPanel) string {
+	p.Title = strings.TrimSpace(p.Title)
+	if p.Title == "" {
+		return "library panel title can not be empty"
+	}
+	if len(p.Title) > 255 {
+		return "library panel title is too long"
+	}
+
+	if p.Model == nil {
+		return "panel model can not be empty"
+	}
+	model, err := p.Model.Map()
+	if err != nil {
+		return "panel model must be a json object"
+	}
+	if _, ok := model["type"].(string); !ok {
+		return "type of panel model is required"
+	}
+
+	// these fields are decided by the dashboards using the panel
+	delete(model, "id")
+	delete(model, "gridPos")
+	delete(model, "libraryPanel")
+
+	return ""
+}
@@ -10,25 +10,59 @@ const (
 	TestDatasourceAPI           = "testDatasource"
 	GetServiceInfoListAPI       = "getServiceInfoList"
 	GetNamespacesAPI            = "getNamespaces"
+	GetGroupsAPI                = "getGroups"
+	GetHostsAPI                 = "getHosts"
 	GetServiceNamesAPI          = "getServiceNames"
 	GetServiceOperationsAPI     = "getServiceOperations"
 	GetServiceRootOperationsAPI = "getServiceRootOperations"
 	GetDependencyGraphAPI       = "getDependencyGraph"
 	GetLogsAPI                  = "getLogs"
+	GetLogPatternsAPI           = "getLogPatterns"
+	GetLogMetricsAPI            = "getLogMetrics"
+	GetLogTraceAPI              = "getLogTrace"
 	GetTracesAPI                = "getTraces"
 	GetTraceAPI                 = "getTrace"
 	GetTraceTagKeysAPI          = "getTraceTagKeys"
+	GetTraceLogsAPI             = "getTraceLogs"
+	SearchTracesAPI             = "searchTraces"
+	CompareTracesAPI            = "compareTraces"
+	ParseTraceAPI               = "parseTrace"
+	GetExceptionsAPI            = "getExceptions"
+	GetExceptionDetailAPI       = "getExceptionDetail"
+	GetServiceMetricsAPI        = "getServiceMetrics"
+	GetOperationMetricsAPI      = "getOperationMetrics"
+	GetServiceDBCallsAPI        = "getServiceDBCalls"
+	GetServiceExternalCallsAPI  = "getServiceExternalCalls"
+	GetUsageAPI                 = "getUsage"
+	GetUsageBreakdownAPI        = "getUsageBreakdown"
 )
 
 var APIRoutes = map[string]func(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult{
 	GetServiceInfoListAPI:       GetServiceInfoList,
 	GetNamespacesAPI:            GetNamespaces,
+	GetGroupsAPI:                GetGroups,
+	GetHostsAPI:                 GetHosts,
 	GetServiceNamesAPI:          GetServiceNames,
 	GetServiceOperationsAPI:     GetServiceOperations,
 	GetServiceRootOperationsAPI: GetServiceRootOperations,
 	GetLogsAPI:                  GetLogs,
+	GetLogPatternsAPI:           GetLogPatterns,
+	GetLogMetricsAPI:            GetLogMetrics,
+	GetLogTraceAPI:              GetLogTrace,
 	GetDependencyGraphAPI:       GetDependencyGraph,
 	GetTracesAPI:                GetTraces,
 	GetTraceAPI:                 GetTrace,
 	GetTraceTagKeysAPI:          GetTraceTagKeys,
+	GetTraceLogsAPI:             GetTraceLogs,
+	SearchTracesAPI:             SearchTraces,
+	CompareTracesAPI:            CompareTraces,
+	ParseTraceAPI:               ParseTrace,
+	GetExceptionsAPI:            GetExceptions,
+	GetExceptionDetailAPI:       GetExceptionDetail,
+	GetServiceMetricsAPI:        GetServiceMetrics,
+	GetOperationMetricsAPI:      GetOperationMetrics,
+	GetServiceDBCallsAPI:        GetServiceDBCalls,
+	GetServiceExternalCallsAPI:  GetServiceExternalCalls,
+	GetUsageAPI:                 GetUsage,
+	GetUsageBreakdownAPI:        GetUsageBreakdown,
 }
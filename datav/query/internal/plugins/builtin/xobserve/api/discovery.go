@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	pluginUtils "github.com/xObserve/xObserve/query/internal/plugins/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+const (
+	// used when the time range is not given
+	defaultDiscoveryRange = 3600
+	defaultDiscoveryLimit = 1000
+)
+
+type discoverySource struct {
+	db     string
+	table  string
+	column string
+	// name of the returned column, getNamespaces, getServiceNames and getServiceOperations keep the names
+	// they returned before, because they are used by existing dashboards
+	alias     string
+	timeExpr  string // in seconds
+	countExpr string
+	// the rows of the table are bucketed per hour, so start is floored to the hour,
+	// otherwise the bucket containing start is missed
+	hourly bool
+}
+
+var (
+	// namespaces, groups and services are counted by usage_explorer_mv per hour, its timestamp is fixed in traces migration 000004
+	namespaceDiscoverySource = &discoverySource{xobservemodels.DefaultTraceDB, xobservemodels.DefaultUsageExplorerTable, "namespace", "namespace", "toUInt64(toDateTime(timestamp))", "sum(count)", true}
+	groupDiscoverySource     = &discoverySource{xobservemodels.DefaultTraceDB, xobservemodels.DefaultUsageExplorerTable, "group", "group", "toUInt64(toDateTime(timestamp))", "sum(count)", true}
+	serviceDiscoverySource   = &discoverySource{xobservemodels.DefaultTraceDB, xobservemodels.DefaultUsageExplorerTable, "service_name", "serviceName", "toUInt64(toDateTime(timestamp))", "sum(count)", true}
+	operationDiscoverySource = &discoverySource{xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, "name", "name", "intDiv(startTime, 1000000000)", "count()", false}
+	hostDiscoverySource      = &discoverySource{xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable, "host", "host", "intDiv(timestamp, 1000000000)", "count()", false}
+)
+
+// The discovery apis return the values which have reported data in the time range, ordered by the number of spans or logs.
+// The time range is the start and end of the query, default to the last hour. getNamespaces, getServiceNames and
+// getServiceOperations returned all the values ever reported before, now only the values in the time range are returned,
+// so the values of variables follow the time range of dashboards.
+//
+// params:
+//   - search: only return the values with this prefix
+//   - limit: default to 1000
+//   - withCounts: when true, the count of each value is returned in the count column,
+//     otherwise only the values are returned, so they can be used as variable values
+
+// GetNamespaces returns the namespaces of current tenant
+func GetNamespaces(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	domainQuery := xobserveutils.BuildTenantQuery(models.GetTelemetryTenants(c))
+
+	return queryDiscovery(c, conn, params, namespaceDiscoverySource, domainQuery)
+}
+
+// GetGroups returns the groups in the namespaces given by namespace param, default to all namespaces
+func GetGroups(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	domainQuery := xobserveutils.BuildTenantQuery(models.GetTelemetryTenants(c))
+
+	namespace := xobserveutils.GetValueListFromParams(params, "namespace")
+	if namespace != nil {
+		domainQuery += fmt.Sprintf(" AND namespace in ('%s')", strings.Join(namespace, "','"))
+	}
+
+	return queryDiscovery(c, conn, params, groupDiscoverySource, domainQuery)
+}
+
+// GetServiceNames returns the services in the namespaces and groups given by params, the column is serviceName
+func GetServiceNames(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
+
+	return queryDiscovery(c, conn, params, serviceDiscoverySource, domainQuery)
+}
+
+// GetServiceOperations returns the span names of the services given by service param, the column is name
+func GetServiceOperations(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
+
+	service := xobserveutils.GetValueListFromParams(params, "service")
+	if service != nil {
+		domainQuery += fmt.Sprintf(" AND serviceName in ('%s')", strings.Join(service, "','"))
+	}
+
+	return queryDiscovery(c, conn, params, operationDiscoverySource, domainQuery)
+}
+
+// GetHosts returns the hosts which have reported logs, filtered by service param
+func GetHosts(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
+
+	service := xobserveutils.GetValueListFromParams(params, "service")
+	if service != nil {
+		domainQuery += fmt.Sprintf(" AND service in ('%s')", strings.Join(service, "','"))
+	}
+
+	return queryDiscovery(c, conn, params, hostDiscoverySource, domainQuery)
+}
+
+func queryDiscovery(c *gin.Context, conn ch.Conn, params map[string]interface{}, source *discoverySource, domainQuery string) models.PluginResult {
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if end == 0 {
+		end = time.Now().Unix()
+	}
+	if start == 0 {
+		start = end - defaultDiscoveryRange
+	}
+	if source.hourly {
+		start -= start % 3600
+	}
+
+	where := fmt.Sprintf("%s >= ? AND %s <= ? AND %s AND %s != ''", source.timeExpr, source.timeExpr, domainQuery, source.column)
+	args := []interface{}{start, end}
+
+	search, _ := params["search"].(string)
+	if search != "" {
+		where += fmt.Sprintf(" AND startsWith(%s, ?)", source.column)
+		args = append(args, search)
+	}
+
+	limit, _ := params["limit"].(float64)
+	if limit <= 0 {
+		limit = defaultDiscoveryLimit
+	}
+
+	selectColumns := source.column
+	if source.alias != source.column {
+		selectColumns += " AS " + source.alias
+	}
+	withCounts, _ := params["withCounts"].(bool)
+	if withCounts {
+		selectColumns += fmt.Sprintf(", %s AS count", source.countExpr)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s.%s WHERE %s GROUP BY %s ORDER BY %s DESC, %s LIMIT %d",
+		selectColumns, source.db, source.table, where, source.column, source.countExpr, source.column, int(limit))
+	rows, err := conn.Query(c.Request.Context(), query, args...)
+	if err != nil {
+		logger.Warn("Error Query discovery", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query discovery", "query", query, "args", args)
+
+	res, err := pluginUtils.ConvertDbRowsToPluginData(rows)
+	if err != nil {
+		logger.Warn("Error conver rows to data", "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", res)
+}
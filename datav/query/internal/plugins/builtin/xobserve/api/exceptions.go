@@ -0,0 +1,181 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// GetExceptions groups the exceptions in trace error index by service, exception type and message fingerprint,
+// fingerprint is the exception message with numbers and hex ids masked
+func GetExceptions(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	step, _ := strconv.ParseInt(c.Query("step"), 10, 64)
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+	if step <= 0 {
+		step = 60
+	}
+	if end < start {
+		end = start
+	}
+	if (end-start)/step+1 > xobservemodels.ExceptionMaxBuckets {
+		step = (end-start)/(xobservemodels.ExceptionMaxBuckets-1) + 1
+	}
+
+	limit := xobserveutils.GetIntValueFromParams(params, "limit", 100)
+	domainQuery := buildExceptionsDomainQuery(c, params)
+
+	query := fmt.Sprintf(`SELECT serviceName, exceptionType, fingerprint, any(message) AS sampleMessage, sum(cnt) AS total, min(firstSeen), max(lastSeen), groupArray(bucket), groupArray(cnt)
+FROM (
+	SELECT serviceName, exceptionType, %s AS fingerprint, any(exceptionMessage) AS message, toUnixTimestamp(toStartOfInterval(timestamp, INTERVAL %d SECOND)) AS bucket, count() AS cnt, toUnixTimestamp(min(timestamp)) AS firstSeen, toUnixTimestamp(max(timestamp)) AS lastSeen
+	FROM %s.%s
+	WHERE timestamp >= toDateTime(%d) AND timestamp <= toDateTime(%d) AND %s
+	GROUP BY serviceName, exceptionType, fingerprint, bucket
+)
+GROUP BY serviceName, exceptionType, fingerprint ORDER BY total DESC LIMIT %d`,
+		xobservemodels.ExceptionFingerprintSQL, step, xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceErrorTable, start, end, domainQuery, limit)
+
+	rows, err := conn.Query(c.Request.Context(), query)
+	if err != nil {
+		logger.Warn("Error Query exceptions", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query exceptions", "query", query)
+
+	buckets := int((end-start)/step) + 1
+	// align with toStartOfInterval, which rounds down to the multiple of step
+	alignedStart := start - start%step
+	groups := make([]*xobservemodels.ExceptionGroup, 0)
+	for rows.Next() {
+		group := &xobservemodels.ExceptionGroup{}
+		var firstSeen, lastSeen uint32
+		var bucketTimes []uint32
+		var bucketCounts []uint64
+		err := rows.Scan(&group.ServiceName, &group.ExceptionType, &group.Fingerprint, &group.Message, &group.Count, &firstSeen, &lastSeen, &bucketTimes, &bucketCounts)
+		if err != nil {
+			logger.Warn("Error scan exception group", "error", err)
+			continue
+		}
+		group.FirstSeen = int64(firstSeen)
+		group.LastSeen = int64(lastSeen)
+
+		group.Sparkline = make([]uint64, buckets)
+		for i, t := range bucketTimes {
+			index := int((int64(t) - alignedStart) / step)
+			if index >= 0 && index < buckets {
+				group.Sparkline[index] += bucketCounts[i]
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", groups)
+}
+
+// GetExceptionDetail returns the latest stacktrace, sample traces and affected operations of an exception group
+func GetExceptionDetail(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+
+	service := c.Query("service")
+	exceptionType := c.Query("exceptionType")
+	fingerprint := c.Query("fingerprint")
+	if service == "" {
+		return models.GenPluginResult(models.PluginStatusError, "service can not be empty", nil)
+	}
+
+	limit := xobserveutils.GetIntValueFromParams(params, "limit", 20)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(models.GetTelemetryTenants(c), params)
+	domainQuery += fmt.Sprintf(" AND timestamp >= toDateTime(%d) AND timestamp <= toDateTime(%d) AND serviceName=? AND exceptionType=? AND %s=?", start, end, xobservemodels.ExceptionFingerprintSQL)
+	args := []interface{}{service, exceptionType, fingerprint}
+
+	detail := &xobservemodels.ExceptionDetail{
+		ServiceName:   service,
+		ExceptionType: exceptionType,
+		Fingerprint:   fingerprint,
+		Samples:       make([]*xobservemodels.ExceptionSample, 0),
+		Operations:    make([]*xobservemodels.ExceptionOperation, 0),
+	}
+
+	query := fmt.Sprintf("SELECT timestamp, traceID, spanID, exceptionMessage, exceptionStacktrace, exceptionEscaped FROM %s.%s WHERE %s ORDER BY timestamp DESC LIMIT %d", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceErrorTable, domainQuery, limit)
+	rows, err := conn.Query(c.Request.Context(), query, args...)
+	if err != nil {
+		logger.Warn("Error Query exception samples", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query exception samples", "query", query)
+
+	for rows.Next() {
+		sample := &xobservemodels.ExceptionSample{}
+		var timestamp time.Time
+		var stacktrace string
+		err := rows.Scan(&timestamp, &sample.TraceId, &sample.SpanId, &sample.Message, &stacktrace, &sample.Escaped)
+		if err != nil {
+			logger.Warn("Error scan exception sample", "error", err)
+			continue
+		}
+		sample.Timestamp = timestamp.UnixNano()
+		if len(detail.Samples) == 0 {
+			// samples are ordered by timestamp desc, so the first one is the latest
+			detail.Message = sample.Message
+			detail.Stacktrace = stacktrace
+		}
+		detail.Samples = append(detail.Samples, sample)
+	}
+
+	query = fmt.Sprintf("SELECT name, count() AS calls FROM %s.%s WHERE startTime >= %d AND startTime <= %d AND serviceName=? AND (traceId, spanId) IN (SELECT traceID, spanID FROM %s.%s WHERE %s) GROUP BY name ORDER BY calls DESC",
+		xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, start*1e9, end*1e9, xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceErrorTable, domainQuery)
+	rows, err = conn.Query(c.Request.Context(), query, append([]interface{}{service}, args...)...)
+	if err != nil {
+		logger.Warn("Error Query exception operations", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query exception operations", "query", query)
+
+	for rows.Next() {
+		operation := &xobservemodels.ExceptionOperation{}
+		err := rows.Scan(&operation.Name, &operation.Count)
+		if err != nil {
+			logger.Warn("Error scan exception operation", "error", err)
+			continue
+		}
+		detail.Operations = append(detail.Operations, operation)
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", detail)
+}
+
+func buildExceptionsDomainQuery(c *gin.Context, params map[string]interface{}) string {
+	domainQuery := xobserveutils.BuildBasicDomainQuery(models.GetTelemetryTenants(c), params)
+
+	services := xobserveutils.GetValueListFromParams(params, "service")
+	if services != nil {
+		domainQuery += fmt.Sprintf(" AND serviceName in ('%s')", strings.Join(services, "','"))
+	}
+
+	exceptionTypes := xobserveutils.GetValueListFromParams(params, "exceptionType")
+	if exceptionTypes != nil {
+		domainQuery += fmt.Sprintf(" AND exceptionType in ('%s')", strings.Join(exceptionTypes, "','"))
+	}
+
+	return domainQuery
+}
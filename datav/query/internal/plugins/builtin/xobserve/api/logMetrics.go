@@ -0,0 +1,187 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	pluginUtils "github.com/xObserve/xObserve/query/internal/plugins/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+const maxLogMetricsGroupBy = 3
+
+var fieldNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+$`)
+
+// GetLogMetrics aggregates the logs matching the search filter into time series,
+// the result can be used directly in graph panels and alert rules
+//
+// params:
+//   - aggregate: count, count_distinct, sum, avg, min, max or quantile, default to count
+//   - field: the field to aggregate on, required by all aggregations except count
+//   - quantile: between 0 and 1, used by quantile aggregation, default to 0.99
+//   - groupBy: at most 3 fields separated by ',', e.g "service,attributes.http.method"
+func GetLogMetrics(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	step, _ := strconv.ParseInt(c.Query("step"), 10, 64)
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+	if step <= 0 {
+		step = 60
+	}
+
+	aggregate := xobserveutils.GetValueFromParams(params, "aggregate")
+	field := xobserveutils.GetValueFromParams(params, "field")
+	quantile := 0.99
+	quantileI, ok := params["quantile"].(float64)
+	if ok {
+		quantile = quantileI
+	}
+
+	aggregateQuery, err := buildLogAggregateQuery(aggregate, field, quantile)
+	if err != nil {
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	groupBy := make([]string, 0)
+	for _, g := range strings.Split(xobserveutils.GetValueFromParams(params, "groupBy"), ",") {
+		g = strings.TrimSpace(g)
+		if g != "" {
+			groupBy = append(groupBy, g)
+		}
+	}
+	if len(groupBy) > maxLogMetricsGroupBy {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("at most %d group by fields are allowed", maxLogMetricsGroupBy), nil)
+	}
+
+	selectGroups := ""
+	groupByQuery := "ts_bucket"
+	for _, g := range groupBy {
+		column, err := mapLogFieldColumn(g, false)
+		if err != nil {
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		selectGroups += fmt.Sprintf("%s AS `%s`, ", column, g)
+		groupByQuery += fmt.Sprintf(", `%s`", g)
+	}
+
+	domainQuery, searchQuery, searchArgs, err := buildLogsFilterQuery(c, params)
+	if err != nil {
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	query := fmt.Sprintf("SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL %d SECOND) AS ts_bucket, %s%s AS value FROM %s.%s where (timestamp >= ? AND timestamp <= ? %s %s) group by %s order by ts_bucket", step, selectGroups, aggregateQuery, xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable, domainQuery, searchQuery, groupByQuery)
+	args := append([]interface{}{start * 1e9, end * 1e9}, searchArgs...)
+	rows, err := conn.Query(c.Request.Context(), query, args...)
+	if err != nil {
+		logger.Warn("Error Query log metrics", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query log metrics", "query", query, "args", args)
+
+	res, err := pluginUtils.ConvertDbRowsToPluginData(rows)
+	if err != nil {
+		logger.Warn("Error conver rows to data", "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", res)
+}
+
+func buildLogAggregateQuery(aggregate, field string, quantile float64) (string, error) {
+	if aggregate == "" || aggregate == "count" {
+		return "toFloat64(count(*))", nil
+	}
+
+	if field == "" {
+		return "", fmt.Errorf("field is required for aggregation %s", aggregate)
+	}
+
+	numeric := aggregate != "count_distinct"
+	column, err := mapLogFieldColumn(field, numeric)
+	if err != nil {
+		return "", err
+	}
+
+	// logs without the attribute are skipped with the -If combinators, otherwise they are aggregated as 0 or ''
+	suffix := ""
+	if cond := logFieldExistsCondition(field, numeric); cond != "" {
+		suffix = "If"
+		column += ", " + cond
+	}
+
+	switch aggregate {
+	case "count_distinct":
+		return fmt.Sprintf("toFloat64(uniqExact%s(%s))", suffix, column), nil
+	case "sum", "avg", "min", "max":
+		return fmt.Sprintf("toFloat64(%s%s(%s))", aggregate, suffix, column), nil
+	case "quantile":
+		if quantile <= 0 || quantile >= 1 {
+			return "", fmt.Errorf("quantile must be between 0 and 1")
+		}
+		return fmt.Sprintf("toFloat64(quantile%s(%f)(%s))", suffix, quantile, column), nil
+	default:
+		return "", fmt.Errorf("unsupported aggregation: %s", aggregate)
+	}
+}
+
+// logFieldExistsCondition returns the condition of logs having the attribute or resource, empty for the columns of logs table
+func logFieldExistsCondition(field string, numeric bool) string {
+	if strings.HasPrefix(field, "attributes.") {
+		name := field[11:]
+		if numeric {
+			return fmt.Sprintf("(has(attributes_float64_key, '%s') OR has(attributes_int64_key, '%s'))", name, name)
+		}
+		return fmt.Sprintf("has(attributes_string_key, '%s')", name)
+	}
+
+	if strings.HasPrefix(field, "resources.") {
+		return fmt.Sprintf("has(resources_string_key, '%s')", field[10:])
+	}
+
+	return ""
+}
+
+// mapLogFieldColumn maps a field name used in UI to the column expression in logs table,
+// numeric attributes are read from either the float64 or the int64 attributes
+func mapLogFieldColumn(field string, numeric bool) (string, error) {
+	if !fieldNameRegexp.MatchString(field) {
+		return "", fmt.Errorf("invalid field name: %s", field)
+	}
+
+	if strings.HasPrefix(field, "attributes.") {
+		name := field[11:]
+		if numeric {
+			return fmt.Sprintf("if(has(attributes_float64_key, '%s'), attributes_float64_value[indexOf(attributes_float64_key, '%s')], toFloat64(attributes_int64_value[indexOf(attributes_int64_key, '%s')]))", name, name, name), nil
+		}
+		return fmt.Sprintf("attributes_string_value[indexOf(attributes_string_key, '%s')]", name), nil
+	}
+
+	if strings.HasPrefix(field, "resources.") {
+		if numeric {
+			return "", fmt.Errorf("resource %s is not a numeric field", field)
+		}
+		return fmt.Sprintf("resources_string_value[indexOf(resources_string_key, '%s')]", field[10:]), nil
+	}
+
+	switch field {
+	case "service", "host", "severity", "namespace", "group", "trace_id", "span_id":
+		if numeric {
+			return "", fmt.Errorf("%s is not a numeric field", field)
+		}
+		return fmt.Sprintf("`%s`", field), nil
+	case "severity_number":
+		return field, nil
+	default:
+		return "", fmt.Errorf("unsupported field: %s", field)
+	}
+}
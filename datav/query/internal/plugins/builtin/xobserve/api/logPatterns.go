@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	"github.com/xObserve/xObserve/query/pkg/config"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// GetLogPatterns clusters the latest logs in the selected range into patterns,
+// the number of logs read from clickhouse is bounded by `log_patterns.max_sample_size` in config
+func GetLogPatterns(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	step, _ := strconv.ParseInt(c.Query("step"), 10, 64)
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+	if end < start {
+		return models.GenPluginResult(models.PluginStatusError, "end must be greater than start", nil)
+	}
+
+	cfg := config.Data.Observability.LogPatterns
+	maxSampleSize := cfg.MaxSampleSize
+	if maxSampleSize <= 0 {
+		maxSampleSize = xobservemodels.DefaultLogPatternSampleSize
+	}
+	maxPatterns := cfg.MaxPatterns
+	if maxPatterns <= 0 {
+		maxPatterns = xobservemodels.DefaultLogPatternMaxPatterns
+	}
+	similarity := cfg.SimilarityThreshold
+	if similarity <= 0 || similarity > 1 {
+		similarity = xobservemodels.DefaultLogPatternSimilarity
+	}
+
+	sampleSize := xobserveutils.GetIntValueFromParams(params, "sampleSize", maxSampleSize)
+	if sampleSize > maxSampleSize {
+		sampleSize = maxSampleSize
+	}
+	limit := xobserveutils.GetIntValueFromParams(params, "limit", xobservemodels.DefaultLogPatternLimit)
+	samplesPerPattern := xobserveutils.GetIntValueFromParams(params, "samplesPerPattern", xobservemodels.DefaultLogPatternSamplesPerOne)
+
+	domainQuery, searchQuery, searchArgs, err := buildLogsFilterQuery(c, params)
+	if err != nil {
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	query := fmt.Sprintf("SELECT timestamp, id, body FROM %s.%s where (timestamp >= ? AND timestamp <= ? %s %s) order by timestamp desc LIMIT %d", xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable, domainQuery, searchQuery, sampleSize)
+	args := append([]interface{}{start * 1e9, end * 1e9}, searchArgs...)
+	rows, err := conn.Query(c.Request.Context(), query, args...)
+	if err != nil {
+		logger.Warn("Error Query log patterns", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query log patterns", "query", query, "args", args)
+
+	miner := xobserveutils.NewLogPatternMiner(start, end, step, maxPatterns, samplesPerPattern, similarity)
+	var sampled uint64
+	for rows.Next() {
+		var timestamp uint64
+		var id, body string
+		err := rows.Scan(&timestamp, &id, &body)
+		if err != nil {
+			logger.Warn("Error scan log", "error", err)
+			continue
+		}
+		miner.Add(id, timestamp, body)
+		sampled++
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", map[string]interface{}{
+		"patterns":    miner.Patterns(limit),
+		"sampled":     sampled,
+		"unclustered": miner.Unclustered(),
+		"step":        miner.Step(),
+	})
+}
@@ -35,8 +35,13 @@ func GetLogs(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[str
 	logTs := c.Query("logTs")
 
 	if logId != "" {
+		domainQuery, _, _, err := buildLogsFilterQuery(c, params)
+		if err != nil {
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+
 		// query logs
-		logsQuery := fmt.Sprintf(xobservemodels.LogSelectSQL+" FROM %s.%s  where timestamp = ? AND id = ?", xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable)
+		logsQuery := fmt.Sprintf(xobservemodels.LogSelectSQL+" FROM %s.%s  where timestamp = ? AND id = ? %s", xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable, domainQuery)
 
 		rows, err := conn.Query(c.Request.Context(), logsQuery, logTs, logId)
 		if err != nil {
@@ -56,16 +61,9 @@ func GetLogs(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[str
 		return models.GenPluginResult(models.PluginStatusSuccess, "", res)
 	}
 
-	search := c.Query("search")
-	var searchQuery string
-	var searchArgs []interface{}
-	if search != "" {
-		var err error
-		searchQuery, searchArgs, err = parseSearchQuery(search)
-		if err != nil {
-			logger.Info("Error parse search query", "error", err, "query", search)
-			return models.GenPluginResult(models.PluginStatusError, "Parse search query error: "+err.Error(), nil)
-		}
+	domainQuery, searchQuery, searchArgs, err := buildLogsFilterQuery(c, params)
+	if err != nil {
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
 	}
 
 	orderI := params["orderByTimestamp"]
@@ -74,22 +72,6 @@ func GetLogs(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[str
 		order = orderI.(string)
 	}
 
-	tenant := models.GetTenant(c)
-	domainQuery := " AND " + xobserveutils.BuildBasicDomainQuery(tenant, params)
-	services := xobserveutils.GetValueListFromParams(params, "service")
-	hosts := xobserveutils.GetValueListFromParams(params, "host")
-	if services != nil {
-		domainQuery += fmt.Sprintf(" AND service in ('%s')", strings.Join(services, "','"))
-	}
-	if hosts != nil {
-		domainQuery += fmt.Sprintf(" AND host in ('%s')", strings.Join(hosts, "','"))
-	}
-
-	severity := xobserveutils.GetValueListFromParams(params, "severity")
-	if severity != nil {
-		domainQuery += fmt.Sprintf(" AND severity in ('%s')", strings.Join(severity, "','"))
-	}
-
 	// query logs
 	logsQuery := fmt.Sprintf(xobservemodels.LogsSelectSQL+" FROM %s.%s  where (timestamp >= ? AND timestamp <= ? %s %s) order by timestamp %s LIMIT %d OFFSET %d", xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable, domainQuery, searchQuery, order, perPageLogs, page*int64(perPageLogs))
 
@@ -134,6 +116,41 @@ func GetLogs(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[str
 		"chart": res1,
 	})
 }
+
+// buildLogsFilterQuery builds the tenant/domain conditions and the user search conditions
+// shared by all logs APIs, the returned domainQuery and searchQuery both start with " AND "
+func buildLogsFilterQuery(c *gin.Context, params map[string]interface{}) (string, string, []interface{}, error) {
+	search := c.Query("search")
+	var searchQuery string
+	var searchArgs []interface{}
+	if search != "" {
+		var err error
+		searchQuery, searchArgs, err = parseSearchQuery(search)
+		if err != nil {
+			logger.Info("Error parse search query", "error", err, "query", search)
+			return "", "", nil, fmt.Errorf("Parse search query error: %s", err.Error())
+		}
+	}
+
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := " AND " + xobserveutils.BuildBasicDomainQuery(tenants, params)
+	services := xobserveutils.GetValueListFromParams(params, "service")
+	hosts := xobserveutils.GetValueListFromParams(params, "host")
+	if services != nil {
+		domainQuery += fmt.Sprintf(" AND service in ('%s')", strings.Join(services, "','"))
+	}
+	if hosts != nil {
+		domainQuery += fmt.Sprintf(" AND host in ('%s')", strings.Join(hosts, "','"))
+	}
+
+	severity := xobserveutils.GetValueListFromParams(params, "severity")
+	if severity != nil {
+		domainQuery += fmt.Sprintf(" AND severity in ('%s')", strings.Join(severity, "','"))
+	}
+
+	return domainQuery, searchQuery, searchArgs, nil
+}
+
 func parseSearchQuery(query string) (string, []interface{}, error) {
 	result, err := lep.ParseExpression(query)
 	if err != nil {
@@ -20,58 +20,9 @@ type ServiceNameRes struct {
 	ServiceName string `ch:"serviceName"`
 }
 
-func GetServiceNames(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
-	tenant := models.DefaultTenant
-	domainQuery := xobserveutils.BuildBasicDomainQuery(tenant, params)
-
-	query := fmt.Sprintf("SELECT DISTINCT serviceName FROM %s.%s WHERE %s", xobservemodels.DefaultTraceDB, xobservemodels.DefaultServiceOperationsTable, domainQuery)
-
-	rows, err := conn.Query(c.Request.Context(), query)
-	if err != nil {
-		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
-	}
-	defer rows.Close()
-
-	logger.Info("Query service names", "query", query)
-
-	res, err := pluginUtils.ConvertDbRowsToPluginData(rows)
-	if err != nil {
-		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
-	}
-
-	return models.GenPluginResult(models.PluginStatusSuccess, "", res)
-}
-
-func GetServiceOperations(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
-	tenant := models.DefaultTenant
-	domainQuery := xobserveutils.BuildBasicDomainQuery(tenant, params)
-
-	service := xobserveutils.GetValueListFromParams(params, "service")
-	if service != nil {
-		domainQuery += fmt.Sprintf(" AND serviceName in ('%s')", strings.Join(service, "','"))
-	}
-
-	query := fmt.Sprintf("SELECT DISTINCT name FROM %s.%s WHERE %s", xobservemodels.DefaultTraceDB, xobservemodels.DefaultServiceOperationsTable, domainQuery)
-	rows, err := conn.Query(c.Request.Context(), query)
-	if err != nil {
-		logger.Warn("Error Query service operations", "query", query, "error", err)
-		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
-	}
-	defer rows.Close()
-
-	logger.Info("Query service operations", "query", query)
-
-	res, err := pluginUtils.ConvertDbRowsToPluginData(rows)
-	if err != nil {
-		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
-	}
-
-	return models.GenPluginResult(models.PluginStatusSuccess, "", res)
-}
-
 func GetServiceRootOperations(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
-	tenant := models.DefaultTenant
-	domainQuery := xobserveutils.BuildBasicDomainQuery(tenant, params)
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
 
 	service := xobserveutils.GetValueListFromParams(params, "service")
 	if service != nil {
@@ -112,8 +63,8 @@ func GetServiceInfoList(c *gin.Context, ds *models.Datasource, conn ch.Conn, par
 		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
 	}
 
-	tenant := models.DefaultTenant
-	domainQuery := xobserveutils.BuildBasicDomainQuery(tenant, params)
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
 
 	service := xobserveutils.GetValueListFromParams(params, "service")
 	if service != nil {
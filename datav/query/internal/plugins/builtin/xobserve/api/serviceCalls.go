@@ -0,0 +1,229 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// downstream calls of a service, e.g db calls or external http calls
+type serviceCallsQuery struct {
+	countMetric string
+	sumMetric   string
+	// labels identifying a call target in span metrics
+	metricTargets []string
+	// expressions identifying a call target in trace index, in the same order as metricTargets
+	traceTargets []string
+	traceFilter  string
+	columns      []string
+}
+
+var dbCallsQuery = &serviceCallsQuery{
+	countMetric:   xobservemodels.SpanMetricsDBLatencyCount,
+	sumMetric:     xobservemodels.SpanMetricsDBLatencySum,
+	metricTargets: []string{xobservemodels.MetricsDBSystemLabel, xobservemodels.MetricsDBNameLabel},
+	traceTargets:  []string{"dbSystem", "dbName"},
+	// the same as xobservespanmetrics processor: db.system is set and the span is not a server span
+	traceFilter: "dbSystem != '' AND kind != 2",
+	columns:     []string{"timestamp", "dbSystem", "dbName"},
+}
+
+// the remote address of a client span, the same as getRemoteAddress in xobservespanmetrics processor, so it matches the address label of span metrics:
+// rpc service/method, http.host, net peer name or ip with port, host of http.url, peer.service.
+// The processor reads net.peer.port as a string, so a numeric port only leaves the ':'
+var (
+	externalCallPeerPort = "if(mapContains(stringAttributesMap, 'net.peer.port'), concat(':', stringAttributesMap['net.peer.port']), if(mapContains(numberAttributesMap, 'net.peer.port'), ':', ''))"
+	externalCallPeerAddr = fmt.Sprintf("multiIf(mapContains(stringAttributesMap, 'net.peer.name'), concat(stringAttributesMap['net.peer.name'], %s), mapContains(stringAttributesMap, 'net.peer.ip'), concat(stringAttributesMap['net.peer.ip'], %s), '')", externalCallPeerPort, externalCallPeerPort)
+	externalCallRPCAddr  = "concat(stringAttributesMap['rpc.service'], if(mapContains(stringAttributesMap, 'rpc.method'), concat('/', stringAttributesMap['rpc.method']), ''))"
+	externalCallURL      = "if(startsWith(stringAttributesMap['http.url'], 'http://') OR startsWith(stringAttributesMap['http.url'], 'https://'), stringAttributesMap['http.url'], concat('http://', stringAttributesMap['http.url']))"
+	externalCallURLHost  = fmt.Sprintf("concat(domain(%s), if(port(%s) != 0, concat(':', toString(port(%s))), ''))", externalCallURL, externalCallURL, externalCallURL)
+	externalCallAddress  = fmt.Sprintf("multiIf(mapContains(stringAttributesMap, 'rpc.system'), if(%s != '', %s, %s), mapContains(stringAttributesMap, 'http.host'), stringAttributesMap['http.host'], %s != '', %s, mapContains(stringAttributesMap, 'http.url'), %s, stringAttributesMap['peer.service'])",
+		externalCallRPCAddr, externalCallRPCAddr, externalCallPeerAddr, externalCallPeerAddr, externalCallPeerAddr, externalCallURLHost)
+)
+
+var externalCallsQuery = &serviceCallsQuery{
+	countMetric:   xobservemodels.SpanMetricsExternalLatencyCount,
+	sumMetric:     xobservemodels.SpanMetricsExternalLatencySum,
+	metricTargets: []string{xobservemodels.MetricsAddressLabel},
+	traceTargets:  []string{externalCallAddress},
+	// client spans with a remote address
+	traceFilter: fmt.Sprintf("kind = 3 AND %s != ''", externalCallAddress),
+	columns:     []string{"timestamp", "address"},
+}
+
+// GetServiceDBCalls returns call rate, error rate and latency of each database called by a service
+func GetServiceDBCalls(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	return getServiceCalls(c, conn, params, dbCallsQuery)
+}
+
+// GetServiceExternalCalls returns call rate, error rate and latency of each remote address called by a service
+func GetServiceExternalCalls(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	return getServiceCalls(c, conn, params, externalCallsQuery)
+}
+
+type serviceCallsRow struct {
+	timestamp int64
+	targets   []string
+	calls     float64
+	errors    float64
+	avg       float64
+	p50       float64
+	p90       float64
+	p99       float64
+}
+
+// getServiceCalls computes the latency percentiles from trace index, as span metrics only have the sum and count of calls latency.
+// Rate, error rate and avg latency are read from span metrics when present, because traces may be sampled
+func getServiceCalls(c *gin.Context, conn ch.Conn, params map[string]interface{}, q *serviceCallsQuery) models.PluginResult {
+	step, _ := strconv.ParseInt(c.Query("step"), 10, 64)
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+	if step <= 0 {
+		step = 60
+	}
+	step = xobserveutils.LimitMetricsStep(start, end, step)
+
+	service := c.Query("service")
+	if service == "" {
+		service = xobserveutils.GetValueFromParams(params, "service")
+	}
+	if service == "" {
+		return models.GenPluginResult(models.PluginStatusError, "service can not be empty", nil)
+	}
+
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
+
+	selectTargets := ""
+	groupTargets := ""
+	for i, target := range q.traceTargets {
+		selectTargets += fmt.Sprintf("%s AS target%d, ", target, i)
+		groupTargets += fmt.Sprintf(", target%d", i)
+	}
+	query := fmt.Sprintf("SELECT toInt64(toUnixTimestamp(toStartOfInterval(fromUnixTimestamp64Nano(startTime), INTERVAL %d SECOND))) AS timestamp, %stoFloat64(count()), toFloat64(countIf(statusCode=2)), avg(duration) / 1e6, quantile(0.5)(duration) / 1e6, quantile(0.9)(duration) / 1e6, quantile(0.99)(duration) / 1e6 FROM %s.%s WHERE startTime >= %d AND startTime <= %d AND %s AND serviceName=? AND %s GROUP BY timestamp%s ORDER BY timestamp",
+		step, selectTargets, xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, start*1e9, end*1e9, domainQuery, q.traceFilter, groupTargets)
+	rows, err := conn.Query(c.Request.Context(), query, service)
+	if err != nil {
+		logger.Warn("Error Query service calls", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query service calls", "query", query)
+
+	traceRows := make(map[string]*serviceCallsRow)
+	traceKeys := make([]string, 0)
+	for rows.Next() {
+		row := &serviceCallsRow{targets: make([]string, len(q.traceTargets))}
+		dest := []interface{}{&row.timestamp}
+		for i := range row.targets {
+			dest = append(dest, &row.targets[i])
+		}
+		dest = append(dest, &row.calls, &row.errors, &row.avg, &row.p50, &row.p90, &row.p99)
+		if err := rows.Scan(dest...); err != nil {
+			logger.Warn("Error scan service calls", "error", err)
+			continue
+		}
+		key := serviceCallsKey(row.timestamp, row.targets)
+		traceRows[key] = row
+		traceKeys = append(traceKeys, key)
+	}
+
+	metricsFilter := xobserveutils.BuildMetricsDomainQuery(tenants, params) + fmt.Sprintf(" AND JSONExtractString(labels, '%s')='%s'", xobservemodels.MetricsServiceLabel, service)
+	n := len(q.metricTargets)
+	counts, err := xobserveutils.QueryMetricIncreases(c.Request.Context(), conn, q.countMetric, metricsFilter, append(q.metricTargets[:n:n], xobservemodels.MetricsStatusLabel), start, end, step)
+	if err != nil {
+		logger.Warn("Error Query service calls metrics", "metric", q.countMetric, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	var result []*serviceCallsRow
+	if len(counts) == 0 {
+		// span metrics are missing, use the values computed from traces
+		result = make([]*serviceCallsRow, 0, len(traceKeys))
+		for _, key := range traceKeys {
+			result = append(result, traceRows[key])
+		}
+	} else {
+		sums, err := xobserveutils.QueryMetricIncreases(c.Request.Context(), conn, q.sumMetric, metricsFilter, q.metricTargets, start, end, step)
+		if err != nil {
+			logger.Warn("Error Query service calls metrics", "metric", q.sumMetric, "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+
+		startBucket := start / step
+		metricRows := make(map[string]*serviceCallsRow)
+		for _, increases := range counts {
+			targets := increases.Labels[:n]
+			isError := increases.Labels[n] == xobservemodels.StatusCodeError
+			for i, v := range increases.Values {
+				if v == 0 {
+					continue
+				}
+				timestamp := (startBucket + int64(i)) * step
+				key := serviceCallsKey(timestamp, targets)
+				row, ok := metricRows[key]
+				if !ok {
+					row = &serviceCallsRow{timestamp: timestamp, targets: targets}
+					metricRows[key] = row
+				}
+				row.calls += v
+				if isError {
+					row.errors += v
+				}
+			}
+		}
+
+		for _, increases := range sums {
+			for i, v := range increases.Values {
+				row, ok := metricRows[serviceCallsKey((startBucket+int64(i))*step, increases.Labels)]
+				if ok {
+					row.avg += v
+				}
+			}
+		}
+
+		result = make([]*serviceCallsRow, 0, len(metricRows))
+		for key, row := range metricRows {
+			row.avg = row.avg / row.calls
+			traceRow, ok := traceRows[key]
+			if ok {
+				row.p50, row.p90, row.p99 = traceRow.p50, traceRow.p90, traceRow.p99
+			}
+			result = append(result, row)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].timestamp < result[j].timestamp
+		})
+	}
+
+	data := make([][]interface{}, 0, len(result))
+	for _, row := range result {
+		values := []interface{}{row.timestamp}
+		for _, target := range row.targets {
+			values = append(values, target)
+		}
+		values = append(values, row.calls/float64(step), row.errors/row.calls, row.avg, row.p50, row.p90, row.p99)
+		data = append(data, values)
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", &models.PluginResultData{
+		Columns:     append(q.columns[:len(q.columns):len(q.columns)], "rate", "errorRate", "avg", "p50", "p90", "p99"),
+		Data:        data,
+		ColumnTypes: map[string]string{"timestamp": "time"},
+	})
+}
+
+func serviceCallsKey(timestamp int64, targets []string) string {
+	return strconv.FormatInt(timestamp, 10) + xobservemodels.VariableSplitChart + strings.Join(targets, xobservemodels.VariableSplitChart)
+}
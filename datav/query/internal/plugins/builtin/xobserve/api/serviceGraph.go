@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -13,15 +14,53 @@ import (
 	"github.com/xObserve/xObserve/query/pkg/models"
 )
 
+// edges written before dest_type was added are service calls
+const dependencyEdgeTypeSQL = "if(dest_type = '', 'service', dest_type)"
+
+const (
+	defaultNodeErrorRateWarning  = 0.01
+	defaultNodeErrorRateCritical = 0.05
+)
+
+type DependencyGraphNode struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // service, db or messaging
+	// calls received by the node, latency is in ns
+	Calls     uint64  `json:"calls"`
+	Errors    uint64  `json:"errors"`
+	ErrorRate float64 `json:"errorRate"`
+	P99       float64 `json:"p99"`
+	// calls sent by the node to its downstreams
+	OutgoingCalls  uint64 `json:"outgoingCalls"`
+	OutgoingErrors uint64 `json:"outgoingErrors"`
+	Health         string `json:"health"` // healthy, warning, critical or unknown
+}
+
+// GetDependencyGraph returns the edges between services, databases and messaging systems
+//
+// params:
+//   - source, target: filter edges by src and dest
+//   - types: edge types, e.g service|db|messaging, default to all types
+//   - breakdown: break edges down by namespace and group, or by client and server instance, e.g namespace|instance
+//   - timeseries: when true, the rate, error rate and p99 latency of each edge in each step are also returned
+//   - health: when true, the health of each node is computed from the calls it receives,
+//     errorRateWarning and errorRateCritical are the thresholds, default to 0.01 and 0.05
+//
+// When timeseries or health is enabled, the result is {graph, timeseries, nodes} instead of the edges only
 func GetDependencyGraph(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
 	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
 	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	step, _ := strconv.ParseInt(c.Query("step"), 10, 64)
+	if step <= 0 {
+		step = 60
+	}
 
 	source := xobserveutils.GetValueListFromParams(params, "source")
 	target := xobserveutils.GetValueListFromParams(params, "target")
+	types := xobserveutils.GetValueListFromParams(params, "types")
 
-	tenant := models.GetTenant(c)
-	domainQuery := xobserveutils.BuildBasicDomainQuery(tenant, params)
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
 
 	if source != nil {
 		domainQuery += fmt.Sprintf(" AND src in ('%s')", strings.Join(source, "','"))
@@ -29,13 +68,30 @@ func GetDependencyGraph(c *gin.Context, ds *models.Datasource, conn ch.Conn, par
 	if target != nil {
 		domainQuery += fmt.Sprintf(" AND dest in ('%s')", strings.Join(target, "','"))
 	}
+	if types != nil {
+		domainQuery += fmt.Sprintf(" AND %s in ('%s')", dependencyEdgeTypeSQL, strings.Join(types, "','"))
+	}
+
+	selectDims := fmt.Sprintf("src,\n\tdest,\n\t%s AS type,", dependencyEdgeTypeSQL)
+	groupBy := "src, dest, type"
+	for _, b := range xobserveutils.GetValueListFromParams(params, "breakdown") {
+		switch b {
+		case "namespace":
+			selectDims += "\n\tnamespace,\n\tgroup,"
+			groupBy += ", namespace, group"
+		case "instance":
+			selectDims += "\n\tsrc_instance AS srcInstance,\n\tdest_instance AS destInstance,"
+			groupBy += ", srcInstance, destInstance"
+		default:
+			return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("unsupported breakdown: %s", b), nil)
+		}
+	}
 
 	query := fmt.Sprintf(`WITH
 	quantilesMergeState(0.5, 0.75, 0.9, 0.95, 0.99)(duration_quantiles_state) AS duration_quantiles_state,
 	finalizeAggregation(duration_quantiles_state) AS result
 SELECT
-	src,
-	dest,
+	%s
 	result[1] AS p50,
 	result[2] AS p75,
 	result[3] AS p90,
@@ -43,9 +99,9 @@ SELECT
 	result[5] AS p99,
 	sum(total_count) as calls,
 	sum(error_count) as errors
-FROM %s.%s	
-WHERE toUInt64(toDateTime(timestamp)) >= ? AND toUInt64(toDateTime(timestamp)) <= ? AND %s GROUP BY src, dest`,
-		xobservemodels.DefaultTraceDB, xobservemodels.DefaultDependencyGraphTable, domainQuery)
+FROM %s.%s
+WHERE toUInt64(toDateTime(timestamp)) >= ? AND toUInt64(toDateTime(timestamp)) <= ? AND %s GROUP BY %s`,
+		selectDims, xobservemodels.DefaultTraceDB, xobservemodels.DefaultDependencyGraphTable, domainQuery, groupBy)
 
 	rows, err := conn.Query(c.Request.Context(), query, start, end)
 	if err != nil {
@@ -62,5 +118,140 @@ WHERE toUInt64(toDateTime(timestamp)) >= ? AND toUInt64(toDateTime(timestamp)) <
 		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
 	}
 
-	return models.GenPluginResult(models.PluginStatusSuccess, "", res)
+	withTimeseries, _ := params["timeseries"].(bool)
+	withHealth, _ := params["health"].(bool)
+	if !withTimeseries && !withHealth {
+		return models.GenPluginResult(models.PluginStatusSuccess, "", res)
+	}
+
+	result := map[string]interface{}{
+		"graph": res,
+	}
+
+	if withTimeseries {
+		query := fmt.Sprintf(`SELECT
+	toStartOfInterval(timestamp, INTERVAL %d SECOND) AS ts_bucket,
+	src,
+	dest,
+	%s AS type,
+	sum(total_count) / %d AS rate,
+	sum(error_count) / sum(total_count) AS errorRate,
+	quantilesMerge(0.5, 0.75, 0.9, 0.95, 0.99)(duration_quantiles_state)[5] AS p99
+FROM %s.%s
+WHERE toUInt64(toDateTime(timestamp)) >= ? AND toUInt64(toDateTime(timestamp)) <= ? AND %s GROUP BY ts_bucket, src, dest, type ORDER BY ts_bucket`,
+			step, dependencyEdgeTypeSQL, step, xobservemodels.DefaultTraceDB, xobservemodels.DefaultDependencyGraphTable, domainQuery)
+
+		rows, err := conn.Query(c.Request.Context(), query, start, end)
+		if err != nil {
+			logger.Warn("Error Query dependency graph timeseries", "query", query, "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		defer rows.Close()
+
+		logger.Info("Query denendency graph timeseries", "query", query)
+
+		timeseries, err := pluginUtils.ConvertDbRowsToPluginData(rows)
+		if err != nil {
+			logger.Warn("Error conver rows to data", "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		result["timeseries"] = timeseries
+	}
+
+	if withHealth {
+		warning := defaultNodeErrorRateWarning
+		if v, ok := params["errorRateWarning"].(float64); ok {
+			warning = v
+		}
+		critical := defaultNodeErrorRateCritical
+		if v, ok := params["errorRateCritical"].(float64); ok {
+			critical = v
+		}
+
+		nodes, err := queryDependencyGraphNodes(c.Request.Context(), conn, domainQuery, start, end, warning, critical)
+		if err != nil {
+			logger.Warn("Error Query dependency graph nodes", "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		result["nodes"] = nodes
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", result)
+}
+
+func queryDependencyGraphNodes(ctx context.Context, conn ch.Conn, domainQuery string, start, end int64, warning, critical float64) ([]*DependencyGraphNode, error) {
+	nodesMap := make(map[string]*DependencyGraphNode)
+	nodes := make([]*DependencyGraphNode, 0)
+	getNode := func(name string) *DependencyGraphNode {
+		node, ok := nodesMap[name]
+		if !ok {
+			node = &DependencyGraphNode{Name: name, Type: "service"}
+			nodesMap[name] = node
+			nodes = append(nodes, node)
+		}
+		return node
+	}
+
+	query := fmt.Sprintf("SELECT dest, any(%s), sum(total_count), sum(error_count), quantilesMerge(0.5, 0.75, 0.9, 0.95, 0.99)(duration_quantiles_state)[5] FROM %s.%s WHERE toUInt64(toDateTime(timestamp)) >= ? AND toUInt64(toDateTime(timestamp)) <= ? AND %s GROUP BY dest",
+		dependencyEdgeTypeSQL, xobservemodels.DefaultTraceDB, xobservemodels.DefaultDependencyGraphTable, domainQuery)
+	rows, err := conn.Query(ctx, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, tp string
+		var calls, errors uint64
+		var p99 float64
+		err := rows.Scan(&name, &tp, &calls, &errors, &p99)
+		if err != nil {
+			return nil, err
+		}
+		node := getNode(name)
+		node.Type = tp
+		node.Calls = calls
+		node.Errors = errors
+		node.P99 = p99
+	}
+
+	query = fmt.Sprintf("SELECT src, sum(total_count), sum(error_count) FROM %s.%s WHERE toUInt64(toDateTime(timestamp)) >= ? AND toUInt64(toDateTime(timestamp)) <= ? AND %s GROUP BY src",
+		xobservemodels.DefaultTraceDB, xobservemodels.DefaultDependencyGraphTable, domainQuery)
+	rows, err = conn.Query(ctx, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var calls, errors uint64
+		err := rows.Scan(&name, &calls, &errors)
+		if err != nil {
+			return nil, err
+		}
+		node := getNode(name)
+		node.OutgoingCalls = calls
+		node.OutgoingErrors = errors
+	}
+
+	for _, node := range nodes {
+		if node.Calls == 0 {
+			// e.g the entry services, their health can't be told from the graph
+			node.Health = "unknown"
+			continue
+		}
+
+		node.ErrorRate = float64(node.Errors) / float64(node.Calls)
+		switch {
+		case node.ErrorRate >= critical:
+			node.Health = "critical"
+		case node.ErrorRate >= warning:
+			node.Health = "warning"
+		default:
+			node.Health = "healthy"
+		}
+	}
+
+	return nodes, nil
 }
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+var redMetricsColumns = []string{"rate", "errorRate", "p50", "p90", "p99"}
+
+// GetServiceMetrics returns rate, error rate and latency percentiles of services over time,
+// only server and consumer spans are counted, as they represent the requests handled by a service
+func GetServiceMetrics(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	return getREDMetrics(c, conn, params, false)
+}
+
+// GetOperationMetrics returns rate, error rate and latency percentiles of each operation over time
+func GetOperationMetrics(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	return getREDMetrics(c, conn, params, true)
+}
+
+// getREDMetrics reads the pre-aggregated span metrics first, and falls back to raw trace index for the services without metrics.
+// Timestamps of both are unix seconds aligned to step
+func getREDMetrics(c *gin.Context, conn ch.Conn, params map[string]interface{}, byOperation bool) models.PluginResult {
+	step, _ := strconv.ParseInt(c.Query("step"), 10, 64)
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+	if step <= 0 {
+		step = 60
+	}
+	step = xobserveutils.LimitMetricsStep(start, end, step)
+
+	tenants := models.GetTelemetryTenants(c)
+	services := xobserveutils.GetValueListFromParams(params, "service")
+	operations := xobserveutils.GetValueListFromParams(params, "operation")
+
+	metricsFilter := xobserveutils.BuildMetricsDomainQuery(tenants, params)
+	if services != nil {
+		metricsFilter += fmt.Sprintf(" AND JSONExtractString(labels, '%s') in ('%s')", xobservemodels.MetricsServiceLabel, strings.Join(services, "','"))
+	}
+	groupLabels := []string{xobservemodels.MetricsServiceLabel}
+	columns := []string{"timestamp", "serviceName"}
+	if byOperation {
+		if operations != nil {
+			metricsFilter += fmt.Sprintf(" AND JSONExtractString(labels, '%s') in ('%s')", xobservemodels.MetricsOperationLabel, strings.Join(operations, "','"))
+		}
+		groupLabels = append(groupLabels, xobservemodels.MetricsOperationLabel)
+		columns = append(columns, "operation")
+	} else {
+		metricsFilter += fmt.Sprintf(" AND JSONExtractString(labels, '%s') in ('%s','%s')", xobservemodels.MetricsSpanKindLabel, xobservemodels.SpanKindServer, xobservemodels.SpanKindConsumer)
+	}
+	columns = append(columns, redMetricsColumns...)
+
+	data, err := queryREDMetricsFromSpanMetrics(c.Request.Context(), conn, metricsFilter, groupLabels, start, end, step)
+	if err != nil {
+		logger.Warn("Error Query span metrics", "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	// services having span metrics are not computed again from traces
+	metricsServices := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, row := range data {
+		service := row[1].(string)
+		if !seen[service] {
+			seen[service] = true
+			metricsServices = append(metricsServices, service)
+		}
+	}
+
+	// compute from raw trace index for the services without span metrics
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
+	if services != nil {
+		domainQuery += fmt.Sprintf(" AND serviceName in ('%s')", strings.Join(services, "','"))
+	}
+	domainQuery += " AND NOT has(?, serviceName)"
+	selectGroups := "serviceName"
+	orderBy := "serviceName"
+	if byOperation {
+		if operations != nil {
+			domainQuery += fmt.Sprintf(" AND name in ('%s')", strings.Join(operations, "','"))
+		}
+		selectGroups += ", name AS operation"
+		orderBy += ", operation"
+	} else {
+		// server and consumer
+		domainQuery += " AND kind in (2, 5)"
+	}
+
+	query := fmt.Sprintf("SELECT toInt64(toUnixTimestamp(toStartOfInterval(fromUnixTimestamp64Nano(startTime), INTERVAL %d SECOND))) AS timestamp, %s, count() / %d AS rate, countIf(statusCode=2) / count() AS errorRate, quantile(0.5)(duration) / 1e6 AS p50, quantile(0.9)(duration) / 1e6 AS p90, quantile(0.99)(duration) / 1e6 AS p99 FROM %s.%s WHERE startTime >= %d AND startTime <= %d AND %s GROUP BY %s, timestamp ORDER BY %s, timestamp",
+		step, selectGroups, step, xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, start*1e9, end*1e9, domainQuery, orderBy, orderBy)
+	rows, err := conn.Query(c.Request.Context(), query, metricsServices)
+	if err != nil {
+		logger.Warn("Error Query red metrics from traces", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query red metrics from traces", "query", query)
+
+	for rows.Next() {
+		var timestamp int64
+		var rate, errorRate, p50, p90, p99 float64
+		labels := make([]string, len(groupLabels))
+		dest := []interface{}{&timestamp}
+		for i := range labels {
+			dest = append(dest, &labels[i])
+		}
+		dest = append(dest, &rate, &errorRate, &p50, &p90, &p99)
+		if err := rows.Scan(dest...); err != nil {
+			logger.Warn("Error scan red metrics from traces", "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+
+		row := []interface{}{timestamp}
+		for _, label := range labels {
+			row = append(row, label)
+		}
+		data = append(data, append(row, rate, errorRate, p50, p90, p99))
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", &models.PluginResultData{
+		Columns:     columns,
+		Data:        data,
+		ColumnTypes: map[string]string{"timestamp": "time"},
+	})
+}
+
+type redSeries struct {
+	labels  []string
+	calls   []float64
+	errors  []float64
+	buckets []map[string]float64
+}
+
+// queryREDMetricsFromSpanMetrics returns rows of [timestamp, groupLabels..., rate, errorRate, p50, p90, p99],
+// nil is returned when there is no span metrics matching the filter
+func queryREDMetricsFromSpanMetrics(ctx context.Context, conn ch.Conn, filter string, groupLabels []string, start, end, step int64) ([][]interface{}, error) {
+	n := len(groupLabels)
+	calls, err := xobserveutils.QueryMetricIncreases(ctx, conn, xobservemodels.SpanMetricsCalls, filter, append(groupLabels[:n:n], xobservemodels.MetricsStatusLabel), start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	latency, err := xobserveutils.QueryMetricIncreases(ctx, conn, xobservemodels.SpanMetricsLatencyBucket, filter, append(groupLabels[:n:n], xobservemodels.MetricsBucketLabel), start, end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesMap := make(map[string]*redSeries)
+	getSeries := func(labels []string, buckets int) *redSeries {
+		key := strings.Join(labels, xobservemodels.VariableSplitChart)
+		series, ok := seriesMap[key]
+		if !ok {
+			series = &redSeries{
+				labels:  labels,
+				calls:   make([]float64, buckets),
+				errors:  make([]float64, buckets),
+				buckets: make([]map[string]float64, buckets),
+			}
+			for i := range series.buckets {
+				series.buckets[i] = make(map[string]float64)
+			}
+			seriesMap[key] = series
+		}
+		return series
+	}
+
+	for _, increases := range calls {
+		series := getSeries(increases.Labels[:n], len(increases.Values))
+		isError := increases.Labels[n] == xobservemodels.StatusCodeError
+		for i, v := range increases.Values {
+			series.calls[i] += v
+			if isError {
+				series.errors[i] += v
+			}
+		}
+	}
+
+	for _, increases := range latency {
+		series := getSeries(increases.Labels[:n], len(increases.Values))
+		le := increases.Labels[n]
+		for i, v := range increases.Values {
+			series.buckets[i][le] += v
+		}
+	}
+
+	keys := make([]string, 0, len(seriesMap))
+	for key := range seriesMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	startBucket := start / step
+	data := make([][]interface{}, 0)
+	for _, key := range keys {
+		series := seriesMap[key]
+		for i, calls := range series.calls {
+			if calls == 0 {
+				continue
+			}
+			row := []interface{}{(startBucket + int64(i)) * step}
+			for _, label := range series.labels {
+				row = append(row, label)
+			}
+			row = append(row,
+				calls/float64(step),
+				series.errors[i]/calls,
+				xobserveutils.HistogramQuantile(0.5, series.buckets[i]),
+				xobserveutils.HistogramQuantile(0.9, series.buckets[i]),
+				xobserveutils.HistogramQuantile(0.99, series.buckets[i]),
+			)
+			data = append(data, row)
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+const maxCompareTraces = 1000
+
+// One side of trace comparison, start and end default to the time range of the request
+type TraceSet struct {
+	Start      int64                                `json:"start"`
+	End        int64                                `json:"end"`
+	Conditions []*xobservemodels.TraceSpanCondition `json:"conditions"`
+}
+
+type TraceCompareRequest struct {
+	Service    string    `json:"service"`
+	Operation  string    `json:"operation"`
+	Baseline   *TraceSet `json:"baseline"`
+	Comparison *TraceSet `json:"comparison"`
+	Limit      int       `json:"limit"`
+}
+
+// self time stats of a span type(service + operation), times are in ms
+type SpanTypeStats struct {
+	Spans            uint64  `json:"spans"`
+	SpansPerTrace    float64 `json:"spansPerTrace"`
+	SelfTimePerTrace float64 `json:"selfTimePerTrace"`
+	SelfTimeAvg      float64 `json:"selfTimeAvg"`
+	SelfTimeP50      float64 `json:"selfTimeP50"`
+	SelfTimeP90      float64 `json:"selfTimeP90"`
+	SelfTimeP99      float64 `json:"selfTimeP99"`
+}
+
+type SpanTypeDiff struct {
+	ServiceName string         `json:"serviceName"`
+	Name        string         `json:"name"`
+	Status      string         `json:"status"` // changed, new or missing
+	Baseline    *SpanTypeStats `json:"baseline"`
+	Comparison  *SpanTypeStats `json:"comparison"`
+	// how much the self time of this span type contributes to the latency change of a trace, in ms
+	SelfTimePerTraceDiff float64 `json:"selfTimePerTraceDiff"`
+	SelfTimeP50Diff      float64 `json:"selfTimeP50Diff"`
+	SelfTimeP99Diff      float64 `json:"selfTimeP99Diff"`
+	SpansPerTraceDiff    float64 `json:"spansPerTraceDiff"`
+}
+
+// CompareTraces compares two sets of traces of the same entry operation,
+// and attributes the latency change to each span type by self time
+func CompareTraces(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+
+	req := &TraceCompareRequest{}
+	b, _ := json.Marshal(params)
+	err := json.Unmarshal(b, req)
+	if err != nil {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("decode compare params error: %s", err.Error()), nil)
+	}
+
+	if req.Service == "" || req.Operation == "" {
+		return models.GenPluginResult(models.PluginStatusError, "service and operation can not be empty", nil)
+	}
+	if req.Baseline == nil || req.Comparison == nil {
+		return models.GenPluginResult(models.PluginStatusError, "baseline and comparison are required", nil)
+	}
+	if req.Limit <= 0 || req.Limit > maxCompareTraces {
+		req.Limit = 200
+	}
+
+	domainQuery := xobserveutils.BuildBasicDomainQuery(models.GetTelemetryTenants(c), params)
+
+	stats := make([]map[string]*SpanTypeStats, 0, 2)
+	numTraces := make([]int, 0, 2)
+	for _, set := range []*TraceSet{req.Baseline, req.Comparison} {
+		if set.Start == 0 || set.End == 0 {
+			set.Start, set.End = start, end
+		}
+		if set.Start == 0 || set.End == 0 {
+			return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+		}
+
+		spans, traces, err := queryTraceSetSpans(c.Request.Context(), conn, domainQuery, req, set)
+		if err != nil {
+			logger.Warn("Error Query trace set", "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		stats = append(stats, computeSpanTypeStats(spans, traces))
+		numTraces = append(numTraces, traces)
+	}
+
+	diffs := make([]*SpanTypeDiff, 0)
+	for key, baseline := range stats[0] {
+		comparison := stats[1][key]
+		diffs = append(diffs, newSpanTypeDiff(key, baseline, comparison))
+	}
+	for key, comparison := range stats[1] {
+		if _, ok := stats[0][key]; !ok {
+			diffs = append(diffs, newSpanTypeDiff(key, nil, comparison))
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return math.Abs(diffs[i].SelfTimePerTraceDiff) > math.Abs(diffs[j].SelfTimePerTraceDiff)
+	})
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", map[string]interface{}{
+		"baselineTraces":   numTraces[0],
+		"comparisonTraces": numTraces[1],
+		"spans":            diffs,
+	})
+}
+
+// queryTraceSetSpans returns the spans of the latest traces in the set and the number of traces
+func queryTraceSetSpans(ctx context.Context, conn ch.Conn, domainQuery string, req *TraceCompareRequest, set *TraceSet) ([]*xobservemodels.TraceSpan, int, error) {
+	filterQuery, args, err := buildTraceSpanFilterQuery(&xobservemodels.TraceSpanFilter{
+		Service:    req.Service,
+		Operation:  req.Operation,
+		Conditions: set.Conditions,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tracesQuery := fmt.Sprintf("SELECT traceId FROM %s.%s WHERE startTime >= %d AND startTime <= %d AND %s AND %s GROUP BY traceId ORDER BY max(startTime) DESC LIMIT %d",
+		xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, set.Start*1e9, set.End*1e9, domainQuery, filterQuery, req.Limit)
+	query := fmt.Sprintf("SELECT traceId, spanId, parentId, serviceName, name, startTime, duration FROM %s.%s WHERE startTime >= %d AND startTime <= %d AND traceId IN (%s)",
+		xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, set.Start*1e9, set.End*1e9, tracesQuery)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	logger.Info("Query trace set spans", "query", query)
+
+	spans := make([]*xobservemodels.TraceSpan, 0)
+	traces := make(map[string]bool)
+	for rows.Next() {
+		span := &xobservemodels.TraceSpan{}
+		err := rows.Scan(&span.TraceId, &span.SpanId, &span.ParentId, &span.ServiceName, &span.Name, &span.StartTime, &span.Duration)
+		if err != nil {
+			return nil, 0, err
+		}
+		spans = append(spans, span)
+		traces[span.TraceId] = true
+	}
+
+	return spans, len(traces), nil
+}
+
+func computeSpanTypeStats(spans []*xobservemodels.TraceSpan, numTraces int) map[string]*SpanTypeStats {
+	traceSpans := make(map[string][]*xobservemodels.TraceSpan)
+	for _, span := range spans {
+		traceSpans[span.TraceId] = append(traceSpans[span.TraceId], span)
+	}
+
+	selfTimes := make(map[string][]float64)
+	for _, spans := range traceSpans {
+		roots, orphans := xobserveutils.BuildSpanTree(spans)
+		for _, node := range append(roots, orphans...) {
+			xobserveutils.ComputeSelfTime(node)
+			walkSpanTree(node, func(n *xobservemodels.SpanNode) {
+				key := n.Span.ServiceName + xobservemodels.VariableSplitChart + n.Span.Name
+				selfTimes[key] = append(selfTimes[key], float64(n.SelfTime)/1e6)
+			})
+		}
+	}
+
+	stats := make(map[string]*SpanTypeStats)
+	for key, times := range selfTimes {
+		sort.Float64s(times)
+		var sum float64
+		for _, t := range times {
+			sum += t
+		}
+		stats[key] = &SpanTypeStats{
+			Spans:            uint64(len(times)),
+			SpansPerTrace:    float64(len(times)) / float64(numTraces),
+			SelfTimePerTrace: sum / float64(numTraces),
+			SelfTimeAvg:      sum / float64(len(times)),
+			SelfTimeP50:      percentile(times, 0.5),
+			SelfTimeP90:      percentile(times, 0.9),
+			SelfTimeP99:      percentile(times, 0.99),
+		}
+	}
+
+	return stats
+}
+
+func newSpanTypeDiff(key string, baseline, comparison *SpanTypeStats) *SpanTypeDiff {
+	service, name := splitSpanTypeKey(key)
+	diff := &SpanTypeDiff{
+		ServiceName: service,
+		Name:        name,
+		Status:      "changed",
+		Baseline:    baseline,
+		Comparison:  comparison,
+	}
+
+	empty := &SpanTypeStats{}
+	if baseline == nil {
+		diff.Status = "new"
+		baseline = empty
+	}
+	if comparison == nil {
+		diff.Status = "missing"
+		comparison = empty
+	}
+
+	diff.SelfTimePerTraceDiff = comparison.SelfTimePerTrace - baseline.SelfTimePerTrace
+	diff.SelfTimeP50Diff = comparison.SelfTimeP50 - baseline.SelfTimeP50
+	diff.SelfTimeP99Diff = comparison.SelfTimeP99 - baseline.SelfTimeP99
+	diff.SpansPerTraceDiff = comparison.SpansPerTrace - baseline.SpansPerTrace
+
+	return diff
+}
+
+func splitSpanTypeKey(key string) (string, string) {
+	parts := strings.SplitN(key, xobservemodels.VariableSplitChart, 2)
+	if len(parts) < 2 {
+		return key, ""
+	}
+	return parts[0], parts[1]
+}
+
+func walkSpanTree(node *xobservemodels.SpanNode, fn func(*xobservemodels.SpanNode)) {
+	fn(node)
+	for _, child := range node.Children {
+		walkSpanTree(child, fn)
+	}
+}
+
+// percentile of sorted values
+func percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// ParseTrace parses the trace file in request body and returns it in the same format as getTrace,
+// so an exported trace can be rendered in trace view without being written to clickhouse.
+// The format is detected from the content when format query is empty.
+// If the file contains several traces, traceId query is required to pick one of them
+func ParseTrace(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, xobservemodels.MaxTraceFileSize)
+	body, err := c.GetRawData()
+	if err != nil {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("read trace file error: %s", err.Error()), nil)
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format, err = detectTraceFormat(body)
+		if err != nil {
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+	}
+
+	var spans []*xobservemodels.TraceSpan
+	switch format {
+	case xobservemodels.TraceFormatOTLPJSON:
+		data := &xobservemodels.OTLPTraceData{}
+		err = json.Unmarshal(body, data)
+		if err == nil {
+			spans, err = xobserveutils.TraceFromOTLP(data)
+		}
+	case xobservemodels.TraceFormatJaegerJSON:
+		data := &xobservemodels.JaegerTraceData{}
+		err = json.Unmarshal(body, data)
+		if err == nil {
+			spans, err = xobserveutils.TraceFromJaeger(data)
+		}
+	default:
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("unsupported trace format: %s", format), nil)
+	}
+	if err != nil {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("parse %s trace file error: %s", format, err.Error()), nil)
+	}
+
+	traceID := c.Query("traceId")
+	traces := make(map[string][]*xobservemodels.TraceSpan)
+	for _, span := range spans {
+		if traceID == "" || span.TraceId == traceID {
+			traces[span.TraceId] = append(traces[span.TraceId], span)
+		}
+	}
+	if len(traces) == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "no trace found in trace file", nil)
+	}
+	if len(traces) > 1 {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("trace file contains %d traces, please specify one with traceId", len(traces)), nil)
+	}
+
+	for id, traceSpans := range traces {
+		traceID, spans = id, traceSpans
+	}
+
+	if c.Query("analyze") == "true" {
+		return models.GenPluginResult(models.PluginStatusSuccess, "", xobserveutils.AnalyzeTrace(traceID, spans))
+	}
+
+	data := make([][]interface{}, 0, len(spans))
+	for _, span := range spans {
+		model, _ := json.Marshal(span)
+		data = append(data, []interface{}{span.StartTime, span.TraceId, string(model)})
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", &models.PluginResultData{
+		Columns: []string{"startTime", "traceId", "model"},
+		Data:    data,
+	})
+}
+
+func detectTraceFormat(body []byte) (string, error) {
+	fields := make(map[string]json.RawMessage)
+	err := json.Unmarshal(body, &fields)
+	if err != nil {
+		return "", fmt.Errorf("trace file is not a valid json: %s", err.Error())
+	}
+
+	if _, ok := fields["resourceSpans"]; ok {
+		return xobservemodels.TraceFormatOTLPJSON, nil
+	}
+	if _, ok := fields["data"]; ok {
+		return xobservemodels.TraceFormatJaegerJSON, nil
+	}
+
+	return "", fmt.Errorf("unknown trace file format, %s and %s are supported", xobservemodels.TraceFormatOTLPJSON, xobservemodels.TraceFormatJaegerJSON)
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	pluginUtils "github.com/xObserve/xObserve/query/internal/plugins/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+const (
+	// logs may be written a little before or after the spans, e.g clock skew between hosts or async logging
+	traceLogsTimeMargin = 60
+	maxTraceLogs        = 2000
+)
+
+type TraceLogsService struct {
+	Service   string `json:"service"`
+	Count     uint64 `json:"count"`
+	Errors    uint64 `json:"errors"`
+	Spans     uint64 `json:"spans"`
+	FirstSeen uint64 `json:"firstSeen"`
+	LastSeen  uint64 `json:"lastSeen"`
+}
+
+// GetTraceLogs returns the logs of a trace ordered by timestamp, and the logs count of each service.
+// When spanId is given, only the logs of that span are returned
+func GetTraceLogs(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	traceID := strings.TrimSpace(c.Query("traceId"))
+	if traceID == "" {
+		return models.GenPluginResult(models.PluginStatusError, "traceId can not be empty", nil)
+	}
+	spanID := strings.TrimSpace(c.Query("spanId"))
+	tenantQuery := xobserveutils.BuildTenantQuery(models.GetTelemetryTenants(c))
+
+	traceStart, traceEnd, err := queryTraceTimeRange(c.Request.Context(), conn, tenantQuery, traceID)
+	if err != nil {
+		logger.Warn("Error Query trace time range", "traceId", traceID, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	if traceStart == 0 {
+		// the trace may be sampled out, use the time range of the request instead
+		start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+		end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+		if start == 0 || end == 0 {
+			return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("trace %s not found, start and end is required", traceID), nil)
+		}
+		traceStart, traceEnd = uint64(start)*1e9, uint64(end)*1e9
+	}
+
+	where := "timestamp >= ? AND timestamp <= ? AND" + tenantQuery + " AND trace_id = ?"
+	args := []interface{}{traceStart - traceLogsTimeMargin*1e9, traceEnd + traceLogsTimeMargin*1e9, traceID}
+	if spanID != "" {
+		where += " AND span_id = ?"
+		args = append(args, spanID)
+	}
+
+	logsQuery := fmt.Sprintf(xobservemodels.LogsSelectSQL+" FROM %s.%s where %s order by timestamp LIMIT %d", xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable, where, maxTraceLogs)
+	rows, err := conn.Query(c.Request.Context(), logsQuery, args...)
+	if err != nil {
+		logger.Warn("Error Query trace logs", "query", logsQuery, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query trace logs", "query", logsQuery, "args", args)
+
+	logs, err := pluginUtils.ConvertDbRowsToPluginData(rows)
+	if err != nil {
+		logger.Warn("Error conver rows to data", "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	servicesQuery := fmt.Sprintf("SELECT service, count(), countIf(lower(severity) IN ('error', 'fatal')), uniq(span_id), min(timestamp), max(timestamp) FROM %s.%s where %s group by service order by min(timestamp)", xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable, where)
+	rows, err = conn.Query(c.Request.Context(), servicesQuery, args...)
+	if err != nil {
+		logger.Warn("Error Query trace logs services", "query", servicesQuery, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	services := make([]*TraceLogsService, 0)
+	for rows.Next() {
+		service := &TraceLogsService{}
+		err := rows.Scan(&service.Service, &service.Count, &service.Errors, &service.Spans, &service.FirstSeen, &service.LastSeen)
+		if err != nil {
+			logger.Warn("Error scan trace logs service", "error", err)
+			continue
+		}
+		services = append(services, service)
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", map[string]interface{}{
+		"logs":     logs,
+		"services": services,
+	})
+}
+
+// GetLogTrace resolves the trace summary of a log and the span which the log belongs to
+func GetLogTrace(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	logId := c.Query("logId")
+	logTs := c.Query("logTs")
+	if logId == "" || logTs == "" {
+		return models.GenPluginResult(models.PluginStatusError, "logId and logTs can not be empty", nil)
+	}
+	tenantQuery := xobserveutils.BuildTenantQuery(models.GetTelemetryTenants(c))
+
+	logQuery := fmt.Sprintf("SELECT trace_id, span_id FROM %s.%s where timestamp = ? AND id = ? AND%s", xobservemodels.DefaultLogDB, xobservemodels.DefaultLogsTable, tenantQuery)
+	var traceID, spanID string
+	err := conn.QueryRow(c.Request.Context(), logQuery, logTs, logId).Scan(&traceID, &spanID)
+	if err != nil {
+		logger.Warn("Error Query log", "query", logQuery, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	if traceID == "" {
+		return models.GenPluginResult(models.PluginStatusError, "log is not associated with a trace", nil)
+	}
+
+	traceQuery := fmt.Sprintf("SELECT traceId, min(startTime), max(startTime + duration) - min(startTime), argMin(serviceName, startTime), argMin(name, startTime), count(), countIf(statusCode=2), groupUniqArray(serviceName) FROM %s.%s WHERE traceId = ? AND%s GROUP BY traceId", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, tenantQuery)
+	rows, err := conn.Query(c.Request.Context(), traceQuery, traceID)
+	if err != nil {
+		logger.Warn("Error Query log trace", "query", traceQuery, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	var trace *xobservemodels.TraceSummary
+	for rows.Next() {
+		trace = &xobservemodels.TraceSummary{}
+		var traceStart, traceDuration uint64
+		err := rows.Scan(&trace.TraceId, &traceStart, &traceDuration, &trace.ServiceName, &trace.OperationName, &trace.NumSpans, &trace.NumErrors, &trace.Services)
+		if err != nil {
+			logger.Warn("Error scan trace summary", "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		trace.StartTime = traceStart / 1e3
+		trace.Duration = traceDuration / 1e3
+	}
+
+	var span *xobservemodels.TraceSpan
+	if trace != nil && spanID != "" {
+		spanQuery := fmt.Sprintf("SELECT traceId, spanId, parentId, name, duration, startTime, serviceName, kind, statusCode, hasError FROM %s.%s WHERE traceId = ? AND spanId = ? AND%s LIMIT 1", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, tenantQuery)
+		rows, err := conn.Query(c.Request.Context(), spanQuery, traceID, spanID)
+		if err != nil {
+			logger.Warn("Error Query log span", "query", spanQuery, "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			span = &xobservemodels.TraceSpan{}
+			err := rows.Scan(&span.TraceId, &span.SpanId, &span.ParentId, &span.Name, &span.Duration, &span.StartTime, &span.ServiceName, &span.Kind, &span.StatusCode, &span.HasError)
+			if err != nil {
+				logger.Warn("Error scan log span", "error", err)
+				return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+			}
+		}
+	}
+
+	// trace and span are nil when the trace is sampled out
+	return models.GenPluginResult(models.PluginStatusSuccess, "", map[string]interface{}{
+		"traceId": traceID,
+		"spanId":  spanID,
+		"trace":   trace,
+		"span":    span,
+	})
+}
+
+// queryTraceTimeRange returns the start and end time of a trace in ns, both are 0 when trace is not found
+func queryTraceTimeRange(ctx context.Context, conn ch.Conn, tenantQuery string, traceID string) (uint64, uint64, error) {
+	query := fmt.Sprintf("SELECT min(startTime), max(startTime + duration) FROM %s.%s WHERE traceId = ? AND%s", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, tenantQuery)
+	var start, end uint64
+	err := conn.QueryRow(ctx, query, traceID).Scan(&start, &end)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start, end, nil
+}
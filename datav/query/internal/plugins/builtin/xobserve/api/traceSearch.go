@@ -0,0 +1,317 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+const maxTraceSearchLimit = 500
+
+// string columns in trace index which can be used in span conditions
+var traceSpanColumns = map[string]bool{
+	"serviceName": true, "name": true, "spanId": true, "parentId": true,
+	"httpMethod": true, "httpUrl": true, "httpCode": true, "httpRoute": true, "httpHost": true, "responseStatusCode": true,
+	"externalHttpMethod": true, "externalHttpUrl": true, "component": true, "peerService": true,
+	"dbSystem": true, "dbName": true, "dbOperation": true, "msgSystem": true, "msgOperation": true,
+	"rpcSystem": true, "rpcService": true, "rpcMethod": true, "gRPCMethod": true, "gRPCCode": true,
+}
+
+var traceSpanKinds = map[string]int{
+	"internal": 1,
+	"server":   2,
+	"client":   3,
+	"producer": 4,
+	"consumer": 5,
+}
+
+var traceSpanStatus = map[string]int{
+	"unset": 0,
+	"ok":    1,
+	"error": 2,
+}
+
+// SearchTraces searches traces across all services, the search request is passed in params,
+// see xobservemodels.TraceSearch for details
+func SearchTraces(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+
+	search := &xobservemodels.TraceSearch{}
+	b, _ := json.Marshal(params)
+	err := json.Unmarshal(b, search)
+	if err != nil {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("decode search params error: %s", err.Error()), nil)
+	}
+
+	if search.Limit <= 0 {
+		search.Limit = 20
+	}
+	if search.Limit > maxTraceSearchLimit {
+		search.Limit = maxTraceSearchLimit
+	}
+
+	sortBy := "traceStart"
+	if search.SortBy == "duration" {
+		sortBy = "traceDuration"
+	}
+	order := "DESC"
+	if strings.ToLower(search.Order) == "asc" {
+		order = "ASC"
+	}
+
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
+	services := xobserveutils.GetValueListFromParams(params, "service")
+	if services != nil {
+		domainQuery += fmt.Sprintf(" AND serviceName in ('%s')", strings.Join(services, "','"))
+	}
+
+	// traces having at least one span matching each span filter
+	args := make([]interface{}, 0)
+	spanQueries := make([]string, 0, len(search.Spans))
+	for _, filter := range search.Spans {
+		query, filterArgs, err := buildTraceSpanFilterQuery(filter)
+		if err != nil {
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		spanQueries = append(spanQueries, query)
+		args = append(args, filterArgs...)
+	}
+
+	matchQuery := fmt.Sprintf("SELECT traceId FROM %s.%s WHERE startTime >= %d AND startTime <= %d AND %s", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, start*1e9, end*1e9, domainQuery)
+	if len(spanQueries) > 0 {
+		having := make([]string, 0, len(spanQueries))
+		for _, q := range spanQueries {
+			having = append(having, fmt.Sprintf("countIf(%s) > 0", q))
+		}
+		// conditions are used in both WHERE and HAVING, so args are needed twice
+		matchQuery += fmt.Sprintf(" AND (%s) GROUP BY traceId HAVING %s", strings.Join(spanQueries, " OR "), strings.Join(having, " AND "))
+		args = append(args, args...)
+	} else {
+		matchQuery += " GROUP BY traceId"
+	}
+
+	havingQuery := make([]string, 0)
+	if search.MinDuration > 0 {
+		havingQuery = append(havingQuery, fmt.Sprintf("traceDuration >= %d", int64(search.MinDuration*1e6)))
+	}
+	if search.MaxDuration > 0 {
+		havingQuery = append(havingQuery, fmt.Sprintf("traceDuration <= %d", int64(search.MaxDuration*1e6)))
+	}
+	if search.Cursor != "" {
+		cursorValue, cursorTraceId, err := decodeTraceSearchCursor(search.Cursor)
+		if err != nil {
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		op := "<"
+		if order == "ASC" {
+			op = ">"
+		}
+		havingQuery = append(havingQuery, fmt.Sprintf("(%s, traceId) %s (%d, ?)", sortBy, op, cursorValue))
+		args = append(args, cursorTraceId)
+	}
+	having := ""
+	if len(havingQuery) > 0 {
+		having = " HAVING " + strings.Join(havingQuery, " AND ")
+	}
+
+	// spans of a trace may start before the time range, but we only care about the spans in range here
+	query := fmt.Sprintf(`SELECT traceId, min(startTime) AS traceStart, max(startTime + duration) - min(startTime) AS traceDuration, argMin(serviceName, startTime), argMin(name, startTime), count(), countIf(statusCode=2), groupUniqArray(serviceName)
+FROM %s.%s WHERE startTime >= %d AND startTime <= %d AND traceId IN (%s)
+GROUP BY traceId%s ORDER BY %s %s, traceId %s LIMIT %d`,
+		xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, start*1e9, end*1e9, matchQuery, having, sortBy, order, order, search.Limit)
+
+	rows, err := conn.Query(c.Request.Context(), query, args...)
+	if err != nil {
+		logger.Warn("Error Query search traces", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query search traces", "query", query, "args", args)
+
+	traces := make([]*xobservemodels.TraceSummary, 0)
+	var lastSortValue uint64
+	for rows.Next() {
+		trace := &xobservemodels.TraceSummary{}
+		var traceStart, traceDuration uint64
+		err := rows.Scan(&trace.TraceId, &traceStart, &traceDuration, &trace.ServiceName, &trace.OperationName, &trace.NumSpans, &trace.NumErrors, &trace.Services)
+		if err != nil {
+			logger.Warn("Error scan trace summary", "error", err)
+			continue
+		}
+		if sortBy == "traceStart" {
+			lastSortValue = traceStart
+		} else {
+			lastSortValue = traceDuration
+		}
+		trace.StartTime = traceStart / 1e3
+		trace.Duration = traceDuration / 1e3
+		traces = append(traces, trace)
+	}
+
+	nextCursor := ""
+	if len(traces) == search.Limit {
+		nextCursor = encodeTraceSearchCursor(lastSortValue, traces[len(traces)-1].TraceId)
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", map[string]interface{}{
+		"traces":     traces,
+		"nextCursor": nextCursor,
+	})
+}
+
+func buildTraceSpanFilterQuery(filter *xobservemodels.TraceSpanFilter) (string, []interface{}, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if filter.Service != "" {
+		conditions = append(conditions, "serviceName = ?")
+		args = append(args, filter.Service)
+	}
+	if filter.Operation != "" {
+		conditions = append(conditions, "name = ?")
+		args = append(args, filter.Operation)
+	}
+	if filter.Kind != "" {
+		kind, ok := traceSpanKinds[strings.ToLower(filter.Kind)]
+		if !ok {
+			return "", nil, fmt.Errorf("invalid span kind: %s", filter.Kind)
+		}
+		conditions = append(conditions, fmt.Sprintf("kind = %d", kind))
+	}
+	if filter.Status != "" {
+		status, ok := traceSpanStatus[strings.ToLower(filter.Status)]
+		if !ok {
+			return "", nil, fmt.Errorf("invalid span status: %s", filter.Status)
+		}
+		conditions = append(conditions, fmt.Sprintf("statusCode = %d", status))
+	}
+
+	for _, cond := range filter.Conditions {
+		query, condArgs, err := buildTraceSpanCondition(cond)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, query)
+		args = append(args, condArgs...)
+	}
+
+	if len(conditions) == 0 {
+		return "1 = 1", args, nil
+	}
+
+	return "(" + strings.Join(conditions, " AND ") + ")", args, nil
+}
+
+func buildTraceSpanCondition(cond *xobservemodels.TraceSpanCondition) (string, []interface{}, error) {
+	if !fieldNameRegexp.MatchString(cond.Key) {
+		return "", nil, fmt.Errorf("invalid condition key: %s", cond.Key)
+	}
+
+	var stringColumn, numberColumn, existsQuery string
+	switch {
+	case strings.HasPrefix(cond.Key, "attributes."):
+		key := cond.Key[11:]
+		stringColumn = fmt.Sprintf("attributesMap['%s']", key)
+		numberColumn = fmt.Sprintf("numberAttributesMap['%s']", key)
+		existsQuery = fmt.Sprintf("mapContains(attributesMap, '%s')", key)
+	case strings.HasPrefix(cond.Key, "resources."):
+		key := cond.Key[10:]
+		stringColumn = fmt.Sprintf("resourcesMap['%s']", key)
+		numberColumn = fmt.Sprintf("toFloat64OrZero(resourcesMap['%s'])", key)
+		existsQuery = fmt.Sprintf("mapContains(resourcesMap, '%s')", key)
+	case cond.Key == "duration":
+		// duration is in ms in UI
+		numberColumn = "duration / 1e6"
+	case traceSpanColumns[cond.Key]:
+		stringColumn = cond.Key
+		numberColumn = fmt.Sprintf("toFloat64OrZero(toString(%s))", cond.Key)
+		existsQuery = fmt.Sprintf("%s != ''", cond.Key)
+	default:
+		return "", nil, fmt.Errorf("unsupported condition key: %s", cond.Key)
+	}
+
+	switch cond.Operator {
+	case "exists":
+		if existsQuery == "" {
+			return "", nil, fmt.Errorf("operator exists is not supported for %s", cond.Key)
+		}
+		return existsQuery, nil, nil
+	case ">", ">=", "<", "<=":
+		value, err := toFloat(cond.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("value of %s must be a number", cond.Key)
+		}
+		return fmt.Sprintf("%s %s ?", numberColumn, cond.Operator), []interface{}{value}, nil
+	}
+
+	if stringColumn == "" {
+		if cond.Operator == "=" || cond.Operator == "!=" {
+			value, err := toFloat(cond.Value)
+			if err != nil {
+				return "", nil, fmt.Errorf("value of %s must be a number", cond.Key)
+			}
+			return fmt.Sprintf("%s %s ?", numberColumn, cond.Operator), []interface{}{value}, nil
+		}
+		return "", nil, fmt.Errorf("operator %s is not supported for %s", cond.Operator, cond.Key)
+	}
+
+	value := fmt.Sprint(cond.Value)
+	switch cond.Operator {
+	case "=", "!=":
+		return fmt.Sprintf("%s %s ?", stringColumn, cond.Operator), []interface{}{value}, nil
+	case "contains":
+		return fmt.Sprintf("positionCaseInsensitive(%s, ?) > 0", stringColumn), []interface{}{value}, nil
+	case "regex":
+		return fmt.Sprintf("match(%s, ?)", stringColumn), []interface{}{value}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported operator: %s", cond.Operator)
+	}
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch value := v.(type) {
+	case float64:
+		return value, nil
+	case string:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return 0, fmt.Errorf("not a number: %v", v)
+	}
+}
+
+func encodeTraceSearchCursor(sortValue uint64, traceId string) string {
+	return base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", sortValue, traceId)))
+}
+
+func decodeTraceSearchCursor(cursor string) (uint64, string, error) {
+	b, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("invalid cursor")
+	}
+
+	value, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid cursor")
+	}
+
+	return value, parts[1], nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -29,8 +30,8 @@ func GetTraces(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[s
 	// @performace: 对 traceId 做 skip index
 	traceIds := strings.TrimSpace(c.Query("traceIds"))
 
-	tenant := models.GetTenant(c)
-	domainQuery := xobserveutils.BuildBasicDomainQuery(tenant, params)
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
 
 	service0 := c.Query("service")
 	var service string
@@ -129,8 +130,18 @@ func GetTraces(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[s
 
 	if onlyChart != "true" {
 		var query string
+		var args []interface{}
 		if traceIds != "" {
-			query = fmt.Sprintf("SELECT startTime as ts,serviceName,name,traceId, duration as maxDuration FROM %s.%s WHERE traceId in ('%s') AND parentId=''", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, strings.Join(strings.Split(traceIds, ","), "','"))
+			// traces are looked up by ids across namespaces and groups, but only in the tenants of current request
+			for _, id := range strings.Split(traceIds, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					args = append(args, id)
+				}
+			}
+			if len(args) == 0 {
+				return models.GenPluginResult(models.PluginStatusError, "traceIds can not be empty", nil)
+			}
+			query = fmt.Sprintf("SELECT startTime as ts,serviceName,name,traceId, duration as maxDuration FROM %s.%s WHERE traceId in (%s) AND parentId='' AND%s", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, strings.TrimSuffix(strings.Repeat("?,", len(args)), ","), xobserveutils.BuildTenantQuery(tenants))
 		} else {
 			if service == "" {
 				return models.GenPluginResult(models.PluginStatusError, "service can not be empty", nil)
@@ -139,7 +150,7 @@ func GetTraces(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[s
 			query = fmt.Sprintf("SELECT min(startTime) as ts,serviceName,name,traceId,max(duration) as maxDuration FROM %s.%s WHERE traceId in (%s) AND serviceName='%s' %s  %s GROUP BY serviceName,name,traceId", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, query0, service, operationNameQuery, durationQuery)
 		}
 		// query traceIDs
-		rows, err := conn.Query(c.Request.Context(), query)
+		rows, err := conn.Query(c.Request.Context(), query, args...)
 		if err != nil {
 			logger.Warn("Error Query trace ids", "query", query, "error", err)
 			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
@@ -323,12 +334,31 @@ func GetTraces(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[s
 	})
 }
 
+// GetTrace returns the spans of a trace, when analyze=true, the trace is analyzed on the server side,
+// see xobserveutils.AnalyzeTrace. The trace can also be downloaded with format=otlp-json or format=jaeger-json
 func GetTrace(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
 	traceID := strings.TrimSpace(c.Query("traceId"))
 
-	query := fmt.Sprintf("SELECT startTime, traceId, model FROM %s.%s WHERE traceId='%s'", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceSpansTable, traceID)
+	// spans are not stored with tenant, so the trace must be found in the index of current tenants first
+	exist, err := traceExists(c.Request.Context(), conn, xobserveutils.BuildTenantQuery(models.GetTelemetryTenants(c)), traceID)
+	if err != nil {
+		logger.Warn("Error Query trace index", "traceId", traceID, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	if !exist {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("trace %s not found", traceID), nil)
+	}
+
+	if c.Query("analyze") == "true" {
+		return getAnalyzedTrace(c, conn, traceID)
+	}
+	if c.Query("format") != "" {
+		return exportTrace(c, conn, traceID, c.Query("format"))
+	}
+
+	query := fmt.Sprintf("SELECT startTime, traceId, model FROM %s.%s WHERE traceId=?", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceSpansTable)
 	// query traceIDs
-	rows, err := conn.Query(c.Request.Context(), query)
+	rows, err := conn.Query(c.Request.Context(), query, traceID)
 	if err != nil {
 		logger.Warn("Error Query trace ids", "query", query, "error", err)
 		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
@@ -346,6 +376,77 @@ func GetTrace(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[st
 	return models.GenPluginResult(models.PluginStatusSuccess, "", res)
 }
 
+func getAnalyzedTrace(c *gin.Context, conn ch.Conn, traceID string) models.PluginResult {
+	spans, err := queryTraceSpans(c.Request.Context(), conn, traceID)
+	if err != nil {
+		logger.Warn("Error Query trace spans", "traceId", traceID, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", xobserveutils.AnalyzeTrace(traceID, spans))
+}
+
+func exportTrace(c *gin.Context, conn ch.Conn, traceID string, format string) models.PluginResult {
+	spans, err := queryTraceSpans(c.Request.Context(), conn, traceID)
+	if err != nil {
+		logger.Warn("Error Query trace spans", "traceId", traceID, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	if len(spans) == 0 {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("trace %s not found", traceID), nil)
+	}
+
+	switch format {
+	case xobservemodels.TraceFormatOTLPJSON:
+		return models.GenPluginResult(models.PluginStatusSuccess, "", xobserveutils.TraceToOTLP(spans))
+	case xobservemodels.TraceFormatJaegerJSON:
+		return models.GenPluginResult(models.PluginStatusSuccess, "", xobserveutils.TraceToJaeger(traceID, spans))
+	default:
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("unsupported trace format: %s", format), nil)
+	}
+}
+
+// traceExists reports whether the trace is in trace index and belongs to the tenants of tenantQuery
+func traceExists(ctx context.Context, conn ch.Conn, tenantQuery string, traceID string) (bool, error) {
+	query := fmt.Sprintf("SELECT count() FROM %s.%s WHERE traceId = ? AND%s", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceIndexTable, tenantQuery)
+	var count uint64
+	err := conn.QueryRow(ctx, query, traceID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// queryTraceSpans returns the spans of a trace, the tenant of the trace must be checked with traceExists first
+func queryTraceSpans(ctx context.Context, conn ch.Conn, traceID string) ([]*xobservemodels.TraceSpan, error) {
+	query := fmt.Sprintf("SELECT model FROM %s.%s WHERE traceId=?", xobservemodels.DefaultTraceDB, xobservemodels.DefaultTraceSpansTable)
+	rows, err := conn.Query(ctx, query, traceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spans := make([]*xobservemodels.TraceSpan, 0)
+	for rows.Next() {
+		var model string
+		err := rows.Scan(&model)
+		if err != nil {
+			return nil, err
+		}
+
+		span := &xobservemodels.TraceSpan{}
+		err = json.Unmarshal([]byte(model), span)
+		if err != nil {
+			logger.Warn("Error decode span model", "traceId", traceID, "error", err)
+			continue
+		}
+		spans = append(spans, span)
+	}
+
+	return spans, nil
+}
+
 type TagKey struct {
 	Name     string `json:"name"`
 	Type     string `json:"type"`     // "attributes" or "resources"
@@ -354,8 +455,8 @@ type TagKey struct {
 }
 
 func GetTraceTagKeys(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
-	tenant := models.GetTenant(c)
-	domainQuery := xobserveutils.BuildBasicDomainQuery(tenant, params)
+	tenants := models.GetTelemetryTenants(c)
+	domainQuery := xobserveutils.BuildBasicDomainQuery(tenants, params)
 
 	service := c.Query("service")
 	if service == "" {
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	xobserveutils "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/utils"
+	pluginUtils "github.com/xObserve/xObserve/query/internal/plugins/utils"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+const (
+	defaultUsageStep = 3600
+	// usage collector reports every hour, the reports before start are used as the baseline of the accumulated values
+	usageBaselineWindow = 2 * 3600
+
+	defaultUsageBreakdownLimit = 10
+)
+
+// GetUsage returns the ingestion volume of logs, traces and metrics per tenant over time,
+// which is reported by the usage collectors of otel-collector exporters.
+//
+// params:
+//   - signals: e.g logs|traces, default to all signals
+//   - limit: only the top N tenants by ingested size are returned, default to all tenants
+//
+// The result is {timeseries, total}, count is the number of log records, spans or metric samples, size is in bytes
+func GetUsage(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+	step, _ := strconv.ParseInt(c.Query("step"), 10, 64)
+	if step <= 0 {
+		step = defaultUsageStep
+	}
+
+	signals := xobserveutils.GetValueListFromParams(params, "signals")
+	if signals == nil {
+		signals = []string{xobservemodels.UsageSignalLogs, xobservemodels.UsageSignalTraces, xobservemodels.UsageSignalMetrics}
+	}
+
+	tenantQuery := xobserveutils.BuildTenantQuery(models.GetTelemetryTenants(c))
+
+	pointsMap := make(map[string]*xobservemodels.UsagePoint)
+	totalsMap := make(map[string]*xobservemodels.UsageTotal)
+	for _, signal := range signals {
+		db, ok := xobservemodels.UsageSignalDBs[signal]
+		if !ok {
+			return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("unsupported signal: %s", signal), nil)
+		}
+
+		query := fmt.Sprintf("SELECT tenant, exporter_id, timestamp, data FROM %s.%s WHERE timestamp >= toDateTime(?) AND timestamp <= toDateTime(?) AND%s ORDER BY exporter_id, tenant, timestamp", db, xobservemodels.DefaultUsageTable, tenantQuery)
+		rows, err := conn.Query(c.Request.Context(), query, start-usageBaselineWindow, end)
+		if err != nil {
+			logger.Warn("Error Query usage", "query", query, "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+
+		logger.Info("Query usage", "query", query)
+
+		// values are accumulated since the exporter started, and each exporter has its own id
+		var prevKey string
+		var prev *xobservemodels.Usage
+		for rows.Next() {
+			var tenant, exporterID, data string
+			var ts time.Time
+			err := rows.Scan(&tenant, &exporterID, &ts, &data)
+			if err != nil {
+				logger.Warn("Error scan usage", "error", err)
+				continue
+			}
+
+			usage, err := xobserveutils.DecryptUsage(exporterID, data)
+			if err != nil {
+				logger.Warn("Error decrypt usage", "exporterId", exporterID, "error", err)
+				continue
+			}
+
+			count, size := usage.Count, usage.Size
+			key := exporterID + "|" + tenant
+			if key == prevKey && prev != nil && usage.Count >= prev.Count && usage.Size >= prev.Size {
+				count -= prev.Count
+				size -= prev.Size
+			}
+			prevKey, prev = key, usage
+
+			if ts.Unix() < start {
+				continue
+			}
+
+			bucket := ts.Unix() / step * step
+			pointKey := fmt.Sprintf("%d|%s|%s", bucket, tenant, signal)
+			point, ok := pointsMap[pointKey]
+			if !ok {
+				point = &xobservemodels.UsagePoint{Timestamp: bucket, Tenant: tenant, Signal: signal}
+				pointsMap[pointKey] = point
+			}
+			point.Count += count
+			point.Size += size
+
+			totalKey := tenant + "|" + signal
+			total, ok := totalsMap[totalKey]
+			if !ok {
+				total = &xobservemodels.UsageTotal{Tenant: tenant, Signal: signal}
+				totalsMap[totalKey] = total
+			}
+			total.Count += count
+			total.Size += size
+		}
+		rows.Close()
+	}
+
+	tenants := make(map[string]int64)
+	for _, total := range totalsMap {
+		tenants[total.Tenant] += total.Size
+	}
+
+	limit, _ := params["limit"].(float64)
+	if limit > 0 && int(limit) < len(tenants) {
+		sortedTenants := make([]string, 0, len(tenants))
+		for tenant := range tenants {
+			sortedTenants = append(sortedTenants, tenant)
+		}
+		sort.Slice(sortedTenants, func(i, j int) bool {
+			return tenants[sortedTenants[i]] > tenants[sortedTenants[j]]
+		})
+
+		for _, tenant := range sortedTenants[int(limit):] {
+			delete(tenants, tenant)
+		}
+	}
+
+	timeseries := make([]*xobservemodels.UsagePoint, 0, len(pointsMap))
+	for _, point := range pointsMap {
+		if _, ok := tenants[point.Tenant]; ok {
+			timeseries = append(timeseries, point)
+		}
+	}
+	sort.Slice(timeseries, func(i, j int) bool {
+		if timeseries[i].Timestamp != timeseries[j].Timestamp {
+			return timeseries[i].Timestamp < timeseries[j].Timestamp
+		}
+		if timeseries[i].Tenant != timeseries[j].Tenant {
+			return timeseries[i].Tenant < timeseries[j].Tenant
+		}
+		return timeseries[i].Signal < timeseries[j].Signal
+	})
+
+	totals := make([]*xobservemodels.UsageTotal, 0, len(totalsMap))
+	for _, total := range totalsMap {
+		if _, ok := tenants[total.Tenant]; ok {
+			totals = append(totals, total)
+		}
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Size > totals[j].Size
+	})
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", map[string]interface{}{
+		"timeseries": timeseries,
+		"total":      totals,
+	})
+}
+
+type usageBreakdownSource struct {
+	db        string
+	table     string
+	timeExpr  string // in seconds
+	countExpr string
+	sizeExpr  string // empty when size is unknown
+	dims      map[string]string
+}
+
+var usageBreakdownSources = map[string]*usageBreakdownSource{
+	// spans are counted from trace_index, usage_explorer is bucketed per hour, which is too coarse for the steps of chargeback
+	xobservemodels.UsageSignalTraces: {
+		db:        xobservemodels.DefaultTraceDB,
+		table:     xobservemodels.DefaultTraceIndexTable,
+		timeExpr:  "intDiv(startTime, 1000000000)",
+		countExpr: "count()",
+		dims:      map[string]string{"tenant": "tenant", "namespace": "namespace", "group": "group", "service": "serviceName"},
+	},
+	xobservemodels.UsageSignalLogs: {
+		db:        xobservemodels.DefaultLogDB,
+		table:     xobservemodels.DefaultLogsTable,
+		timeExpr:  "intDiv(timestamp, 1000000000)",
+		countExpr: "count()",
+		sizeExpr:  "sum(length(body))",
+		dims:      map[string]string{"tenant": "tenant", "namespace": "namespace", "group": "group", "service": "service"},
+	},
+}
+
+// GetUsageBreakdown returns the top N tenants, namespaces, groups or services by ingested volume of a signal,
+// so the noisy services can be found out.
+//
+// params:
+//   - signal: traces or logs, default to traces. The size of traces is not available
+//   - by: dimensions to break down, e.g namespace|service, default to service
+//   - limit: default to 10
+//   - namespace, group, service: filters, all namespaces and groups are included by default
+//   - timeseries: when true, the volume of the top N items in each step is also returned
+//
+// The result is {top, timeseries}
+func GetUsageBreakdown(c *gin.Context, ds *models.Datasource, conn ch.Conn, params map[string]interface{}) models.PluginResult {
+	start, _ := strconv.ParseInt(c.Query("start"), 10, 64)
+	end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+	if start == 0 || end == 0 {
+		return models.GenPluginResult(models.PluginStatusError, "start and end is required", nil)
+	}
+	step, _ := strconv.ParseInt(c.Query("step"), 10, 64)
+	if step <= 0 {
+		step = defaultUsageStep
+	}
+
+	signal, _ := params["signal"].(string)
+	if signal == "" {
+		signal = xobservemodels.UsageSignalTraces
+	}
+	source, ok := usageBreakdownSources[signal]
+	if !ok {
+		return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("unsupported signal: %s", signal), nil)
+	}
+
+	by := xobserveutils.GetValueListFromParams(params, "by")
+	if by == nil {
+		by = []string{"service"}
+	}
+
+	dims := make([]string, 0, len(by))
+	selectDims := ""
+	for _, b := range by {
+		dim, ok := source.dims[b]
+		if !ok {
+			return models.GenPluginResult(models.PluginStatusError, fmt.Sprintf("unsupported breakdown: %s", b), nil)
+		}
+		dims = append(dims, dim)
+		selectDims += fmt.Sprintf("%s AS %s, ", dim, b)
+	}
+	dimsExpr := strings.Join(dims, ", ")
+
+	limit, _ := params["limit"].(float64)
+	if limit <= 0 {
+		limit = defaultUsageBreakdownLimit
+	}
+
+	where := fmt.Sprintf("%s >= ? AND %s <= ? AND%s", source.timeExpr, source.timeExpr, xobserveutils.BuildTenantQuery(models.GetTelemetryTenants(c)))
+	for _, filter := range []string{"namespace", "group", "service"} {
+		values := xobserveutils.GetValueListFromParams(params, filter)
+		if values != nil {
+			where += fmt.Sprintf(" AND %s in ('%s')", source.dims[filter], strings.Join(values, "','"))
+		}
+	}
+
+	selectValues := source.countExpr + " AS count"
+	orderBy := source.countExpr
+	if source.sizeExpr != "" {
+		selectValues += ", " + source.sizeExpr + " AS size"
+		orderBy = source.sizeExpr
+	}
+
+	query := fmt.Sprintf("SELECT %s%s FROM %s.%s WHERE %s GROUP BY %s ORDER BY %s DESC LIMIT %d",
+		selectDims, selectValues, source.db, source.table, where, dimsExpr, orderBy, int(limit))
+	rows, err := conn.Query(c.Request.Context(), query, start, end)
+	if err != nil {
+		logger.Warn("Error Query usage breakdown", "query", query, "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+	defer rows.Close()
+
+	logger.Info("Query usage breakdown", "query", query)
+
+	top, err := pluginUtils.ConvertDbRowsToPluginData(rows)
+	if err != nil {
+		logger.Warn("Error conver rows to data", "error", err)
+		return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+	}
+
+	result := map[string]interface{}{
+		"top": top,
+	}
+
+	withTimeseries, _ := params["timeseries"].(bool)
+	if withTimeseries {
+		query := fmt.Sprintf("SELECT intDiv(%s, %d) * %d AS ts_bucket, %s%s FROM %s.%s WHERE %s AND (%s) IN (SELECT %s FROM %s.%s WHERE %s GROUP BY %s ORDER BY %s DESC LIMIT %d) GROUP BY ts_bucket, %s ORDER BY ts_bucket",
+			source.timeExpr, step, step, selectDims, selectValues, source.db, source.table, where,
+			dimsExpr, dimsExpr, source.db, source.table, where, dimsExpr, orderBy, int(limit), dimsExpr)
+		rows, err := conn.Query(c.Request.Context(), query, start, end, start, end)
+		if err != nil {
+			logger.Warn("Error Query usage breakdown timeseries", "query", query, "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		defer rows.Close()
+
+		logger.Info("Query usage breakdown timeseries", "query", query)
+
+		timeseries, err := pluginUtils.ConvertDbRowsToPluginData(rows)
+		if err != nil {
+			logger.Warn("Error conver rows to data", "error", err)
+			return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+		}
+		result["timeseries"] = timeseries
+	}
+
+	return models.GenPluginResult(models.PluginStatusSuccess, "", result)
+}
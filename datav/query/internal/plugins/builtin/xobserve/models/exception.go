@@ -0,0 +1,42 @@
+package models
+
+const (
+	// max number of sparkline buckets of an exception group, step is enlarged when the range is too long
+	ExceptionMaxBuckets = 1000
+)
+
+// ExceptionGroup is the exceptions of a service with the same type and message fingerprint
+type ExceptionGroup struct {
+	ServiceName   string   `json:"serviceName"`
+	ExceptionType string   `json:"exceptionType"`
+	Fingerprint   string   `json:"fingerprint"`
+	Message       string   `json:"message"`
+	Count         uint64   `json:"count"`
+	FirstSeen     int64    `json:"firstSeen"`
+	LastSeen      int64    `json:"lastSeen"`
+	Sparkline     []uint64 `json:"sparkline"`
+}
+
+type ExceptionSample struct {
+	Timestamp int64  `json:"timestamp"`
+	TraceId   string `json:"traceId"`
+	SpanId    string `json:"spanId"`
+	Message   string `json:"message"`
+	Escaped   bool   `json:"escaped"`
+}
+
+type ExceptionOperation struct {
+	Name  string `json:"name"`
+	Count uint64 `json:"count"`
+}
+
+// ExceptionDetail is the latest stacktrace, sample traces and affected operations of an exception group
+type ExceptionDetail struct {
+	ServiceName   string                `json:"serviceName"`
+	ExceptionType string                `json:"exceptionType"`
+	Fingerprint   string                `json:"fingerprint"`
+	Message       string                `json:"message"`
+	Stacktrace    string                `json:"stacktrace"`
+	Samples       []*ExceptionSample    `json:"samples"`
+	Operations    []*ExceptionOperation `json:"operations"`
+}
@@ -0,0 +1,34 @@
+package models
+
+const (
+	DefaultLogPatternSampleSize    = 10000
+	DefaultLogPatternMaxPatterns   = 500
+	DefaultLogPatternSimilarity    = 0.5
+	DefaultLogPatternLimit         = 50
+	DefaultLogPatternSamplesPerOne = 5
+	// max tokens of a log body used in clustering, the rest are ignored
+	LogPatternMaxTokens = 100
+	LogPatternWildcard  = "<*>"
+	// max number of sparkline buckets of a pattern, step is enlarged when the range is too long
+	LogPatternMaxBuckets = 1000
+	// default sparkline step in seconds, the same as other logs APIs
+	LogPatternDefaultStep = 60
+)
+
+// Log pattern, clustered from log bodies
+type LogPattern struct {
+	Pattern   string   `json:"pattern"`
+	Count     uint64   `json:"count"`
+	FirstSeen uint64   `json:"firstSeen"`
+	LastSeen  uint64   `json:"lastSeen"`
+	Sparkline []uint64 `json:"sparkline"`
+	// log id and timestamp are both needed for querying a single log
+	Samples []*LogPatternSample `json:"samples"`
+
+	Tokens []string `json:"-"`
+}
+
+type LogPatternSample struct {
+	Id        string `json:"id"`
+	Timestamp uint64 `json:"timestamp"`
+}
@@ -0,0 +1,48 @@
+package models
+
+const (
+	DefaultMetricsTimeSeriesTable string = "distributed_time_series"
+	DefaultMetricsSamplesTable    string = "distributed_samples"
+)
+
+// max number of step buckets of a metrics query, step is enlarged when the range is too long
+const MetricsMaxBuckets = 1000
+
+// metrics produced by xobservespanmetrics processor in otel-collector
+const (
+	SpanMetricsCalls         = "xobserve_calls_total"
+	SpanMetricsLatencyBucket = "xobserve_latency_bucket"
+
+	SpanMetricsDBLatencyCount       = "xobserve_db_latency_count"
+	SpanMetricsDBLatencySum         = "xobserve_db_latency_sum"
+	SpanMetricsExternalLatencyCount = "xobserve_external_call_latency_count"
+	SpanMetricsExternalLatencySum   = "xobserve_external_call_latency_sum"
+)
+
+// labels of span metrics, label names are sanitized by metrics exporter, e.g service.name -> service_name
+const (
+	MetricsTenantLabel    = "xobserve_tenant"
+	MetricsNamespaceLabel = "xobserve_namespace"
+	MetricsGroupLabel     = "xobserve_group"
+	MetricsServiceLabel   = "service_name"
+	MetricsOperationLabel = "operation"
+	MetricsSpanKindLabel  = "span_kind"
+	MetricsStatusLabel    = "status_code"
+	MetricsBucketLabel    = "le"
+	MetricsDBSystemLabel  = "db_system"
+	MetricsDBNameLabel    = "db_name"
+	MetricsAddressLabel   = "address"
+)
+
+const (
+	SpanKindServer   = "SPAN_KIND_SERVER"
+	SpanKindConsumer = "SPAN_KIND_CONSUMER"
+	StatusCodeError  = "STATUS_CODE_ERROR"
+	TemporalityDelta = "Delta"
+)
+
+// Increases of a group of metric series in every step, series in the same group have the same values of the group labels
+type MetricIncreases struct {
+	Labels []string
+	Values []float64
+}
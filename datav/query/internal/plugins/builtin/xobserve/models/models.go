@@ -5,6 +5,7 @@ const (
 	DefaultTraceErrorTable         string = "distributed_trace_error_index"
 	DefaultDurationTable           string = "distributed_durationSort"
 	DefaultUsageExplorerTable      string = "distributed_usage_explorer"
+	DefaultUsageTable              string = "distributed_usage"
 	DefaultTraceSpansTable         string = "distributed_trace_spans"
 	DefaultDependencyGraphTable    string = "distributed_dependency_graph_minutes"
 	DefaultTopLevelOperationsTable string = "distributed_top_level_operations"
@@ -32,6 +33,9 @@ const (
 		"CAST((attributes_string_key, attributes_string_value), 'Map(String, String)') as  attributes_string,CAST((attributes_int64_key, attributes_int64_value), 'Map(String, Int64)') as  attributes_int64, CAST((attributes_float64_key, attributes_float64_value), 'Map(String, Float64)') as  attributes_float64, CAST((resources_string_key, resources_string_value), 'Map(String, String)') as resources_string "
 )
 
+// masks the numbers and hex ids in exception message, so that exceptions with the same message template are grouped together
+const ExceptionFingerprintSQL = `replaceRegexpAll(exceptionMessage, '[0-9a-fA-F]{8,}|\\d+', '<*>')`
+
 type SearchToken struct {
 	Key      string
 	Value    string
@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // Trace list item
 type TraceIndex struct {
 	TraceId        string               `json:"traceID"`
@@ -17,3 +19,111 @@ type TraceServiceIndex struct {
 	NumSpans uint64 `json:"numSpans"`
 	Errors   uint64 `json:"errors"`
 }
+
+// Trace search request, trace matches when all of its filters are matched and
+// for every span filter, there is at least one span in the trace matching it
+type TraceSearch struct {
+	Spans       []*TraceSpanFilter `json:"spans"`
+	MinDuration float64            `json:"minDuration"` // ms, duration of the whole trace
+	MaxDuration float64            `json:"maxDuration"` // ms
+	SortBy      string             `json:"sortBy"`      // startTime or duration
+	Order       string             `json:"order"`       // desc or asc
+	Limit       int                `json:"limit"`
+	Cursor      string             `json:"cursor"`
+}
+
+// Conditions on a single span
+type TraceSpanFilter struct {
+	Service    string                `json:"service"`
+	Operation  string                `json:"operation"`
+	Kind       string                `json:"kind"`   // server, client, producer, consumer or internal
+	Status     string                `json:"status"` // unset, ok or error
+	Conditions []*TraceSpanCondition `json:"conditions"`
+}
+
+type TraceSpanCondition struct {
+	Key      string      `json:"key"`
+	Operator string      `json:"op"` // =, !=, contains, regex, >, >=, <, <=, exists
+	Value    interface{} `json:"value"`
+}
+
+// Trace search result item
+type TraceSummary struct {
+	TraceId       string   `json:"traceID"`
+	ServiceName   string   `json:"serviceName"`
+	OperationName string   `json:"name"`
+	Duration      uint64   `json:"duration"`
+	StartTime     uint64   `json:"startTime"`
+	NumSpans      uint64   `json:"numSpans"`
+	NumErrors     uint64   `json:"numErrors"`
+	Services      []string `json:"services"`
+}
+
+// Span stored in trace spans table, the same as TraceModel in clickhouse traces exporter
+type TraceSpan struct {
+	TraceId             string             `json:"traceId,omitempty"`
+	SpanId              string             `json:"spanId,omitempty"`
+	ParentId            string             `json:"parentId,omitempty"`
+	Name                string             `json:"name,omitempty"`
+	Duration            uint64             `json:"duration,omitempty"`
+	StartTime           uint64             `json:"startTime,omitempty"`
+	ServiceName         string             `json:"serviceName,omitempty"`
+	Kind                int8               `json:"kind"`
+	Links               json.RawMessage    `json:"links,omitempty"`
+	StatusCode          int16              `json:"statusCode,omitempty"`
+	ResourcesMap        map[string]string  `json:"resourcesMap,omitempty"`
+	AttributesMap       map[string]string  `json:"attributesMap,omitempty"`
+	StringAttributesMap map[string]string  `json:"stringAttributesMap,omitempty"`
+	NumberAttributesMap map[string]float64 `json:"numberAttributesMap,omitempty"`
+	BoolAttributesMap   map[string]bool    `json:"boolAttributesMap,omitempty"`
+	Events              []string           `json:"events,omitempty"`
+	HasError            bool               `json:"hasError,omitempty"`
+}
+
+// Self time of a span is its duration excluding the time covered by its children
+type SpanNode struct {
+	Span     *TraceSpan
+	Parent   *SpanNode
+	Children []*SpanNode
+	SelfTime uint64
+}
+
+// Analyzed trace returned by getTrace when analyze is enabled, times are in ns
+type TraceAnalysis struct {
+	TraceId   string `json:"traceId"`
+	StartTime uint64 `json:"startTime"`
+	Duration  uint64 `json:"duration"`
+	NumSpans  int    `json:"numSpans"`
+	// spans in depth-first order, children are sorted by start time, orphans follow the spans of the main tree
+	Spans        []*AnalyzedSpan         `json:"spans"`
+	CriticalPath []*CriticalPathSegment  `json:"criticalPath"`
+	Services     []*ServiceTimeBreakdown `json:"services"`
+	Orphans      []string                `json:"orphans"`
+}
+
+type AnalyzedSpan struct {
+	*TraceSpan
+	Depth    int    `json:"depth"`
+	SelfTime uint64 `json:"selfTime"`
+	// how much the start time of the span has been shifted to fix clock skew between services
+	ClockSkew      int64 `json:"clockSkew,omitempty"`
+	OnCriticalPath bool  `json:"onCriticalPath,omitempty"`
+	Orphan         bool  `json:"orphan,omitempty"`
+}
+
+type CriticalPathSegment struct {
+	SpanId      string `json:"spanId"`
+	ServiceName string `json:"serviceName"`
+	Name        string `json:"name"`
+	Start       uint64 `json:"start"`
+	End         uint64 `json:"end"`
+}
+
+type ServiceTimeBreakdown struct {
+	ServiceName      string  `json:"serviceName"`
+	Spans            int     `json:"spans"`
+	Errors           int     `json:"errors"`
+	SelfTime         uint64  `json:"selfTime"`
+	CriticalPathTime uint64  `json:"criticalPathTime"`
+	Percentage       float64 `json:"percentage"` // self time / sum of self time of all services
+}
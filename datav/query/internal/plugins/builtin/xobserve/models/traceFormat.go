@@ -0,0 +1,146 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	TraceFormatOTLPJSON   = "otlp-json"
+	TraceFormatJaegerJSON = "jaeger-json"
+	// max size of a trace file uploaded to parseTrace
+	MaxTraceFileSize = 32 * 1024 * 1024
+)
+
+// Span event stored in TraceSpan.Events as json string, the same as Event in clickhouse traces exporter
+type TraceSpanEvent struct {
+	Name         string            `json:"name,omitempty"`
+	TimeUnixNano uint64            `json:"timeUnixNano,omitempty"`
+	AttributeMap map[string]string `json:"attributeMap,omitempty"`
+	IsError      bool              `json:"isError,omitempty"`
+}
+
+// OTLP JSON encoding of traces, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type OTLPTraceData struct {
+	ResourceSpans []*OTLPResourceSpans `json:"resourceSpans"`
+}
+
+type OTLPResourceSpans struct {
+	Resource   OTLPResource      `json:"resource"`
+	ScopeSpans []*OTLPScopeSpans `json:"scopeSpans"`
+}
+
+type OTLPResource struct {
+	Attributes []*OTLPKeyValue `json:"attributes"`
+}
+
+type OTLPScopeSpans struct {
+	Scope OTLPScope   `json:"scope"`
+	Spans []*OTLPSpan `json:"spans"`
+}
+
+type OTLPScope struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type OTLPSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int8            `json:"kind"`
+	StartTimeUnixNano OTLPInt         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   OTLPInt         `json:"endTimeUnixNano"`
+	Attributes        []*OTLPKeyValue `json:"attributes,omitempty"`
+	Events            []*OTLPEvent    `json:"events,omitempty"`
+	Status            OTLPStatus      `json:"status"`
+}
+
+type OTLPEvent struct {
+	TimeUnixNano OTLPInt         `json:"timeUnixNano"`
+	Name         string          `json:"name"`
+	Attributes   []*OTLPKeyValue `json:"attributes,omitempty"`
+}
+
+type OTLPStatus struct {
+	Code    int16  `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type OTLPKeyValue struct {
+	Key   string       `json:"key"`
+	Value OTLPAnyValue `json:"value"`
+}
+
+// array and kvlist values are kept as raw json
+type OTLPAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *OTLPInt        `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  json.RawMessage `json:"arrayValue,omitempty"`
+	KvlistValue json.RawMessage `json:"kvlistValue,omitempty"`
+}
+
+// 64 bit integers are encoded as strings in OTLP JSON, but numbers are also accepted when decoding
+type OTLPInt int64
+
+func (i OTLPInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(i), 10))
+}
+
+func (i *OTLPInt) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = OTLPInt(v)
+	return nil
+}
+
+// Jaeger JSON is the format used by jaeger query api and jaeger ui for trace download
+type JaegerTraceData struct {
+	Data []*JaegerTrace `json:"data"`
+}
+
+type JaegerTrace struct {
+	TraceID   string                    `json:"traceID"`
+	Spans     []*JaegerSpan             `json:"spans"`
+	Processes map[string]*JaegerProcess `json:"processes"`
+}
+
+type JaegerSpan struct {
+	TraceID       string             `json:"traceID"`
+	SpanID        string             `json:"spanID"`
+	OperationName string             `json:"operationName"`
+	References    []*JaegerReference `json:"references"`
+	StartTime     uint64             `json:"startTime"` // us
+	Duration      uint64             `json:"duration"`  // us
+	Tags          []*JaegerKeyValue  `json:"tags"`
+	Logs          []*JaegerLog       `json:"logs"`
+	ProcessID     string             `json:"processID"`
+}
+
+type JaegerReference struct {
+	RefType string `json:"refType"` // CHILD_OF or FOLLOWS_FROM
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type JaegerKeyValue struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"` // string, bool, int64, float64 or binary
+	Value interface{} `json:"value"`
+}
+
+type JaegerLog struct {
+	Timestamp uint64            `json:"timestamp"` // us
+	Fields    []*JaegerKeyValue `json:"fields"`
+}
+
+type JaegerProcess struct {
+	ServiceName string            `json:"serviceName"`
+	Tags        []*JaegerKeyValue `json:"tags"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	UsageSignalLogs    = "logs"
+	UsageSignalTraces  = "traces"
+	UsageSignalMetrics = "metrics"
+)
+
+// the database which usage collector of each signal writes to
+var UsageSignalDBs = map[string]string{
+	UsageSignalLogs:    DefaultLogDB,
+	UsageSignalTraces:  DefaultTraceDB,
+	UsageSignalMetrics: DefaultMetricsDB,
+}
+
+// usage reported by the usage collector of otel-collector exporters,
+// Count and Size are accumulated since the exporter started
+type Usage struct {
+	TimeStamp time.Time
+	Count     int64
+	Size      int64
+}
+
+type UsagePoint struct {
+	Timestamp int64  `json:"timestamp"`
+	Tenant    string `json:"tenant"`
+	Signal    string `json:"signal"`
+	Count     int64  `json:"count"`
+	Size      int64  `json:"size"`
+}
+
+type UsageTotal struct {
+	Tenant string `json:"tenant"`
+	Signal string `json:"signal"`
+	Count  int64  `json:"count"`
+	Size   int64  `json:"size"`
+}
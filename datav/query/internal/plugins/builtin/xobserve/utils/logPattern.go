@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+var (
+	uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexRegexp  = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{16,}$`)
+)
+
+// LogPatternMiner clusters log bodies into patterns in a Drain-like way:
+// logs are first grouped by token count and first token, then matched against
+// the patterns in the group by the ratio of equal tokens
+type LogPatternMiner struct {
+	start       uint64
+	step        uint64
+	buckets     int
+	maxPatterns int
+	maxSamples  int
+	similarity  float64
+
+	groups      map[string][]*xobservemodels.LogPattern
+	patterns    []*xobservemodels.LogPattern
+	unclustered uint64
+}
+
+// start, end and step are all in seconds, the same as the query params of logs APIs,
+// step is enlarged when the range needs more than LogPatternMaxBuckets buckets, so memory used by sparklines is bounded
+func NewLogPatternMiner(start, end, step int64, maxPatterns, maxSamples int, similarity float64) *LogPatternMiner {
+	if step <= 0 {
+		step = xobservemodels.LogPatternDefaultStep
+	}
+	if end < start {
+		end = start
+	}
+	if (end-start)/step+1 > xobservemodels.LogPatternMaxBuckets {
+		step = (end-start)/(xobservemodels.LogPatternMaxBuckets-1) + 1
+	}
+	buckets := int((end-start)/step) + 1
+
+	return &LogPatternMiner{
+		start:       uint64(start * 1e9),
+		step:        uint64(step * 1e9),
+		buckets:     buckets,
+		maxPatterns: maxPatterns,
+		maxSamples:  maxSamples,
+		similarity:  similarity,
+		groups:      make(map[string][]*xobservemodels.LogPattern),
+		patterns:    make([]*xobservemodels.LogPattern, 0),
+	}
+}
+
+func (m *LogPatternMiner) Add(id string, timestamp uint64, body string) {
+	tokens := TokenizeLogBody(body)
+	if len(tokens) == 0 {
+		return
+	}
+
+	groupKey := fmt.Sprintf("%d|%s", len(tokens), tokens[0])
+	if tokens[0] == xobservemodels.LogPatternWildcard {
+		groupKey = fmt.Sprintf("%d", len(tokens))
+	}
+
+	var matched *xobservemodels.LogPattern
+	maxSim := -1.0
+	for _, pattern := range m.groups[groupKey] {
+		sim := tokenSimilarity(pattern.Tokens, tokens)
+		if sim >= m.similarity && sim > maxSim {
+			maxSim = sim
+			matched = pattern
+		}
+	}
+
+	if matched == nil {
+		if len(m.patterns) >= m.maxPatterns {
+			m.unclustered++
+			return
+		}
+
+		matched = &xobservemodels.LogPattern{
+			Tokens:    tokens,
+			FirstSeen: timestamp,
+			LastSeen:  timestamp,
+			Sparkline: make([]uint64, m.buckets),
+			Samples:   make([]*xobservemodels.LogPatternSample, 0, m.maxSamples),
+		}
+		m.groups[groupKey] = append(m.groups[groupKey], matched)
+		m.patterns = append(m.patterns, matched)
+	} else {
+		for i, token := range matched.Tokens {
+			if token != tokens[i] {
+				matched.Tokens[i] = xobservemodels.LogPatternWildcard
+			}
+		}
+	}
+
+	matched.Count++
+	if timestamp < matched.FirstSeen {
+		matched.FirstSeen = timestamp
+	}
+	if timestamp > matched.LastSeen {
+		matched.LastSeen = timestamp
+	}
+
+	if timestamp >= m.start {
+		bucket := int((timestamp - m.start) / m.step)
+		if bucket < m.buckets {
+			matched.Sparkline[bucket]++
+		}
+	}
+
+	if len(matched.Samples) < m.maxSamples {
+		matched.Samples = append(matched.Samples, &xobservemodels.LogPatternSample{
+			Id:        id,
+			Timestamp: timestamp,
+		})
+	}
+}
+
+// Patterns returns the top `limit` patterns ordered by count
+func (m *LogPatternMiner) Patterns(limit int) []*xobservemodels.LogPattern {
+	sort.SliceStable(m.patterns, func(i, j int) bool {
+		return m.patterns[i].Count > m.patterns[j].Count
+	})
+
+	if limit > 0 && len(m.patterns) > limit {
+		m.patterns = m.patterns[:limit]
+	}
+
+	for _, pattern := range m.patterns {
+		pattern.Pattern = strings.Join(pattern.Tokens, " ")
+	}
+
+	return m.patterns
+}
+
+// Step returns the step of sparklines in seconds
+func (m *LogPatternMiner) Step() int64 {
+	return int64(m.step / 1e9)
+}
+
+// Unclustered returns the number of logs dropped because of reaching max patterns
+func (m *LogPatternMiner) Unclustered() uint64 {
+	return m.unclustered
+}
+
+// TokenizeLogBody splits log body by whitespaces and masks the variable parts,
+// such as numbers, ids and the values of `key=value` pairs
+func TokenizeLogBody(body string) []string {
+	fields := strings.Fields(body)
+	if len(fields) > xobservemodels.LogPatternMaxTokens {
+		fields = fields[:xobservemodels.LogPatternMaxTokens]
+	}
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		i := strings.IndexAny(field, "=:")
+		if i > 0 && i < len(field)-1 {
+			value := field[i+1:]
+			if isLogVariable(value) {
+				tokens = append(tokens, field[:i+1]+xobservemodels.LogPatternWildcard)
+				continue
+			}
+		}
+
+		if isLogVariable(field) {
+			tokens = append(tokens, xobservemodels.LogPatternWildcard)
+		} else {
+			tokens = append(tokens, field)
+		}
+	}
+
+	return tokens
+}
+
+func isLogVariable(token string) bool {
+	token = strings.Trim(token, `"'()[]{},;.`)
+	if token == "" {
+		return false
+	}
+
+	if uuidRegexp.MatchString(token) || hexRegexp.MatchString(token) {
+		return true
+	}
+
+	for _, r := range token {
+		if unicode.IsDigit(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func tokenSimilarity(pattern, tokens []string) float64 {
+	if len(pattern) != len(tokens) {
+		return 0
+	}
+
+	equal := 0
+	for i, token := range pattern {
+		if token == tokens[i] || token == xobservemodels.LogPatternWildcard {
+			equal++
+		}
+	}
+
+	return float64(equal) / float64(len(pattern))
+}
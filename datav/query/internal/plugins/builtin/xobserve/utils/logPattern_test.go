@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+func TestTokenizeLogBody(t *testing.T) {
+	cases := []struct {
+		desc   string
+		body   string
+		tokens []string
+	}{
+		{
+			desc:   "empty body",
+			body:   "  ",
+			tokens: []string{},
+		},
+		{
+			desc:   "plain words are kept",
+			body:   "connection  closed by peer",
+			tokens: []string{"connection", "closed", "by", "peer"},
+		},
+		{
+			desc:   "numbers are masked",
+			body:   "took 35ms to read 1024 bytes",
+			tokens: []string{"took", "<*>", "to", "read", "<*>", "bytes"},
+		},
+		{
+			desc:   "uuid and hex ids are masked",
+			body:   "order 123e4567-e89b-12d3-a456-426614174000 trace 0xdeadbeefdeadbeef",
+			tokens: []string{"order", "<*>", "trace", "<*>"},
+		},
+		{
+			desc:   "values of key value pairs are masked",
+			body:   "user=42 status:500 method=GET",
+			tokens: []string{"user=<*>", "status:<*>", "method=GET"},
+		},
+		{
+			desc:   "punctuations around variables are ignored",
+			body:   "retry (3) of [7],",
+			tokens: []string{"retry", "<*>", "of", "<*>"},
+		},
+	}
+
+	for _, c := range cases {
+		tokens := TokenizeLogBody(c.body)
+		if !reflect.DeepEqual(tokens, c.tokens) {
+			t.Errorf("%s: expected %q, got %q", c.desc, c.tokens, tokens)
+		}
+	}
+}
+
+func TestTokenizeLogBodyMaxTokens(t *testing.T) {
+	body := strings.Repeat("word ", xobservemodels.LogPatternMaxTokens+10)
+	tokens := TokenizeLogBody(body)
+	if len(tokens) != xobservemodels.LogPatternMaxTokens {
+		t.Errorf("expected %d tokens, got %d", xobservemodels.LogPatternMaxTokens, len(tokens))
+	}
+}
+
+func TestLogPatternMiner(t *testing.T) {
+	m := NewLogPatternMiner(0, 120, 60, 10, 2, 0.5)
+	logs := []string{
+		"user 1 login from 10.0.0.1",
+		"user 2 login from 10.0.0.2",
+		"user 3 logout",
+		"user 4 login from 10.0.0.3",
+		"cache miss key=abc",
+		"cache miss key=def",
+	}
+	for i, body := range logs {
+		m.Add(string(rune('a'+i)), uint64(i)*30*1e9, body)
+	}
+
+	patterns := m.Patterns(0)
+	expected := []struct {
+		pattern string
+		count   uint64
+	}{
+		{"user <*> login from <*>", 3},
+		{"cache miss <*>", 2},
+		{"user <*> logout", 1},
+	}
+	if len(patterns) != len(expected) {
+		t.Fatalf("expected %d patterns, got %d", len(expected), len(patterns))
+	}
+	for i, e := range expected {
+		if patterns[i].Pattern != e.pattern || patterns[i].Count != e.count {
+			t.Errorf("pattern %d: expected %q(%d), got %q(%d)", i, e.pattern, e.count, patterns[i].Pattern, patterns[i].Count)
+		}
+	}
+
+	if samples := len(patterns[0].Samples); samples != 2 {
+		t.Errorf("expected 2 samples, got %d", samples)
+	}
+	if sparkline := patterns[0].Sparkline; !reflect.DeepEqual(sparkline, []uint64{2, 1, 0}) {
+		t.Errorf("expected sparkline [2 1 0], got %v", sparkline)
+	}
+}
+
+func TestLogPatternMinerMaxPatterns(t *testing.T) {
+	m := NewLogPatternMiner(0, 60, 60, 1, 1, 0.5)
+	m.Add("a", 0, "connection refused")
+	m.Add("b", 0, "disk is full")
+	m.Add("c", 0, "connection refused")
+
+	if patterns := m.Patterns(0); len(patterns) != 1 || patterns[0].Count != 2 {
+		t.Errorf("expected 1 pattern with 2 logs, got %d patterns", len(patterns))
+	}
+	if m.Unclustered() != 1 {
+		t.Errorf("expected 1 unclustered log, got %d", m.Unclustered())
+	}
+}
+
+func TestNewLogPatternMinerStep(t *testing.T) {
+	cases := []struct {
+		desc    string
+		start   int64
+		end     int64
+		step    int64
+		expStep int64
+	}{
+		{"default step", 0, 3600, 0, xobservemodels.LogPatternDefaultStep},
+		{"given step", 0, 3600, 10, 10},
+		{"end before start", 100, 0, 10, 10},
+		{"step is enlarged for 30 days", 0, 30 * 86400, 0, 2595},
+	}
+
+	for _, c := range cases {
+		m := NewLogPatternMiner(c.start, c.end, c.step, 10, 1, 0.5)
+		if m.Step() != c.expStep {
+			t.Errorf("%s: expected step %d, got %d", c.desc, c.expStep, m.Step())
+		}
+		if m.buckets > xobservemodels.LogPatternMaxBuckets {
+			t.Errorf("%s: %d buckets exceed the max", c.desc, m.buckets)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+// LimitMetricsStep enlarges step when the range needs more than MetricsMaxBuckets buckets, callers of QueryMetricIncreases
+// should use the returned step to compute the timestamps and rates of buckets
+func LimitMetricsStep(start, end, step int64) int64 {
+	if end < start {
+		end = start
+	}
+	// buckets are aligned to the multiple of step, so the range may span one more bucket
+	if (end-start)/step+2 > xobservemodels.MetricsMaxBuckets {
+		step = (end-start)/(xobservemodels.MetricsMaxBuckets-2) + 1
+	}
+	return step
+}
+
+// QueryMetricIncreases reads a counter metric from xobserve_metrics and returns its increases in every step bucket,
+// series are summed up by the values of `groupLabels`. Both cumulative and delta temporality are supported.
+// start, end and step are in seconds, filter is a condition on the time series table
+func QueryMetricIncreases(ctx context.Context, conn ch.Conn, metricName string, filter string, groupLabels []string, start, end, step int64) (map[string]*xobservemodels.MetricIncreases, error) {
+	seriesQuery := fmt.Sprintf("SELECT fingerprint, any(temporality)%s FROM %s.%s WHERE metric_name='%s' AND %s GROUP BY fingerprint",
+		anyLabelsQuery(groupLabels), xobservemodels.DefaultMetricsDB, xobservemodels.DefaultMetricsTimeSeriesTable, metricName, filter)
+
+	rows, err := conn.Query(ctx, seriesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seriesLabels := make(map[uint64][]string)
+	deltaSeries := make(map[uint64]bool)
+	for rows.Next() {
+		var fingerprint uint64
+		var temporality string
+		labels := make([]string, len(groupLabels))
+		dest := []interface{}{&fingerprint, &temporality}
+		for i := range labels {
+			dest = append(dest, &labels[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		seriesLabels[fingerprint] = labels
+		if temporality == xobservemodels.TemporalityDelta {
+			deltaSeries[fingerprint] = true
+		}
+	}
+
+	result := make(map[string]*xobservemodels.MetricIncreases)
+	if len(seriesLabels) == 0 {
+		return result, nil
+	}
+
+	// read one more step before start, so that the increase of the first bucket can be computed for cumulative series
+	startBucket := start / step
+	buckets := int(end/step-startBucket) + 1
+	if step <= 0 || buckets <= 0 || buckets > xobservemodels.MetricsMaxBuckets {
+		return nil, fmt.Errorf("invalid step %d for range %d to %d, use LimitMetricsStep to limit the number of buckets", step, start, end)
+	}
+	samplesQuery := fmt.Sprintf("SELECT fingerprint, intDiv(timestamp_ms, %d) AS bucket, max(value), sum(value) FROM %s.%s WHERE metric_name='%s' AND timestamp_ms >= %d AND timestamp_ms <= %d AND fingerprint IN (SELECT fingerprint FROM %s.%s WHERE metric_name='%s' AND %s) GROUP BY fingerprint, bucket ORDER BY fingerprint, bucket",
+		step*1000, xobservemodels.DefaultMetricsDB, xobservemodels.DefaultMetricsSamplesTable, metricName, (startBucket-1)*step*1000, end*1000, xobservemodels.DefaultMetricsDB, xobservemodels.DefaultMetricsTimeSeriesTable, metricName, filter)
+
+	rows, err = conn.Query(ctx, samplesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lastFingerprint uint64
+	var lastValue float64
+	hasLast := false
+	for rows.Next() {
+		var fingerprint uint64
+		var bucket int64
+		var maxValue, sumValue float64
+		if err := rows.Scan(&fingerprint, &bucket, &maxValue, &sumValue); err != nil {
+			return nil, err
+		}
+
+		labels, ok := seriesLabels[fingerprint]
+		if !ok {
+			continue
+		}
+
+		if fingerprint != lastFingerprint {
+			hasLast = false
+			lastFingerprint = fingerprint
+		}
+
+		var increase float64
+		if deltaSeries[fingerprint] {
+			increase = sumValue
+		} else {
+			if hasLast {
+				increase = maxValue - lastValue
+				if increase < 0 {
+					// counter has been reset
+					increase = maxValue
+				}
+			}
+			lastValue = maxValue
+			hasLast = true
+		}
+
+		index := int(bucket - startBucket)
+		if index < 0 || index >= buckets {
+			continue
+		}
+
+		key := strings.Join(labels, xobservemodels.VariableSplitChart)
+		increases, ok := result[key]
+		if !ok {
+			increases = &xobservemodels.MetricIncreases{
+				Labels: labels,
+				Values: make([]float64, buckets),
+			}
+			result[key] = increases
+		}
+		increases.Values[index] += increase
+	}
+
+	return result, nil
+}
+
+func anyLabelsQuery(labels []string) string {
+	query := ""
+	for _, label := range labels {
+		query += fmt.Sprintf(", any(JSONExtractString(labels, '%s'))", label)
+	}
+	return query
+}
+
+// HistogramQuantile computes the quantile from histogram buckets in the same way as prometheus,
+// bucketCounts are the counts of each `le` bucket(cumulative in le), e.g {"10": 3, "50": 8, "+Inf": 10},
+// 0 is returned when there is no enough data
+func HistogramQuantile(q float64, bucketCounts map[string]float64) float64 {
+	type bucket struct {
+		upperBound float64
+		count      float64
+	}
+
+	buckets := make([]bucket, 0, len(bucketCounts))
+	for le, count := range bucketCounts {
+		upperBound, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			continue
+		}
+		buckets = append(buckets, bucket{upperBound, count})
+	}
+	if len(buckets) < 2 {
+		return 0
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].upperBound < buckets[j].upperBound
+	})
+	if !math.IsInf(buckets[len(buckets)-1].upperBound, 1) {
+		return 0
+	}
+
+	total := buckets[len(buckets)-1].count
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * total
+	i := sort.Search(len(buckets)-1, func(i int) bool { return buckets[i].count >= rank })
+	if i == len(buckets)-1 {
+		return buckets[len(buckets)-2].upperBound
+	}
+
+	var bucketStart, countStart float64
+	if i > 0 {
+		bucketStart = buckets[i-1].upperBound
+		countStart = buckets[i-1].count
+	}
+	bucketEnd := buckets[i].upperBound
+	count := buckets[i].count - countStart
+	if count == 0 {
+		return bucketEnd
+	}
+
+	return bucketStart + (bucketEnd-bucketStart)*((rank-countStart)/count)
+}
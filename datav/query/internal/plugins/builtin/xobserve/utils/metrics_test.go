@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+func TestLimitMetricsStep(t *testing.T) {
+	cases := []struct {
+		desc       string
+		start, end int64
+		step       int64
+		exp        int64
+	}{
+		{"short range keeps step", 1700000000, 1700003600, 60, 60},
+		{"end before start", 1700003600, 1700000000, 60, 60},
+		{"long range enlarges step", 1700000000, 1700000000 + 7*86400, 1, 7*86400/(xobservemodels.MetricsMaxBuckets-2) + 1},
+	}
+
+	for _, c := range cases {
+		step := LimitMetricsStep(c.start, c.end, c.step)
+		if step != c.exp {
+			t.Errorf("%s: expected step %d, got %d", c.desc, c.exp, step)
+		}
+		if c.end > c.start && c.end/step-c.start/step+1 > xobservemodels.MetricsMaxBuckets {
+			t.Errorf("%s: step %d needs more than %d buckets", c.desc, step, xobservemodels.MetricsMaxBuckets)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets := map[string]float64{"10": 3, "50": 8, "+Inf": 10}
+
+	cases := []struct {
+		desc    string
+		q       float64
+		buckets map[string]float64
+		exp     float64
+	}{
+		{"interpolated in first bucket", 0.2, buckets, 10 * 2 / 3.0},
+		{"interpolated in middle bucket", 0.5, buckets, 26},
+		{"upper bound of bucket", 0.8, buckets, 50},
+		{"rank in +Inf bucket returns the largest finite bound", 0.95, buckets, 50},
+		{"empty leading bucket", 0, map[string]float64{"10": 0, "20": 5, "+Inf": 5}, 10},
+		{"invalid le is ignored", 0.5, map[string]float64{"abc": 1, "10": 3, "+Inf": 4}, 10 * 2 / 3.0},
+		{"no +Inf bucket", 0.5, map[string]float64{"10": 3, "50": 8}, 0},
+		{"only one bucket", 0.5, map[string]float64{"+Inf": 8}, 0},
+		{"no data", 0.5, map[string]float64{"10": 0, "+Inf": 0}, 0},
+		{"no buckets", 0.5, nil, 0},
+	}
+
+	for _, c := range cases {
+		v := HistogramQuantile(c.q, c.buckets)
+		if math.Abs(v-c.exp) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", c.desc, c.exp, v)
+		}
+	}
+}
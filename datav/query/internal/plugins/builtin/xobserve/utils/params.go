@@ -35,3 +35,16 @@ func GetValueListFromParams(params map[string]interface{}, key string) []string
 
 	return value
 }
+
+// GetIntValueFromParams returns defaultValue when key is missing or its value is not a positive number
+func GetIntValueFromParams(params map[string]interface{}, key string, defaultValue int) int {
+	valueI := params[key]
+	if valueI != nil {
+		value, ok := valueI.(float64)
+		if ok && value > 0 {
+			return int(value)
+		}
+	}
+
+	return defaultValue
+}
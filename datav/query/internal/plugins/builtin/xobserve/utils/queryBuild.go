@@ -5,10 +5,23 @@ import (
 	"strings"
 
 	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+	"github.com/xObserve/xObserve/query/pkg/models"
 )
 
-func BuildBasicDomainQuery(tenant string, params map[string]interface{}) string {
-	domainQuery := fmt.Sprintf(" tenant='%s'", tenant)
+// BuildTenantQuery restricts the query to the telemetry tenants which current request can read
+func BuildTenantQuery(tenants []string) string {
+	if isAllTelemetryTenants(tenants) {
+		return " 1=1"
+	}
+	if len(tenants) == 0 {
+		// no telemetry data can be read
+		return " 1=0"
+	}
+	return fmt.Sprintf(" tenant in (%s)", quoteValues(tenants))
+}
+
+func BuildBasicDomainQuery(tenants []string, params map[string]interface{}) string {
+	domainQuery := BuildTenantQuery(tenants)
 
 	namespace := GetValueListFromParams(params, "namespace")
 	if namespace != nil {
@@ -25,3 +38,39 @@ func BuildBasicDomainQuery(tenant string, params map[string]interface{}) string
 
 	return domainQuery
 }
+
+// BuildMetricsDomainQuery is the same as BuildBasicDomainQuery, but for the labels of metrics time series,
+// tenant, namespace and group are written as labels by xobservespanmetrics processor
+func BuildMetricsDomainQuery(tenants []string, params map[string]interface{}) string {
+	domainQuery := fmt.Sprintf(" JSONExtractString(labels, '%s') in (%s)", xobservemodels.MetricsTenantLabel, quoteValues(tenants))
+	if isAllTelemetryTenants(tenants) {
+		domainQuery = " 1=1"
+	}
+
+	namespace := GetValueListFromParams(params, "namespace")
+	if namespace != nil {
+		domainQuery += fmt.Sprintf(" AND JSONExtractString(labels, '%s') in ('%s')", xobservemodels.MetricsNamespaceLabel, strings.Join(namespace, "','"))
+	} else {
+		domainQuery += fmt.Sprintf(" AND JSONExtractString(labels, '%s')='%s'", xobservemodels.MetricsNamespaceLabel, xobservemodels.DefaultNamespace)
+	}
+	group := GetValueListFromParams(params, "group")
+	if group != nil {
+		domainQuery += fmt.Sprintf(" AND JSONExtractString(labels, '%s') in ('%s')", xobservemodels.MetricsGroupLabel, strings.Join(group, "','"))
+	} else {
+		domainQuery += fmt.Sprintf(" AND JSONExtractString(labels, '%s')='%s'", xobservemodels.MetricsGroupLabel, xobservemodels.DefaultGroup)
+	}
+
+	return domainQuery
+}
+
+func isAllTelemetryTenants(tenants []string) bool {
+	return len(tenants) == 1 && tenants[0] == models.AllTelemetryTenants
+}
+
+func quoteValues(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, "'"+strings.ReplaceAll(v, "'", "\\'")+"'")
+	}
+	return strings.Join(quoted, ",")
+}
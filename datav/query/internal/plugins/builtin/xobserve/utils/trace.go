@@ -0,0 +1,317 @@
+package utils
+
+import (
+	"math"
+	"sort"
+
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+// BuildSpanTree links spans to their parents, roots are the spans without parent,
+// orphans are the spans whose parent is not found in the trace, they are not included in roots.
+// When span ids are duplicated, children are linked to the first span of the id.
+// Spans in parent cycles can't be reached from roots, the cycles are broken and they are returned as orphans
+func BuildSpanTree(spans []*xobservemodels.TraceSpan) (roots []*xobservemodels.SpanNode, orphans []*xobservemodels.SpanNode) {
+	nodes := make([]*xobservemodels.SpanNode, 0, len(spans))
+	index := make(map[string]*xobservemodels.SpanNode, len(spans))
+	for _, span := range spans {
+		node := &xobservemodels.SpanNode{Span: span}
+		nodes = append(nodes, node)
+		if _, ok := index[span.SpanId]; !ok {
+			index[span.SpanId] = node
+		}
+	}
+
+	roots = make([]*xobservemodels.SpanNode, 0)
+	orphans = make([]*xobservemodels.SpanNode, 0)
+	for _, node := range nodes {
+		if node.Span.ParentId == "" {
+			roots = append(roots, node)
+			continue
+		}
+
+		parent, ok := index[node.Span.ParentId]
+		if !ok {
+			orphans = append(orphans, node)
+			continue
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+
+	visited := make(map[*xobservemodels.SpanNode]bool, len(nodes))
+	var visit func(node *xobservemodels.SpanNode)
+	visit = func(node *xobservemodels.SpanNode) {
+		if visited[node] {
+			return
+		}
+		visited[node] = true
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	for _, node := range roots {
+		visit(node)
+	}
+	for _, node := range orphans {
+		visit(node)
+	}
+
+	for _, node := range nodes {
+		if visited[node] {
+			continue
+		}
+		// the ancestors of an unreachable span are unreachable too, so they are in a cycle or
+		// hang off a cycle, detaching the span breaks the cycle or leaves a tree without cycle
+		parent := node.Parent
+		for i, child := range parent.Children {
+			if child == node {
+				parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+				break
+			}
+		}
+		node.Parent = nil
+		orphans = append(orphans, node)
+		visit(node)
+	}
+
+	for _, node := range nodes {
+		sort.Slice(node.Children, func(i, j int) bool {
+			return node.Children[i].Span.StartTime < node.Children[j].Span.StartTime
+		})
+	}
+
+	return roots, orphans
+}
+
+// ComputeSelfTime sets the self time of the node and all its descendants,
+// children are clipped to the time range of their parent, and overlapped children are only counted once
+func ComputeSelfTime(node *xobservemodels.SpanNode) {
+	start := node.Span.StartTime
+	end := start + node.Span.Duration
+
+	var covered uint64
+	var cursor = start
+	// children are sorted by start time
+	for _, child := range node.Children {
+		ComputeSelfTime(child)
+
+		childStart := child.Span.StartTime
+		childEnd := childStart + child.Span.Duration
+		if childStart < cursor {
+			childStart = cursor
+		}
+		if childEnd > end {
+			childEnd = end
+		}
+		if childEnd > childStart {
+			covered += childEnd - childStart
+			cursor = childEnd
+		}
+	}
+
+	node.SelfTime = node.Span.Duration - covered
+}
+
+// AnalyzeTrace builds the span tree of a trace, fixes clock skew between services,
+// then computes self time, critical path and time breakdown of each service
+func AnalyzeTrace(traceId string, spans []*xobservemodels.TraceSpan) *xobservemodels.TraceAnalysis {
+	analysis := &xobservemodels.TraceAnalysis{
+		TraceId:      traceId,
+		NumSpans:     len(spans),
+		Spans:        make([]*xobservemodels.AnalyzedSpan, 0, len(spans)),
+		CriticalPath: make([]*xobservemodels.CriticalPathSegment, 0),
+		Services:     make([]*xobservemodels.ServiceTimeBreakdown, 0),
+		Orphans:      make([]string, 0),
+	}
+	if len(spans) == 0 {
+		return analysis
+	}
+
+	roots, orphans := BuildSpanTree(spans)
+
+	skews := make(map[*xobservemodels.SpanNode]int64)
+	for _, node := range append(roots, orphans...) {
+		AdjustClockSkew(node, 0, skews)
+		ComputeSelfTime(node)
+	}
+
+	// the critical path starts at the longest root, if all spans are orphans, use the longest orphan instead
+	var main *xobservemodels.SpanNode
+	for _, node := range roots {
+		if main == nil || node.Span.Duration > main.Span.Duration {
+			main = node
+		}
+	}
+	if main == nil {
+		for _, node := range orphans {
+			if main == nil || node.Span.Duration > main.Span.Duration {
+				main = node
+			}
+		}
+	}
+	if main != nil {
+		ComputeCriticalPath(main, main.Span.StartTime+main.Span.Duration, &analysis.CriticalPath)
+		// segments are collected from the end of the trace to the start
+		for i, j := 0, len(analysis.CriticalPath)-1; i < j; i, j = i+1, j-1 {
+			analysis.CriticalPath[i], analysis.CriticalPath[j] = analysis.CriticalPath[j], analysis.CriticalPath[i]
+		}
+	}
+
+	criticalSpans := make(map[string]bool)
+	services := make(map[string]*xobservemodels.ServiceTimeBreakdown)
+	for _, segment := range analysis.CriticalPath {
+		criticalSpans[segment.SpanId] = true
+		service := getServiceTimeBreakdown(services, segment.ServiceName)
+		service.CriticalPathTime += segment.End - segment.Start
+	}
+
+	var traceStart, traceEnd uint64 = math.MaxUint64, 0
+	var totalSelfTime uint64
+	var walk func(node *xobservemodels.SpanNode, depth int, orphan bool)
+	walk = func(node *xobservemodels.SpanNode, depth int, orphan bool) {
+		span := node.Span
+		analysis.Spans = append(analysis.Spans, &xobservemodels.AnalyzedSpan{
+			TraceSpan:      span,
+			Depth:          depth,
+			SelfTime:       node.SelfTime,
+			ClockSkew:      skews[node],
+			OnCriticalPath: criticalSpans[span.SpanId],
+			Orphan:         orphan,
+		})
+
+		if span.StartTime < traceStart {
+			traceStart = span.StartTime
+		}
+		if span.StartTime+span.Duration > traceEnd {
+			traceEnd = span.StartTime + span.Duration
+		}
+
+		service := getServiceTimeBreakdown(services, span.ServiceName)
+		service.Spans++
+		if span.HasError {
+			service.Errors++
+		}
+		service.SelfTime += node.SelfTime
+		totalSelfTime += node.SelfTime
+
+		for _, child := range node.Children {
+			walk(child, depth+1, false)
+		}
+	}
+
+	for _, node := range roots {
+		walk(node, 0, false)
+	}
+	for _, node := range orphans {
+		analysis.Orphans = append(analysis.Orphans, node.Span.SpanId)
+		walk(node, 0, true)
+	}
+
+	analysis.StartTime = traceStart
+	analysis.Duration = traceEnd - traceStart
+
+	for _, service := range services {
+		if totalSelfTime > 0 {
+			service.Percentage = float64(service.SelfTime) * 100 / float64(totalSelfTime)
+		}
+		analysis.Services = append(analysis.Services, service)
+	}
+	sort.Slice(analysis.Services, func(i, j int) bool {
+		return analysis.Services[i].SelfTime > analysis.Services[j].SelfTime
+	})
+
+	return analysis
+}
+
+func getServiceTimeBreakdown(services map[string]*xobservemodels.ServiceTimeBreakdown, name string) *xobservemodels.ServiceTimeBreakdown {
+	service, ok := services[name]
+	if !ok {
+		service = &xobservemodels.ServiceTimeBreakdown{ServiceName: name}
+		services[name] = service
+	}
+	return service
+}
+
+// AdjustClockSkew shifts the node and its descendants by delta, and when a child comes from another service
+// but doesn't fit into its parent, the child subtree is moved to the middle of the parent, assuming the
+// network latency of request and response is the same. The applied shift of each node is recorded in skews
+func AdjustClockSkew(node *xobservemodels.SpanNode, delta int64, skews map[*xobservemodels.SpanNode]int64) {
+	if delta != 0 {
+		node.Span.StartTime = uint64(int64(node.Span.StartTime) + delta)
+		skews[node] = delta
+	}
+
+	start := node.Span.StartTime
+	end := start + node.Span.Duration
+	for _, child := range node.Children {
+		childDelta := delta
+		if child.Span.ServiceName != node.Span.ServiceName && child.Span.Duration <= node.Span.Duration {
+			childStart := uint64(int64(child.Span.StartTime) + delta)
+			childEnd := childStart + child.Span.Duration
+			if childStart < start || childEnd > end {
+				latency := (node.Span.Duration - child.Span.Duration) / 2
+				childDelta += int64(start+latency) - int64(childStart)
+			}
+		}
+		AdjustClockSkew(child, childDelta, skews)
+	}
+
+	// the start time of children may be changed
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Span.StartTime < node.Children[j].Span.StartTime
+	})
+}
+
+// ComputeCriticalPath walks back from end, at each moment the critical path goes through the child
+// which finishes last, and the parent itself when no child is running
+func ComputeCriticalPath(node *xobservemodels.SpanNode, end uint64, path *[]*xobservemodels.CriticalPathSegment) {
+	start := node.Span.StartTime
+	cursor := start + node.Span.Duration
+	if end < cursor {
+		cursor = end
+	}
+
+	children := make([]*xobservemodels.SpanNode, len(node.Children))
+	copy(children, node.Children)
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Span.StartTime+children[i].Span.Duration > children[j].Span.StartTime+children[j].Span.Duration
+	})
+
+	for _, child := range children {
+		if cursor <= start {
+			break
+		}
+		childEnd := child.Span.StartTime + child.Span.Duration
+		if child.Span.StartTime >= cursor || childEnd <= start {
+			continue
+		}
+
+		if childEnd > cursor {
+			childEnd = cursor
+		}
+		if childEnd < cursor {
+			*path = append(*path, newCriticalPathSegment(node, childEnd, cursor))
+		}
+		ComputeCriticalPath(child, childEnd, path)
+
+		cursor = child.Span.StartTime
+		if cursor < start {
+			cursor = start
+		}
+	}
+
+	if cursor > start {
+		*path = append(*path, newCriticalPathSegment(node, start, cursor))
+	}
+}
+
+func newCriticalPathSegment(node *xobservemodels.SpanNode, start, end uint64) *xobservemodels.CriticalPathSegment {
+	return &xobservemodels.CriticalPathSegment{
+		SpanId:      node.Span.SpanId,
+		ServiceName: node.Span.ServiceName,
+		Name:        node.Span.Name,
+		Start:       start,
+		End:         end,
+	}
+}
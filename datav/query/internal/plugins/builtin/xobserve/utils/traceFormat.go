@@ -0,0 +1,427 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+const serviceNameKey = "service.name"
+
+var spanKindNames = map[int8]string{
+	1: "internal",
+	2: "server",
+	3: "client",
+	4: "producer",
+	5: "consumer",
+}
+
+var spanStatusNames = map[int16]string{
+	1: "OK",
+	2: "ERROR",
+}
+
+// TraceToOTLP converts spans to OTLP JSON, spans with the same resource attributes are put into one resource
+func TraceToOTLP(spans []*xobservemodels.TraceSpan) *xobservemodels.OTLPTraceData {
+	data := &xobservemodels.OTLPTraceData{ResourceSpans: make([]*xobservemodels.OTLPResourceSpans, 0)}
+	resources := make(map[string]*xobservemodels.OTLPScopeSpans)
+	for _, span := range spans {
+		key := spanResourceKey(span)
+		scopeSpans, ok := resources[key]
+		if !ok {
+			resourceAttrs := make(map[string]string, len(span.ResourcesMap)+1)
+			for k, v := range span.ResourcesMap {
+				resourceAttrs[k] = v
+			}
+			resourceAttrs[serviceNameKey] = span.ServiceName
+
+			scopeSpans = &xobservemodels.OTLPScopeSpans{Spans: make([]*xobservemodels.OTLPSpan, 0)}
+			data.ResourceSpans = append(data.ResourceSpans, &xobservemodels.OTLPResourceSpans{
+				Resource:   xobservemodels.OTLPResource{Attributes: toOTLPAttributes(resourceAttrs, nil, nil)},
+				ScopeSpans: []*xobservemodels.OTLPScopeSpans{scopeSpans},
+			})
+			resources[key] = scopeSpans
+		}
+
+		otlpSpan := &xobservemodels.OTLPSpan{
+			TraceId:           span.TraceId,
+			SpanId:            span.SpanId,
+			ParentSpanId:      span.ParentId,
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: xobservemodels.OTLPInt(span.StartTime),
+			EndTimeUnixNano:   xobservemodels.OTLPInt(span.StartTime + span.Duration),
+			Attributes:        toOTLPAttributes(span.StringAttributesMap, span.NumberAttributesMap, span.BoolAttributesMap),
+			Status:            xobservemodels.OTLPStatus{Code: span.StatusCode},
+		}
+		for _, event := range decodeSpanEvents(span.Events) {
+			otlpSpan.Events = append(otlpSpan.Events, &xobservemodels.OTLPEvent{
+				TimeUnixNano: xobservemodels.OTLPInt(event.TimeUnixNano),
+				Name:         event.Name,
+				Attributes:   toOTLPAttributes(event.AttributeMap, nil, nil),
+			})
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, otlpSpan)
+	}
+
+	return data
+}
+
+// TraceFromOTLP converts OTLP JSON to spans, in the same way as the clickhouse traces exporter does
+func TraceFromOTLP(data *xobservemodels.OTLPTraceData) ([]*xobservemodels.TraceSpan, error) {
+	spans := make([]*xobservemodels.TraceSpan, 0)
+	for _, rs := range data.ResourceSpans {
+		resourceAttrs := make(map[string]string, len(rs.Resource.Attributes))
+		for _, kv := range rs.Resource.Attributes {
+			resourceAttrs[kv.Key] = otlpValueString(kv.Value)
+		}
+
+		for _, ss := range rs.ScopeSpans {
+			for _, otlpSpan := range ss.Spans {
+				if otlpSpan.TraceId == "" || otlpSpan.SpanId == "" {
+					return nil, errors.New("traceId and spanId of span can not be empty")
+				}
+
+				span := &xobservemodels.TraceSpan{
+					TraceId:             otlpSpan.TraceId,
+					SpanId:              otlpSpan.SpanId,
+					ParentId:            otlpSpan.ParentSpanId,
+					Name:                otlpSpan.Name,
+					StartTime:           uint64(otlpSpan.StartTimeUnixNano),
+					ServiceName:         resourceAttrs[serviceNameKey],
+					Kind:                otlpSpan.Kind,
+					StatusCode:          otlpSpan.Status.Code,
+					ResourcesMap:        resourceAttrs,
+					StringAttributesMap: make(map[string]string),
+					NumberAttributesMap: make(map[string]float64),
+					BoolAttributesMap:   make(map[string]bool),
+				}
+				if otlpSpan.EndTimeUnixNano > otlpSpan.StartTimeUnixNano {
+					span.Duration = uint64(otlpSpan.EndTimeUnixNano - otlpSpan.StartTimeUnixNano)
+				}
+
+				for _, kv := range otlpSpan.Attributes {
+					switch {
+					case kv.Value.BoolValue != nil:
+						span.BoolAttributesMap[kv.Key] = *kv.Value.BoolValue
+					case kv.Value.IntValue != nil:
+						span.NumberAttributesMap[kv.Key] = float64(*kv.Value.IntValue)
+					case kv.Value.DoubleValue != nil:
+						span.NumberAttributesMap[kv.Key] = *kv.Value.DoubleValue
+					default:
+						span.StringAttributesMap[kv.Key] = otlpValueString(kv.Value)
+					}
+				}
+
+				setSpanError(span)
+
+				for _, otlpEvent := range otlpSpan.Events {
+					event := &xobservemodels.TraceSpanEvent{
+						Name:         otlpEvent.Name,
+						TimeUnixNano: uint64(otlpEvent.TimeUnixNano),
+						AttributeMap: make(map[string]string, len(otlpEvent.Attributes)),
+					}
+					for _, kv := range otlpEvent.Attributes {
+						event.AttributeMap[kv.Key] = otlpValueString(kv.Value)
+					}
+					span.Events = append(span.Events, encodeSpanEvent(event))
+				}
+
+				spans = append(spans, span)
+			}
+		}
+	}
+
+	return spans, nil
+}
+
+// TraceToJaeger converts spans to Jaeger JSON, spans with the same resource attributes share one process
+func TraceToJaeger(traceId string, spans []*xobservemodels.TraceSpan) *xobservemodels.JaegerTraceData {
+	trace := &xobservemodels.JaegerTrace{
+		TraceID:   traceId,
+		Spans:     make([]*xobservemodels.JaegerSpan, 0, len(spans)),
+		Processes: make(map[string]*xobservemodels.JaegerProcess),
+	}
+
+	processIDs := make(map[string]string)
+	for _, span := range spans {
+		key := spanResourceKey(span)
+		processID, ok := processIDs[key]
+		if !ok {
+			processID = fmt.Sprintf("p%d", len(processIDs)+1)
+			processIDs[key] = processID
+			trace.Processes[processID] = &xobservemodels.JaegerProcess{
+				ServiceName: span.ServiceName,
+				Tags:        toJaegerTags(span.ResourcesMap, nil, nil),
+			}
+		}
+
+		jaegerSpan := &xobservemodels.JaegerSpan{
+			TraceID:       span.TraceId,
+			SpanID:        span.SpanId,
+			OperationName: span.Name,
+			References:    make([]*xobservemodels.JaegerReference, 0),
+			StartTime:     span.StartTime / 1e3,
+			Duration:      span.Duration / 1e3,
+			Tags:          toJaegerTags(span.StringAttributesMap, span.NumberAttributesMap, span.BoolAttributesMap),
+			Logs:          make([]*xobservemodels.JaegerLog, 0),
+			ProcessID:     processID,
+		}
+		if span.ParentId != "" {
+			jaegerSpan.References = append(jaegerSpan.References, &xobservemodels.JaegerReference{
+				RefType: "CHILD_OF",
+				TraceID: span.TraceId,
+				SpanID:  span.ParentId,
+			})
+		}
+
+		kind, ok := spanKindNames[span.Kind]
+		if ok {
+			jaegerSpan.Tags = append(jaegerSpan.Tags, &xobservemodels.JaegerKeyValue{Key: "span.kind", Type: "string", Value: kind})
+		}
+		status, ok := spanStatusNames[span.StatusCode]
+		if ok {
+			jaegerSpan.Tags = append(jaegerSpan.Tags, &xobservemodels.JaegerKeyValue{Key: "otel.status_code", Type: "string", Value: status})
+		}
+
+		for _, event := range decodeSpanEvents(span.Events) {
+			fields := []*xobservemodels.JaegerKeyValue{{Key: "event", Type: "string", Value: event.Name}}
+			jaegerSpan.Logs = append(jaegerSpan.Logs, &xobservemodels.JaegerLog{
+				Timestamp: event.TimeUnixNano / 1e3,
+				Fields:    append(fields, toJaegerTags(event.AttributeMap, nil, nil)...),
+			})
+		}
+
+		trace.Spans = append(trace.Spans, jaegerSpan)
+	}
+
+	return &xobservemodels.JaegerTraceData{Data: []*xobservemodels.JaegerTrace{trace}}
+}
+
+// TraceFromJaeger converts Jaeger JSON to spans, span.kind and otel.status_code tags are mapped back to span kind and status
+func TraceFromJaeger(data *xobservemodels.JaegerTraceData) ([]*xobservemodels.TraceSpan, error) {
+	spans := make([]*xobservemodels.TraceSpan, 0)
+	for _, trace := range data.Data {
+		for _, jaegerSpan := range trace.Spans {
+			if jaegerSpan.TraceID == "" || jaegerSpan.SpanID == "" {
+				return nil, errors.New("traceID and spanID of span can not be empty")
+			}
+
+			process, ok := trace.Processes[jaegerSpan.ProcessID]
+			if !ok {
+				return nil, fmt.Errorf("process %s of span %s not found", jaegerSpan.ProcessID, jaegerSpan.SpanID)
+			}
+
+			span := &xobservemodels.TraceSpan{
+				TraceId:             jaegerSpan.TraceID,
+				SpanId:              jaegerSpan.SpanID,
+				Name:                jaegerSpan.OperationName,
+				StartTime:           jaegerSpan.StartTime * 1e3,
+				Duration:            jaegerSpan.Duration * 1e3,
+				ServiceName:         process.ServiceName,
+				ResourcesMap:        make(map[string]string, len(process.Tags)+1),
+				StringAttributesMap: make(map[string]string),
+				NumberAttributesMap: make(map[string]float64),
+				BoolAttributesMap:   make(map[string]bool),
+			}
+			for _, tag := range process.Tags {
+				span.ResourcesMap[tag.Key] = fmt.Sprint(tag.Value)
+			}
+			span.ResourcesMap[serviceNameKey] = process.ServiceName
+
+			for _, ref := range jaegerSpan.References {
+				if span.ParentId == "" || ref.RefType == "CHILD_OF" {
+					span.ParentId = ref.SpanID
+				}
+			}
+
+			for _, tag := range jaegerSpan.Tags {
+				switch tag.Key {
+				case "span.kind":
+					for kind, name := range spanKindNames {
+						if name == fmt.Sprint(tag.Value) {
+							span.Kind = kind
+						}
+					}
+					continue
+				case "otel.status_code":
+					for code, name := range spanStatusNames {
+						if name == fmt.Sprint(tag.Value) {
+							span.StatusCode = code
+						}
+					}
+					continue
+				}
+
+				switch tag.Type {
+				case "bool":
+					v, _ := strconv.ParseBool(fmt.Sprint(tag.Value))
+					span.BoolAttributesMap[tag.Key] = v
+				case "int64", "float64":
+					v, err := strconv.ParseFloat(fmt.Sprint(tag.Value), 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid %s value of tag %s", tag.Type, tag.Key)
+					}
+					span.NumberAttributesMap[tag.Key] = v
+				default:
+					span.StringAttributesMap[tag.Key] = fmt.Sprint(tag.Value)
+				}
+			}
+
+			// spans recorded by jaeger clients only have the error tag
+			if span.StatusCode == 0 && span.BoolAttributesMap["error"] {
+				span.StatusCode = 2
+			}
+			setSpanError(span)
+
+			for _, log := range jaegerSpan.Logs {
+				event := &xobservemodels.TraceSpanEvent{
+					Name:         "log",
+					TimeUnixNano: log.Timestamp * 1e3,
+					AttributeMap: make(map[string]string, len(log.Fields)),
+				}
+				for _, field := range log.Fields {
+					if field.Key == "event" {
+						event.Name = fmt.Sprint(field.Value)
+						continue
+					}
+					event.AttributeMap[field.Key] = fmt.Sprint(field.Value)
+				}
+				span.Events = append(span.Events, encodeSpanEvent(event))
+			}
+
+			spans = append(spans, span)
+		}
+	}
+
+	return spans, nil
+}
+
+// setSpanError marks the span as error in the same way as the clickhouse traces exporter
+func setSpanError(span *xobservemodels.TraceSpan) {
+	if span.StatusCode == 2 {
+		span.HasError = true
+		span.BoolAttributesMap["error"] = true
+	}
+}
+
+func decodeSpanEvents(events []string) []*xobservemodels.TraceSpanEvent {
+	res := make([]*xobservemodels.TraceSpanEvent, 0, len(events))
+	for _, e := range events {
+		event := &xobservemodels.TraceSpanEvent{}
+		err := json.Unmarshal([]byte(e), event)
+		if err != nil {
+			continue
+		}
+		res = append(res, event)
+	}
+	return res
+}
+
+func encodeSpanEvent(event *xobservemodels.TraceSpanEvent) string {
+	event.IsError = event.Name == "exception"
+	b, _ := json.Marshal(event)
+	return string(b)
+}
+
+func toOTLPAttributes(strs map[string]string, numbers map[string]float64, bools map[string]bool) []*xobservemodels.OTLPKeyValue {
+	attrs := make([]*xobservemodels.OTLPKeyValue, 0, len(strs)+len(numbers)+len(bools))
+	for k, v := range strs {
+		v := v
+		attrs = append(attrs, &xobservemodels.OTLPKeyValue{Key: k, Value: xobservemodels.OTLPAnyValue{StringValue: &v}})
+	}
+	for k, v := range numbers {
+		v := v
+		kv := &xobservemodels.OTLPKeyValue{Key: k}
+		if isInteger(v) {
+			i := xobservemodels.OTLPInt(v)
+			kv.Value.IntValue = &i
+		} else {
+			kv.Value.DoubleValue = &v
+		}
+		attrs = append(attrs, kv)
+	}
+	for k, v := range bools {
+		v := v
+		attrs = append(attrs, &xobservemodels.OTLPKeyValue{Key: k, Value: xobservemodels.OTLPAnyValue{BoolValue: &v}})
+	}
+
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Key < attrs[j].Key
+	})
+	return attrs
+}
+
+func toJaegerTags(strs map[string]string, numbers map[string]float64, bools map[string]bool) []*xobservemodels.JaegerKeyValue {
+	tags := make([]*xobservemodels.JaegerKeyValue, 0, len(strs)+len(numbers)+len(bools))
+	for k, v := range strs {
+		tags = append(tags, &xobservemodels.JaegerKeyValue{Key: k, Type: "string", Value: v})
+	}
+	for k, v := range numbers {
+		if isInteger(v) {
+			tags = append(tags, &xobservemodels.JaegerKeyValue{Key: k, Type: "int64", Value: int64(v)})
+		} else {
+			tags = append(tags, &xobservemodels.JaegerKeyValue{Key: k, Type: "float64", Value: v})
+		}
+	}
+	for k, v := range bools {
+		tags = append(tags, &xobservemodels.JaegerKeyValue{Key: k, Type: "bool", Value: v})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Key < tags[j].Key
+	})
+	return tags
+}
+
+// the same as pcommon.Value.AsString
+func otlpValueString(v xobservemodels.OTLPAnyValue) string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strconv.FormatInt(int64(*v.IntValue), 10)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'f', -1, 64)
+	case v.ArrayValue != nil:
+		return strings.TrimSpace(string(v.ArrayValue))
+	case v.KvlistValue != nil:
+		return strings.TrimSpace(string(v.KvlistValue))
+	default:
+		return ""
+	}
+}
+
+// numbers are stored as float64 in trace spans, integers are exported as int values
+func isInteger(v float64) bool {
+	return v == math.Trunc(v) && math.Abs(v) < 1<<53
+}
+
+// spanResourceKey identifies the resource of a span by service name and all the resource attributes,
+// so spans from different instances of a service keep their own attributes, e.g host.name
+func spanResourceKey(span *xobservemodels.TraceSpan) string {
+	keys := make([]string, 0, len(span.ResourcesMap))
+	for k := range span.ResourcesMap {
+		if k != serviceNameKey {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	b, _ := json.Marshal(span.ServiceName)
+	key := string(b)
+	for _, k := range keys {
+		kb, _ := json.Marshal(k)
+		vb, _ := json.Marshal(span.ResourcesMap[k])
+		key += "," + string(kb) + ":" + string(vb)
+	}
+	return key
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+// times are in microseconds precision, because jaeger doesn't support nanoseconds
+func testTraceSpans() []*xobservemodels.TraceSpan {
+	return []*xobservemodels.TraceSpan{
+		{
+			TraceId:             "5b8aa5a2d2c872e8321cf37308d69df2",
+			SpanId:              "051581bf3cb55c13",
+			Name:                "GET /api/orders",
+			StartTime:           1700000000123456000,
+			Duration:            25000000,
+			ServiceName:         "frontend",
+			Kind:                2,
+			StatusCode:          1,
+			ResourcesMap:        map[string]string{"service.name": "frontend", "host.name": "node-1"},
+			StringAttributesMap: map[string]string{"http.method": "GET"},
+			NumberAttributesMap: map[string]float64{"http.status_code": 200, "sample.ratio": 0.5},
+			BoolAttributesMap:   map[string]bool{"cache.hit": false},
+		},
+		{
+			TraceId:             "5b8aa5a2d2c872e8321cf37308d69df2",
+			SpanId:              "5fb397be34d26b51",
+			ParentId:            "051581bf3cb55c13",
+			Name:                "SELECT orders",
+			StartTime:           1700000000125000000,
+			Duration:            20000000,
+			ServiceName:         "order",
+			Kind:                3,
+			StatusCode:          2,
+			ResourcesMap:        map[string]string{"service.name": "order"},
+			StringAttributesMap: map[string]string{"db.system": "mysql"},
+			NumberAttributesMap: map[string]float64{},
+			BoolAttributesMap:   map[string]bool{"error": true},
+			Events: []string{
+				encodeSpanEvent(&xobservemodels.TraceSpanEvent{
+					Name:         "exception",
+					TimeUnixNano: 1700000000145000000,
+					AttributeMap: map[string]string{"exception.message": "timeout"},
+				}),
+			},
+			HasError: true,
+		},
+		{
+			TraceId:             "5b8aa5a2d2c872e8321cf37308d69df2",
+			SpanId:              "7a0c1b5e2f3d4c6b",
+			ParentId:            "5fb397be34d26b51",
+			Name:                "GET /api/stock",
+			StartTime:           1700000000130000000,
+			Duration:            5000000,
+			ServiceName:         "frontend",
+			Kind:                2,
+			ResourcesMap:        map[string]string{"service.name": "frontend", "host.name": "node-2"},
+			StringAttributesMap: map[string]string{},
+			NumberAttributesMap: map[string]float64{},
+			BoolAttributesMap:   map[string]bool{},
+		},
+	}
+}
+
+func TestTraceOTLPRoundTrip(t *testing.T) {
+	spans := testTraceSpans()
+
+	otlp := TraceToOTLP(spans)
+	if len(otlp.ResourceSpans) != 3 {
+		t.Errorf("expected 3 resources, got %d", len(otlp.ResourceSpans))
+	}
+	b, err := json.Marshal(otlp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := &xobservemodels.OTLPTraceData{}
+	err = json.Unmarshal(b, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := TraceFromOTLP(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareTraceSpans(t, spans, res)
+}
+
+func TestTraceJaegerRoundTrip(t *testing.T) {
+	spans := testTraceSpans()
+
+	jaeger := TraceToJaeger(spans[0].TraceId, spans)
+	if len(jaeger.Data[0].Processes) != 3 {
+		t.Errorf("expected 3 processes, got %d", len(jaeger.Data[0].Processes))
+	}
+	b, err := json.Marshal(jaeger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := &xobservemodels.JaegerTraceData{}
+	err = json.Unmarshal(b, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := TraceFromJaeger(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareTraceSpans(t, spans, res)
+}
+
+func TestTraceFromInvalidData(t *testing.T) {
+	_, err := TraceFromOTLP(&xobservemodels.OTLPTraceData{
+		ResourceSpans: []*xobservemodels.OTLPResourceSpans{
+			{ScopeSpans: []*xobservemodels.OTLPScopeSpans{{Spans: []*xobservemodels.OTLPSpan{{Name: "no ids"}}}}},
+		},
+	})
+	if err == nil {
+		t.Error("expected error for otlp span without ids")
+	}
+
+	_, err = TraceFromJaeger(&xobservemodels.JaegerTraceData{
+		Data: []*xobservemodels.JaegerTrace{
+			{Spans: []*xobservemodels.JaegerSpan{{TraceID: "1", SpanID: "2", ProcessID: "p1"}}},
+		},
+	})
+	if err == nil {
+		t.Error("expected error for jaeger span without process")
+	}
+}
+
+func compareTraceSpans(t *testing.T, exp, res []*xobservemodels.TraceSpan) {
+	t.Helper()
+	if len(res) != len(exp) {
+		t.Fatalf("expected %d spans, got %d", len(exp), len(res))
+	}
+
+	for i := range exp {
+		if !reflect.DeepEqual(exp[i], res[i]) {
+			e, _ := json.Marshal(exp[i])
+			r, _ := json.Marshal(res[i])
+			t.Errorf("span %d:\nexpected %s\ngot      %s", i, e, r)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+func testSpan(id, parentId string, start, duration uint64) *xobservemodels.TraceSpan {
+	return &xobservemodels.TraceSpan{SpanId: id, ParentId: parentId, StartTime: start, Duration: duration, ServiceName: "svc"}
+}
+
+func spanIdsOf(nodes []*xobservemodels.SpanNode) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.Span.SpanId)
+	}
+	return ids
+}
+
+func TestBuildSpanTree(t *testing.T) {
+	cases := []struct {
+		desc    string
+		spans   []*xobservemodels.TraceSpan
+		roots   []string
+		orphans []string
+		// number of nodes reachable from roots and orphans, every span must be reached once
+		reached int
+	}{
+		{
+			desc:    "tree with orphan",
+			spans:   []*xobservemodels.TraceSpan{testSpan("a", "", 0, 10), testSpan("b", "a", 1, 5), testSpan("c", "x", 2, 3)},
+			roots:   []string{"a"},
+			orphans: []string{"c"},
+			reached: 3,
+		},
+		{
+			desc:    "two spans in a cycle",
+			spans:   []*xobservemodels.TraceSpan{testSpan("a", "b", 0, 10), testSpan("b", "a", 1, 5)},
+			roots:   []string{},
+			orphans: []string{"a"},
+			reached: 2,
+		},
+		{
+			desc:    "span is its own parent",
+			spans:   []*xobservemodels.TraceSpan{testSpan("a", "a", 0, 10), testSpan("b", "a", 1, 5)},
+			roots:   []string{},
+			orphans: []string{"a"},
+			reached: 2,
+		},
+		{
+			desc:    "cycle besides a normal tree",
+			spans:   []*xobservemodels.TraceSpan{testSpan("r", "", 0, 10), testSpan("a", "c", 0, 10), testSpan("b", "a", 1, 5), testSpan("c", "b", 2, 3), testSpan("d", "b", 3, 1)},
+			roots:   []string{"r"},
+			orphans: []string{"a"},
+			reached: 5,
+		},
+		{
+			desc:    "duplicated span ids",
+			spans:   []*xobservemodels.TraceSpan{testSpan("a", "", 0, 10), testSpan("b", "a", 1, 5), testSpan("b", "a", 2, 5), testSpan("c", "b", 3, 1)},
+			roots:   []string{"a"},
+			orphans: []string{},
+			reached: 4,
+		},
+	}
+
+	for _, c := range cases {
+		roots, orphans := BuildSpanTree(c.spans)
+		if ids := spanIdsOf(roots); !reflect.DeepEqual(ids, c.roots) {
+			t.Errorf("%s: expected roots %v, got %v", c.desc, c.roots, ids)
+		}
+		if ids := spanIdsOf(orphans); !reflect.DeepEqual(ids, c.orphans) {
+			t.Errorf("%s: expected orphans %v, got %v", c.desc, c.orphans, ids)
+		}
+
+		reached := make(map[*xobservemodels.SpanNode]int)
+		var walk func(node *xobservemodels.SpanNode)
+		walk = func(node *xobservemodels.SpanNode) {
+			reached[node]++
+			if reached[node] > 1 {
+				return
+			}
+			for _, child := range node.Children {
+				walk(child)
+			}
+		}
+		for _, node := range append(roots, orphans...) {
+			walk(node)
+		}
+		if len(reached) != c.reached {
+			t.Errorf("%s: expected %d spans reached, got %d", c.desc, c.reached, len(reached))
+		}
+		for node, n := range reached {
+			if n != 1 {
+				t.Errorf("%s: span %s is reached %d times", c.desc, node.Span.SpanId, n)
+			}
+		}
+	}
+}
+
+func TestAnalyzeTraceWithCycle(t *testing.T) {
+	spans := []*xobservemodels.TraceSpan{testSpan("a", "b", 0, 10), testSpan("b", "a", 2, 5)}
+	analysis := AnalyzeTrace("t1", spans)
+
+	if analysis.NumSpans != 2 || len(analysis.Spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(analysis.Spans))
+	}
+	if !reflect.DeepEqual(analysis.Orphans, []string{"a"}) {
+		t.Errorf("expected orphans [a], got %v", analysis.Orphans)
+	}
+
+	ids := make([]string, 0)
+	for _, segment := range analysis.CriticalPath {
+		ids = append(ids, segment.SpanId)
+	}
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"a", "a", "b"}) {
+		t.Errorf("expected critical path through a, b and a, got %v", ids)
+	}
+	if analysis.Duration != 10 {
+		t.Errorf("expected duration 10, got %d", analysis.Duration)
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	xobservemodels "github.com/xObserve/xObserve/query/internal/plugins/builtin/xobserve/models"
+)
+
+// DecryptUsage decrypts the usage data written by otel-collector usage collector,
+// which is encrypted with the first 32 bytes of the exporter id as AES-CFB key
+func DecryptUsage(exporterID string, data string) (*xobservemodels.Usage, error) {
+	if len(exporterID) < 32 {
+		return nil, errors.New("invalid usage exporter id")
+	}
+	if len(data) < aes.BlockSize {
+		return nil, errors.New("usage data is too short")
+	}
+
+	block, err := aes.NewCipher([]byte(exporterID)[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := []byte(data)
+	text := make([]byte, len(ciphertext)-aes.BlockSize)
+	cfb := cipher.NewCFBDecrypter(block, ciphertext[:aes.BlockSize])
+	cfb.XORKeyStream(text, ciphertext[aes.BlockSize:])
+
+	decoded, err := base64.StdEncoding.DecodeString(string(text))
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &xobservemodels.Usage{}
+	err = json.Unmarshal(decoded, usage)
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
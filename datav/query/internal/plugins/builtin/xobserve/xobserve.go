@@ -2,6 +2,7 @@ package xobserve
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -16,7 +17,7 @@ import (
 
 /* Query plugin for xobserve observability*/
 
-var datasourceName = "xobserve"
+var datasourceName = models.DatasourceXobserve
 
 type xobservePlugin struct{}
 
@@ -40,6 +41,16 @@ func (p *xobservePlugin) Query(c *gin.Context, ds *models.Datasource) models.Plu
 	}
 	route, ok := api.APIRoutes[query]
 	if ok {
+		// admin apis may have set the telemetry tenants already
+		if _, ok := c.Get(models.TelemetryTenantsKey); !ok {
+			telemetryTenants, err := queryTelemetryTenants(c)
+			if err != nil {
+				colorlog.RootLogger.Warn("query telemetry tenants error:", err, "ds_id", ds.Id)
+				return models.GenPluginResult(models.PluginStatusError, err.Error(), nil)
+			}
+			c.Set(models.TelemetryTenantsKey, telemetryTenants)
+		}
+
 		paramStr := c.Query("params")
 		params := make(map[string]interface{})
 		fmt.Println(paramStr)
@@ -62,6 +73,18 @@ func (p *xobservePlugin) Query(c *gin.Context, ds *models.Datasource) models.Plu
 	}
 }
 
+// telemetry data can be read is decided by the current tenant of the user, datasources of other tenants
+// are refused by the proxy api
+func queryTelemetryTenants(c *gin.Context) ([]string, error) {
+	u, _ := c.Get("currentUser")
+	user, _ := u.(*models.User)
+	if user == nil {
+		return nil, errors.New("please login first")
+	}
+
+	return models.QueryTelemetryTenants(c.Request.Context(), user.CurrentTenant)
+}
+
 func (p *xobservePlugin) TestDatasource(c *gin.Context) models.PluginResult {
 	return pluginUtils.TestClickhouseDatasource(c)
 }
@@ -0,0 +1,298 @@
+package provisioning
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/models"
+	"gopkg.in/yaml.v2"
+)
+
+// dashboardsConfig is the content of a dashboard provisioning file, e.g
+//
+//	providers:
+//	  - name: infra
+//	    team_id: 1
+//	    folder: Infra/Kubernetes
+//	    path: /etc/xobserve/dashboards/infra
+//	    allow_ui_updates: false
+type dashboardsConfig struct {
+	Providers []*dashboardProvider `yaml:"providers"`
+}
+
+type dashboardProvider struct {
+	// name identifies the dashboards loaded by this provider, so it shouldn't be changed
+	Name   string `yaml:"name"`
+	TeamId int64  `yaml:"team_id"`
+	// titles of folders separated by '/', relative to the root folder of team, they are created if not exist
+	Folder string `yaml:"folder"`
+	// directory of the dashboard json files, sub directories are included
+	Path           string `yaml:"path"`
+	AllowUiUpdates bool   `yaml:"allow_ui_updates"`
+}
+
+// provisionDashboards syncs the dashboards of all providers with their files
+func (p *provisioner) provisionDashboards(dir string) {
+	files, err := readConfigFiles(dir)
+	if err != nil {
+		logger.Warn("read dashboard provisioning files error", "dir", dir, "error", err)
+		return
+	}
+
+	providers := make(map[string]*dashboardProvider)
+	for _, f := range files {
+		cfg := &dashboardsConfig{}
+		err := yaml.Unmarshal(f.content, cfg)
+		if err != nil {
+			// the providers in this file are unknown, it's not safe to sync the others
+			logger.Warn("decode dashboard provisioning file error", "file", f.path, "error", err)
+			return
+		}
+
+		for _, provider := range cfg.Providers {
+			if provider.Name == "" || provider.TeamId == 0 || provider.Path == "" {
+				logger.Warn("name, team_id and path of dashboard provider are required", "file", f.path)
+				continue
+			}
+			if _, ok := providers[provider.Name]; ok {
+				logger.Warn("duplicate dashboard provider", "file", f.path, "provider", provider.Name)
+				continue
+			}
+			providers[provider.Name] = provider
+		}
+	}
+
+	ctx := context.Background()
+	records, err := models.QueryAllDashboardProvisioning(ctx)
+	if err != nil {
+		logger.Warn("query dashboard provisioning error", "error", err)
+		return
+	}
+
+	provisioned := make(map[string]*models.DashboardProvisioning)
+	for _, r := range records {
+		provisioned[r.DashboardId] = r
+	}
+
+	for _, provider := range providers {
+		err := syncProvider(ctx, provider, provisioned)
+		if err != nil {
+			logger.Warn("provision dashboards error", "provider", provider.Name, "error", err)
+		}
+	}
+
+	// dashboards of the removed providers are kept, but they are not managed by provisioning anymore
+	for _, r := range records {
+		if _, ok := providers[r.Provider]; ok {
+			continue
+		}
+		_, err := db.Conn.ExecContext(ctx, "DELETE FROM dashboard_provisioning WHERE dashboard_id=?", r.DashboardId)
+		if err != nil {
+			logger.Warn("release provisioned dashboard error", "dashboard", r.DashboardId, "error", err)
+			continue
+		}
+		logger.Info("provider removed, dashboard is released from provisioning", "provider", r.Provider, "dashboard", r.DashboardId)
+	}
+}
+
+func syncProvider(ctx context.Context, provider *dashboardProvider, provisioned map[string]*models.DashboardProvisioning) error {
+	if _, err := os.Stat(provider.Path); err != nil {
+		// a missing directory is treated as an error rather than removing all the dashboards, it may be not mounted yet
+		return fmt.Errorf("stat dashboards path error: %w", err)
+	}
+
+	root, err := models.QueryRootFolder(ctx, provider.TeamId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("team `%d` not exist", provider.TeamId)
+		}
+		return fmt.Errorf("query root folder error: %w", err)
+	}
+
+	folder, err := models.EnsureFolderPath(ctx, root, strings.Split(provider.Folder, "/"), 0)
+	if err != nil {
+		return fmt.Errorf("prepare folder error: %w", err)
+	}
+
+	// dashboards defined in the files, and the files failed to be loaded
+	seen := make(map[string]bool)
+	failedFiles := make(map[string]bool)
+	err = filepath.WalkDir(provider.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.ToLower(filepath.Ext(path)) != ".json" {
+			return nil
+		}
+
+		id, err := provisionDashboardFile(ctx, provider, folder, path, provisioned)
+		if err != nil {
+			logger.Warn("provision dashboard error", "provider", provider.Name, "file", path, "error", err)
+			failedFiles[path] = true
+			return nil
+		}
+		seen[id] = true
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk dashboards path error: %w", err)
+	}
+
+	for id, r := range provisioned {
+		if r.Provider != provider.Name || seen[id] || failedFiles[r.File] {
+			continue
+		}
+
+		err := deleteDashboard(ctx, id)
+		if err != nil {
+			logger.Warn("delete provisioned dashboard error", "provider", provider.Name, "dashboard", id, "error", err)
+			continue
+		}
+		logger.Info("dashboard file removed, provisioned dashboard is deleted", "provider", provider.Name, "dashboard", id, "file", r.File)
+	}
+
+	return nil
+}
+
+// provisionDashboardFile saves the dashboard in the file if it's new or changed, and returns its id
+func provisionDashboardFile(ctx context.Context, provider *dashboardProvider, folder *models.Folder, path string, provisioned map[string]*models.DashboardProvisioning) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	dash := &models.Dashboard{}
+	err = json.Unmarshal(data, dash)
+	if err != nil {
+		return "", fmt.Errorf("decode dashboard json error: %w", err)
+	}
+	if dash.Title == "" || dash.Data == nil {
+		return "", fmt.Errorf("title and data of dashboard are required")
+	}
+
+	err = models.CheckDashboardData(dash.Data)
+	if err != nil {
+		return "", err
+	}
+
+	if dash.Id == "" {
+		// the id must be stable between restarts, so it's generated from the file path
+		rel, _ := filepath.Rel(provider.Path, path)
+		sum := sha1.Sum([]byte(provider.Name + "/" + filepath.ToSlash(rel)))
+		dash.Id = models.DashboardIdPrefix + hex.EncodeToString(sum[:])[:16]
+	}
+
+	sum := checksum(data)
+	old, ok := provisioned[dash.Id]
+	if ok {
+		if old.Provider != provider.Name {
+			return "", fmt.Errorf("dashboard `%s` is already provisioned by provider `%s`", dash.Id, old.Provider)
+		}
+		// folder is checked too, because it may be changed in the provider config
+		if old.Checksum == sum && old.File == path && old.AllowUiUpdates == provider.AllowUiUpdates {
+			_, folderId, err := models.QueryDashboardFolder(ctx, dash.Id)
+			if err == nil && folderId == folder.Id {
+				return dash.Id, nil
+			}
+		}
+	}
+
+	err = saveDashboard(ctx, provider, folder, dash, path, sum, ok)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("dashboard provisioned", "provider", provider.Name, "dashboard", dash.Id, "file", path)
+	return dash.Id, nil
+}
+
+func saveDashboard(ctx context.Context, provider *dashboardProvider, folder *models.Folder, dash *models.Dashboard, path string, sum string, isProvisioned bool) error {
+	jsonData, err := dash.Data.Encode()
+	if err != nil {
+		return err
+	}
+
+	tags, err := json.Marshal(dash.Tags)
+	if err != nil {
+		return err
+	}
+
+	if dash.VisibleTo == "" {
+		dash.VisibleTo = models.TeamVisible
+	}
+
+	_, _, err = models.QueryDashboardFolder(ctx, dash.Id)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	exist := err == nil
+	if exist && !isProvisioned {
+		return fmt.Errorf("dashboard id `%s` is used by a dashboard created in ui", dash.Id)
+	}
+
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if exist {
+		_, err = tx.ExecContext(ctx, `UPDATE dashboard SET title=?,tags=?,data=?,team_id=?,folder_id=?,visible_to=?,updated=?,version=version+1,updated_by=? WHERE id=?`,
+			dash.Title, tags, jsonData, provider.TeamId, folder.Id, dash.VisibleTo, now, 0, dash.Id)
+	} else {
+		_, err = tx.ExecContext(ctx, `INSERT INTO dashboard (id,title, team_id,folder_id,visible_to, created_by,tags, data,created,updated,version,updated_by) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`,
+			dash.Id, dash.Title, provider.TeamId, folder.Id, dash.VisibleTo, 0, tags, jsonData, now, now, 1, 0)
+	}
+	if err != nil {
+		return fmt.Errorf("save dashboard error: %w", err)
+	}
+
+	err = models.UpdateLibraryPanelConnections(ctx, tx, dash.Id, dash.Data)
+	if err != nil {
+		return fmt.Errorf("save library panel connections error: %w", err)
+	}
+
+	err = models.UpdateDashboardSearch(ctx, tx, dash)
+	if err != nil {
+		return fmt.Errorf("save dashboard search index error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM dashboard_provisioning WHERE dashboard_id=?", dash.Id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO dashboard_provisioning (dashboard_id,provider,file,checksum,allow_ui_updates,updated) VALUES (?,?,?,?,?,?)",
+		dash.Id, provider.Name, path, sum, provider.AllowUiUpdates, now)
+	if err != nil {
+		return fmt.Errorf("save dashboard provisioning error: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func deleteDashboard(ctx context.Context, id string) error {
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = models.DeleteDashboard(ctx, id, tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,116 @@
+package provisioning
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"gopkg.in/yaml.v2"
+)
+
+// datasourcesConfig is the content of a datasource provisioning file, e.g
+//
+//	datasources:
+//	  - name: prometheus
+//	    type: prometheus
+//	    url: http://localhost:9090
+//	    team_id: 1
+//	    data:
+//	      password: ${PROM_PASSWORD}
+//	delete_datasources:
+//	  - name: old-prometheus
+//	    team_id: 1
+type datasourcesConfig struct {
+	Datasources       []*datasourceConfig `yaml:"datasources"`
+	DeleteDatasources []*datasourceConfig `yaml:"delete_datasources"`
+}
+
+type datasourceConfig struct {
+	Name   string            `yaml:"name"`
+	Type   string            `yaml:"type"`
+	URL    string            `yaml:"url"`
+	TeamId int64             `yaml:"team_id"`
+	Data   map[string]string `yaml:"data"`
+}
+
+// provisionDatasources applies the datasource files which are new or changed since last time
+func (p *provisioner) provisionDatasources(dir string) {
+	files, err := readConfigFiles(dir)
+	if err != nil {
+		logger.Warn("read datasource provisioning files error", "dir", dir, "error", err)
+		return
+	}
+
+	for _, f := range files {
+		if p.datasourceChecksums[f.path] == f.checksum {
+			continue
+		}
+
+		err := applyDatasourceFile(f)
+		if err != nil {
+			logger.Warn("provision datasources error", "file", f.path, "error", err)
+			continue
+		}
+
+		p.datasourceChecksums[f.path] = f.checksum
+		logger.Info("datasources provisioned", "file", f.path)
+	}
+}
+
+func applyDatasourceFile(f *configFile) error {
+	cfg := &datasourcesConfig{}
+	err := yaml.Unmarshal(f.content, cfg)
+	if err != nil {
+		return fmt.Errorf("decode yaml error: %w", err)
+	}
+
+	for _, ds := range cfg.Datasources {
+		if ds.Name == "" || ds.Type == "" || ds.TeamId == 0 {
+			return fmt.Errorf("name, type and team_id of datasource are required")
+		}
+	}
+
+	ctx := context.Background()
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, ds := range cfg.DeleteDatasources {
+		_, err = tx.ExecContext(ctx, "DELETE FROM datasource WHERE team_id=? AND name=?", ds.TeamId, ds.Name)
+		if err != nil {
+			return fmt.Errorf("delete datasource `%s` error: %w", ds.Name, err)
+		}
+	}
+
+	now := time.Now()
+	for _, ds := range cfg.Datasources {
+		data, err := json.Marshal(ds.Data)
+		if err != nil {
+			return err
+		}
+
+		var id int64
+		err = tx.QueryRowContext(ctx, "SELECT id FROM datasource WHERE team_id=? AND name=?", ds.TeamId, ds.Name).Scan(&id)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("query datasource `%s` error: %w", ds.Name, err)
+		}
+
+		if id == 0 {
+			_, err = tx.ExecContext(ctx, "INSERT INTO datasource (name,type,url,team_id,data,provisioned,created,updated) VALUES (?,?,?,?,?,?,?,?)",
+				ds.Name, ds.Type, ds.URL, ds.TeamId, data, true, now, now)
+		} else {
+			_, err = tx.ExecContext(ctx, "UPDATE datasource SET type=?,url=?,data=?,provisioned=?,updated=? WHERE id=?",
+				ds.Type, ds.URL, data, true, now, id)
+		}
+		if err != nil {
+			return fmt.Errorf("save datasource `%s` error: %w", ds.Name, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 xObserve.io Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package provisioning loads datasources and dashboards from files, so they can be managed as code.
+//
+// The provisioning directory is configured with `provisioning.path`, it contains two sub directories:
+//   - datasources: yaml files defining datasources, environment variables like ${PROM_PASSWORD} are expanded
+//   - dashboards: yaml files defining dashboard providers, each provider loads the dashboard json files in a directory
+//
+// The files are checked periodically, changed files are applied again, and the dashboards whose files are removed will be deleted.
+package provisioning
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/colorlog"
+	"github.com/xObserve/xObserve/query/pkg/config"
+)
+
+var logger = colorlog.RootLogger.New("logger", "provisioning")
+
+const defaultPollInterval = 10
+
+func Init() {
+	dir := config.Data.Provisioning.Path
+	if dir == "" {
+		return
+	}
+
+	interval := config.Data.Provisioning.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	logger.Info("start provisioning", "path", dir)
+	p := &provisioner{
+		datasourceChecksums: make(map[string]string),
+	}
+	for {
+		p.provisionDatasources(filepath.Join(dir, "datasources"))
+		p.provisionDashboards(filepath.Join(dir, "dashboards"))
+		time.Sleep(time.Duration(interval) * time.Second)
+	}
+}
+
+type provisioner struct {
+	// checksums of the datasource files which have been applied
+	datasourceChecksums map[string]string
+}
+
+type configFile struct {
+	path     string
+	checksum string
+	// content with environment variables expanded
+	content []byte
+}
+
+// readConfigFiles reads the yaml files in dir, sorted by name
+func readConfigFiles(dir string) ([]*configFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	files := make([]*configFile, 0)
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.Warn("read provisioning file error", "file", path, "error", err)
+			continue
+		}
+
+		content := []byte(os.ExpandEnv(string(data)))
+		files = append(files, &configFile{
+			path:     path,
+			checksum: checksum(content),
+			content:  content,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+
+	return files, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -14,6 +14,9 @@ package proxy
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
@@ -46,6 +49,13 @@ func ProxyDatasource(c *gin.Context) {
 		return
 	}
 
+	u := c.MustGet("currentUser").(*models.User)
+	err = checkDatasourceTenant(c.Request.Context(), ds, u)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
 	queryPlugin := models.GetPlugin(ds.Type)
 	if queryPlugin != nil {
 		result := queryPlugin.Query(c, ds)
@@ -104,6 +114,32 @@ func ProxyDatasource(c *gin.Context) {
 	c.String(res.StatusCode, buffer.String())
 }
 
+// checkDatasourceTenant only allows users to query the datasources of their current tenant
+func checkDatasourceTenant(ctx context.Context, ds *models.Datasource, u *models.User) error {
+	tenantId := int64(models.DefaultTenantId)
+	if ds.TeamId != 0 {
+		var err error
+		tenantId, err = models.QueryTenantIdByTeamId(ctx, ds.TeamId)
+		if err != nil {
+			return fmt.Errorf("query tenant of datasource error: %w", err)
+		}
+	}
+
+	if tenantId != u.CurrentTenant {
+		return errors.New("datasource doesn't belong to current tenant")
+	}
+
+	in, err := models.IsUserInTenant(u.Id, tenantId)
+	if err != nil {
+		return fmt.Errorf("check user in tenant error: %w", err)
+	}
+	if !in {
+		return errors.New("you are not in the tenant of datasource")
+	}
+
+	return nil
+}
+
 func TestDatasource(c *gin.Context) {
 	dsType := c.Query("type")
 	queryPlugin := models.GetPlugin(dsType)
@@ -0,0 +1,281 @@
+package savedquery
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
+	"github.com/xObserve/xObserve/query/pkg/colorlog"
+	"github.com/xObserve/xObserve/query/pkg/common"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+var logger = colorlog.RootLogger.New("logger", "savedquery")
+
+func AddNewSavedQuery(c *gin.Context) {
+	q := &models.SavedQuery{}
+	err := c.Bind(&q)
+	if err != nil {
+		logger.Warn("bind saved query error", "error", err)
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	if msg := validateSavedQuery(q); msg != "" {
+		c.JSON(http.StatusBadRequest, common.RespError(msg))
+		return
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	// saved queries are used in team dashboards, so only team members can save queries in it
+	err = acl.CanViewTeam(c.Request.Context(), q.TeamId, u.Id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	params, tags, err := encodeSavedQuery(q)
+	if err != nil {
+		logger.Warn("encode saved query error", "error", err)
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	now := time.Now()
+	res, err := db.Conn.ExecContext(c.Request.Context(), "INSERT INTO saved_query (name,description,ds_type,scope,query,params,tags,owner_id,team_id,visible_to,created,updated) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)",
+		q.Name, q.Description, q.DatasourceType, q.Scope, q.Query, params, tags, u.Id, q.TeamId, q.VisibleTo, now, now)
+	if err != nil {
+		if e.IsErrUniqueConstraint(err) {
+			c.JSON(http.StatusBadRequest, common.RespError("saved query name already exists"))
+			return
+		}
+		logger.Warn("insert saved query error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	q.Id, _ = res.LastInsertId()
+	q.OwnerId = u.Id
+	q.Created = now
+	q.Updated = now
+
+	c.JSON(http.StatusOK, common.RespSuccess(q))
+}
+
+func UpdateSavedQuery(c *gin.Context) {
+	q := &models.SavedQuery{}
+	err := c.Bind(&q)
+	if err != nil {
+		logger.Warn("bind saved query error", "error", err)
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	if msg := validateSavedQuery(q); msg != "" {
+		c.JSON(http.StatusBadRequest, common.RespError(msg))
+		return
+	}
+
+	old, ok := getEditableSavedQuery(c, q.Id)
+	if !ok {
+		return
+	}
+
+	params, tags, err := encodeSavedQuery(q)
+	if err != nil {
+		logger.Warn("encode saved query error", "error", err)
+		c.JSON(http.StatusBadRequest, common.RespError(e.ParamInvalid))
+		return
+	}
+
+	// saved query can't be moved to another team
+	_, err = db.Conn.ExecContext(c.Request.Context(), "UPDATE saved_query SET name=?,description=?,ds_type=?,scope=?,query=?,params=?,tags=?,visible_to=?,updated=? WHERE id=?",
+		q.Name, q.Description, q.DatasourceType, q.Scope, q.Query, params, tags, q.VisibleTo, time.Now(), old.Id)
+	if err != nil {
+		if e.IsErrUniqueConstraint(err) {
+			c.JSON(http.StatusBadRequest, common.RespError("saved query name already exists"))
+			return
+		}
+		logger.Warn("update saved query error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+func DeleteSavedQuery(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	q, ok := getEditableSavedQuery(c, id)
+	if !ok {
+		return
+	}
+
+	_, err := db.Conn.ExecContext(c.Request.Context(), "DELETE FROM saved_query WHERE id=?", q.Id)
+	if err != nil {
+		logger.Warn("delete saved query error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+// GetSavedQuery is used when a saved query is opened by its shared url
+func GetSavedQuery(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	q, ok := getViewableSavedQuery(c, id)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(q))
+}
+
+func QueryTeamSavedQueries(c *gin.Context) {
+	teamId, _ := strconv.ParseInt(c.Param("teamId"), 10, 64)
+	u := c.MustGet("currentUser").(*models.User)
+
+	err := acl.CanViewTeam(c.Request.Context(), teamId, u.Id)
+	if err != nil {
+		c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+		return
+	}
+
+	queries, err := models.QueryTeamSavedQueries(c.Request.Context(), teamId, u.Id, c.Query("scope"))
+	if err != nil {
+		logger.Warn("query saved queries error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	dsType := c.Query("datasourceType")
+	tag := c.Query("tag")
+	res := make([]*models.SavedQuery, 0, len(queries))
+	for _, q := range queries {
+		if dsType != "" && q.DatasourceType != dsType {
+			continue
+		}
+		if tag != "" && !containsTag(q.Tags, tag) {
+			continue
+		}
+		res = append(res, q)
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(res))
+}
+
+// MarkSavedQueryUsed is called when a saved query is applied in the explorers, so the recently used queries can be listed first
+func MarkSavedQueryUsed(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	q, ok := getViewableSavedQuery(c, id)
+	if !ok {
+		return
+	}
+
+	_, err := db.Conn.ExecContext(c.Request.Context(), "UPDATE saved_query SET use_count=use_count+1,last_used=? WHERE id=?", time.Now(), q.Id)
+	if err != nil {
+		logger.Warn("update saved query last used error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.RespSuccess(nil))
+}
+
+func getViewableSavedQuery(c *gin.Context, id int64) (*models.SavedQuery, bool) {
+	q, err := models.QuerySavedQuery(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, common.RespError("saved query not found"))
+			return nil, false
+		}
+		logger.Warn("query saved query error", "error", err)
+		c.JSON(http.StatusInternalServerError, common.RespError(e.Internal))
+		return nil, false
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	if !q.CanView(c.Request.Context(), u.Id) {
+		c.JSON(http.StatusForbidden, common.RespError(e.NoPermission))
+		return nil, false
+	}
+
+	return q, true
+}
+
+// only the owner and team admin can edit a saved query
+func getEditableSavedQuery(c *gin.Context, id int64) (*models.SavedQuery, bool) {
+	q, ok := getViewableSavedQuery(c, id)
+	if !ok {
+		return nil, false
+	}
+
+	u := c.MustGet("currentUser").(*models.User)
+	if q.OwnerId != u.Id {
+		err := acl.CanEditTeam(c.Request.Context(), q.TeamId, u.Id)
+		if err != nil {
+			c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+			return nil, false
+		}
+	}
+
+	return q, true
+}
+
+func validateSavedQuery(q *models.SavedQuery) string {
+	q.Name = strings.TrimSpace(q.Name)
+	if q.Name == "" {
+		return "saved query name can not be empty"
+	}
+	if len(q.Name) > 255 {
+		return "saved query name is too long"
+	}
+
+	if q.DatasourceType == "" || q.Scope == "" {
+		return "datasource type and scope can not be empty"
+	}
+
+	if q.VisibleTo == "" {
+		q.VisibleTo = models.SavedQueryVisibleToOwner
+	}
+	if q.VisibleTo != models.SavedQueryVisibleToOwner && q.VisibleTo != models.SavedQueryVisibleToTeam {
+		return "invalid saved query visibility"
+	}
+
+	return ""
+}
+
+func encodeSavedQuery(q *models.SavedQuery) ([]byte, []byte, error) {
+	params, err := json.Marshal(q.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if q.Tags == nil {
+		q.Tags = make([]string, 0)
+	}
+	tags, err := json.Marshal(q.Tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return params, tags, nil
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -27,10 +27,14 @@ import (
 	"github.com/xObserve/xObserve/query/internal/cache"
 	"github.com/xObserve/xObserve/query/internal/dashboard"
 	"github.com/xObserve/xObserve/query/internal/datasource"
+	"github.com/xObserve/xObserve/query/internal/folder"
+	"github.com/xObserve/xObserve/query/internal/librarypanel"
 	ot "github.com/xObserve/xObserve/query/internal/opentelemetry"
 	_ "github.com/xObserve/xObserve/query/internal/plugins/builtin"
 	_ "github.com/xObserve/xObserve/query/internal/plugins/external"
+	"github.com/xObserve/xObserve/query/internal/provisioning"
 	"github.com/xObserve/xObserve/query/internal/proxy"
+	"github.com/xObserve/xObserve/query/internal/savedquery"
 	"github.com/xObserve/xObserve/query/internal/storage"
 	"github.com/xObserve/xObserve/query/internal/task"
 	"github.com/xObserve/xObserve/query/internal/teams"
@@ -80,6 +84,7 @@ func (s *Server) Start() error {
 	}
 
 	go dashboard.InitHistory()
+	go provisioning.Init()
 
 	go task.Init()
 	go cache.Init()
@@ -119,6 +124,10 @@ func (s *Server) Start() error {
 		r.POST("/dashboard/save", MustLogin(), otelPlugin, dashboard.SaveDashboard)
 		r.GET("/dashboard/team/:id", CheckLogin(), dashboard.GetTeamDashboards)
 		r.GET("/dashboard/history/:id", CheckLogin(), otelPlugin, dashboard.GetHistory)
+		r.GET("/dashboard/versions/:id", MustLogin(), dashboard.GetVersions)
+		r.GET("/dashboard/version/:id/:version", MustLogin(), dashboard.GetVersion)
+		r.GET("/dashboard/diff/:id", MustLogin(), dashboard.DiffVersions)
+		r.POST("/dashboard/restore/:id/:version", MustLogin(), dashboard.RestoreVersion)
 		r.GET("/dashboard/search/:tenantId", CheckLogin(), otelPlugin, dashboard.Search)
 		r.POST("/dashboard/star/:id", MustLogin(), dashboard.Star)
 		r.POST("/dashboard/unstar/:id", MustLogin(), dashboard.UnStar)
@@ -126,13 +135,46 @@ func (s *Server) Start() error {
 		r.GET("/dashboard/starred/:id", dashboard.GetStarred)
 		r.DELETE("/dashboard/:id", MustLogin(), dashboard.Delete)
 		r.POST("/dashboard/weight", MustLogin(), dashboard.UpdateWeight)
+		r.POST("/dashboard/move", MustLogin(), dashboard.Move)
+		r.GET("/dashboard/export", MustLogin(), dashboard.Export)
+		r.POST("/dashboard/import", MustLogin(), dashboard.Import)
+		r.GET("/dashboard/acl/:id", MustLogin(), dashboard.GetAcl)
+		r.POST("/dashboard/acl/:id", MustLogin(), dashboard.UpdateAcl)
+		r.POST("/libraryPanel/new", MustLogin(), librarypanel.AddLibraryPanel)
+		r.POST("/libraryPanel/update", MustLogin(), librarypanel.UpdateLibraryPanel)
+		r.DELETE("/libraryPanel/:id", MustLogin(), librarypanel.DeleteLibraryPanel)
+		r.GET("/libraryPanel/byId/:id", MustLogin(), librarypanel.GetLibraryPanel)
+		r.GET("/libraryPanel/team/:teamId", MustLogin(), librarypanel.QueryTeamLibraryPanels)
+		r.GET("/libraryPanel/connections/:id", MustLogin(), librarypanel.GetLibraryPanelConnections)
+		r.POST("/snapshot", MustLogin(), dashboard.CreateSnapshot)
+		r.GET("/snapshot/dashboard/:id", MustLogin(), dashboard.GetSnapshots)
+		r.DELETE("/snapshot/:key", MustLogin(), dashboard.DeleteSnapshot)
+		// snapshots are public, anyone who knows the key can view it
+		r.GET("/snapshot/:key", dashboard.GetSnapshot)
+
+		// folder apis
+		r.GET("/folder/team/:teamId", MustLogin(), folder.GetTeamFolders)
+		r.GET("/folder/byId/:id", MustLogin(), folder.GetFolder)
+		r.POST("/folder/new", MustLogin(), folder.AddNewFolder)
+		r.POST("/folder/update", MustLogin(), folder.UpdateFolder)
+		r.DELETE("/folder/:id", MustLogin(), folder.DeleteFolder)
+		r.GET("/folder/permissions/:id", MustLogin(), folder.GetFolderPermissions)
+		r.POST("/folder/permissions/:id", MustLogin(), folder.UpdateFolderPermissions)
 
 		// annotation
 		r.POST("/annotation", MustLogin(), annotation.SetAnnotation)
-		r.GET("/annotation/:namespace", annotation.QueryNamespaceAnnotations)
+		r.GET("/annotation/:namespace", CheckLogin(), annotation.QueryNamespaceAnnotations)
 		r.DELETE("/annotation/:namespace/:id", MustLogin(), annotation.RemoveAnnotation)
 		r.DELETE("/annotation/group/:namespace/:group/:expires", MustLogin(), annotation.RemoveGroupAnnotations)
 
+		// saved query apis
+		r.POST("/savedQuery/new", MustLogin(), savedquery.AddNewSavedQuery)
+		r.POST("/savedQuery/update", MustLogin(), savedquery.UpdateSavedQuery)
+		r.DELETE("/savedQuery/:id", MustLogin(), savedquery.DeleteSavedQuery)
+		r.GET("/savedQuery/byId/:id", MustLogin(), savedquery.GetSavedQuery)
+		r.GET("/savedQuery/team/:teamId", MustLogin(), savedquery.QueryTeamSavedQueries)
+		r.POST("/savedQuery/used/:id", MustLogin(), savedquery.MarkSavedQueryUsed)
+
 		// admin apis
 		r.GET("/admin/users", CheckLogin(), otelPlugin, admin.GetUsers)
 		r.POST("/admin/user", MustLogin(), admin.UpdateUser)
@@ -142,6 +184,7 @@ func (s *Server) Start() error {
 		r.DELETE("/admin/user/:id", MustLogin(), admin.MarkUserAsDeleted)
 		r.POST("/admin/user/restore/:id", MustLogin(), admin.RestoreUser)
 		r.GET("/admin/auditlogs", CheckLogin(), admin.QueryAuditLogs)
+		r.GET("/admin/usage/:datasourceId", MustLogin(), admin.QueryUsage)
 
 		// datasource apis
 		r.POST("/datasource/create", MustLogin(), datasource.CreateDatasource)
@@ -185,8 +228,8 @@ func (s *Server) Start() error {
 		r.POST("/team/leave/:id", MustLogin(), teams.LeaveTeam)
 
 		// proxy apis
-		r.Any("/proxy/:id/*path", proxy.ProxyDatasource)
-		r.Any("/proxy/:id", proxy.ProxyDatasource)
+		r.Any("/proxy/:id/*path", MustLogin(), proxy.ProxyDatasource)
+		r.Any("/proxy/:id", MustLogin(), proxy.ProxyDatasource)
 
 		r.GET("/common/proxy/:panelId", proxy.Proxy)
 
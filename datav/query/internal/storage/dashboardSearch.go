@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/xObserve/xObserve/query/pkg/config"
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
+// the full-text index of SQLite is an external content FTS5 table, it's kept in sync with dashboard_search by triggers
+const sqliteDashboardFTS = `
+CREATE VIRTUAL TABLE IF NOT EXISTS dashboard_fts USING fts5(title, tags, panels, descriptions, queries, content='dashboard_search');
+
+CREATE TRIGGER IF NOT EXISTS dashboard_search_ai AFTER INSERT ON dashboard_search BEGIN
+    INSERT INTO dashboard_fts (rowid, title, tags, panels, descriptions, queries) VALUES (new.rowid, new.title, new.tags, new.panels, new.descriptions, new.queries);
+END;
+
+CREATE TRIGGER IF NOT EXISTS dashboard_search_ad AFTER DELETE ON dashboard_search BEGIN
+    INSERT INTO dashboard_fts (dashboard_fts, rowid, title, tags, panels, descriptions, queries) VALUES ('delete', old.rowid, old.title, old.tags, old.panels, old.descriptions, old.queries);
+END;
+
+CREATE TRIGGER IF NOT EXISTS dashboard_search_au AFTER UPDATE ON dashboard_search BEGIN
+    INSERT INTO dashboard_fts (dashboard_fts, rowid, title, tags, panels, descriptions, queries) VALUES ('delete', old.rowid, old.title, old.tags, old.panels, old.descriptions, old.queries);
+    INSERT INTO dashboard_fts (rowid, title, tags, panels, descriptions, queries) VALUES (new.rowid, new.title, new.tags, new.panels, new.descriptions, new.queries);
+END;
+`
+
+const sqliteDropDashboardFTSTriggers = `
+DROP TRIGGER IF EXISTS dashboard_search_ai;
+DROP TRIGGER IF EXISTS dashboard_search_ad;
+DROP TRIGGER IF EXISTS dashboard_search_au;
+`
+
+// initDashboardSearch decides the engine used to search dashboards, and indexes the dashboards
+// created before dashboard search is supported
+func initDashboardSearch() error {
+	if config.Data.Database.ConnectTo == "mysql" {
+		models.DashboardSearchEngine = models.SearchEngineFulltext
+	} else {
+		// FTS5 is only available when go-sqlite3 is built with the `sqlite_fts5` tag
+		var fts5 bool
+		err := db.Conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5)
+		if err != nil {
+			return err
+		}
+
+		if fts5 {
+			_, err = db.Conn.Exec(sqliteDashboardFTS)
+			if err != nil {
+				return err
+			}
+			models.DashboardSearchEngine = models.SearchEngineFTS5
+		} else {
+			logger.Warn("SQLite FTS5 is not available, dashboards will be searched with LIKE queries")
+			// triggers created by previous runs would break the writes of dashboard_search
+			_, err = db.Conn.Exec(sqliteDropDashboardFTSTriggers)
+			if err != nil {
+				return err
+			}
+			models.DashboardSearchEngine = models.SearchEngineLike
+		}
+	}
+
+	err := indexDashboards()
+	if err != nil {
+		return err
+	}
+
+	if models.DashboardSearchEngine == models.SearchEngineFTS5 {
+		// dashboard_search may be written by a previous run without FTS5
+		_, err = db.Conn.Exec("INSERT INTO dashboard_fts (dashboard_fts) VALUES ('rebuild')")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexDashboards indexes the dashboards which are not in dashboard_search
+func indexDashboards() error {
+	rows, err := db.Conn.Query("SELECT id,title,tags,data FROM dashboard WHERE id NOT IN (SELECT dashboard_id FROM dashboard_search)")
+	if err != nil {
+		return err
+	}
+
+	type dashboard struct {
+		id    string
+		title string
+		tags  []byte
+		data  []byte
+	}
+	dashboards := make([]*dashboard, 0)
+	for rows.Next() {
+		dash := &dashboard{}
+		err = rows.Scan(&dash.id, &dash.title, &dash.tags, &dash.data)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		dashboards = append(dashboards, dash)
+	}
+	rows.Close()
+
+	for _, dash := range dashboards {
+		err = models.UpdateDashboardSearchFromRaw(context.Background(), db.Conn, dash.id, dash.title, dash.tags, dash.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(dashboards) > 0 {
+		logger.Info("dashboards indexed for searching", "count", len(dashboards))
+	}
+
+	return nil
+}
@@ -65,6 +65,11 @@ func Init(tc *sdktrace.TracerProvider) error {
 		return err
 	}
 
+	err = initDashboardSearch()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -1,7 +1,77 @@
 package storage
 
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/e"
+	"github.com/xObserve/xObserve/query/pkg/models"
+)
+
 /* update table structure to current xobserve version */
 func update() error {
+	var author int64
+	err := db.Conn.QueryRow("SELECT author FROM dashboard_history limit 1").Scan(&author)
+	if err != nil && e.IsErrNoColumn(err) {
+		_, err = db.Conn.Exec("ALTER TABLE dashboard_history ADD COLUMN author INTEGER DEFAULT 0")
+		if err != nil {
+			return errors.New("update storage error:" + err.Error())
+		}
+	}
+
+	var version int64
+	err = db.Conn.QueryRow("SELECT version FROM dashboard limit 1").Scan(&version)
+	if err != nil && e.IsErrNoColumn(err) {
+		_, err = db.Conn.Exec("ALTER TABLE dashboard ADD COLUMN version INTEGER DEFAULT 1")
+		if err != nil {
+			return errors.New("update storage error:" + err.Error())
+		}
+		_, err = db.Conn.Exec("ALTER TABLE dashboard ADD COLUMN updated_by INTEGER DEFAULT 0")
+		if err != nil {
+			return errors.New("update storage error:" + err.Error())
+		}
+	}
+
+	var folderId int64
+	err = db.Conn.QueryRow("SELECT folder_id FROM dashboard limit 1").Scan(&folderId)
+	if err != nil && e.IsErrNoColumn(err) {
+		_, err = db.Conn.Exec("ALTER TABLE dashboard ADD COLUMN folder_id INTEGER DEFAULT 0")
+		if err != nil {
+			return errors.New("update storage error:" + err.Error())
+		}
+	}
+
+	var provisioned bool
+	err = db.Conn.QueryRow("SELECT provisioned FROM datasource limit 1").Scan(&provisioned)
+	if err != nil && e.IsErrNoColumn(err) {
+		_, err = db.Conn.Exec("ALTER TABLE datasource ADD COLUMN provisioned BOOL DEFAULT false")
+		if err != nil {
+			return errors.New("update storage error:" + err.Error())
+		}
+	}
+
+	var dashboardVersion int64
+	err = db.Conn.QueryRow("SELECT dashboard_version FROM dashboard_history limit 1").Scan(&dashboardVersion)
+	if err != nil && e.IsErrNoColumn(err) {
+		_, err = db.Conn.Exec("ALTER TABLE dashboard_history ADD COLUMN dashboard_version INTEGER DEFAULT 0")
+		if err != nil {
+			return errors.New("update storage error:" + err.Error())
+		}
+	}
+
+	err = numberDashboardHistories()
+	if err != nil {
+		return errors.New("number dashboard histories error:" + err.Error())
+	}
+
+	err = moveDashboardsToRootFolder()
+	if err != nil {
+		return errors.New("move dashboards to root folder error:" + err.Error())
+	}
+
 	// var isPublic bool
 	// err := db.Conn.QueryRow("SELECT is_public FROM tenant limit 1").Scan(&isPublic)
 	// if err != nil && e.IsErrNoColumn(err) {
@@ -13,3 +83,138 @@ func update() error {
 
 	return nil
 }
+
+// moveDashboardsToRootFolder creates root folders for the teams created before folders are supported,
+// and moves the dashboards which are not in any folder to the root folder of their teams.
+// Only the teams without root folder are handled, so it can be run again when it fails in the middle
+func moveDashboardsToRootFolder() error {
+	var folders int64
+	err := db.Conn.QueryRow("SELECT count(1) FROM folder").Scan(&folders)
+	if err != nil {
+		if e.IsErrNoTable(err) {
+			// tables are not created automatically in mysql
+			logger.Warn("folder table not found, please create the folder tables in xobserve.sql, dashboards will be moved to root folders after restarting")
+			return nil
+		}
+		return err
+	}
+
+	ctx := context.Background()
+	rows, err := db.Conn.Query("SELECT id,created_by FROM team WHERE id NOT IN (SELECT team_id FROM folder WHERE parent_id=0)")
+	if err != nil {
+		return err
+	}
+	teams := make(map[int64]int64)
+	for rows.Next() {
+		var teamId, createdBy int64
+		err := rows.Scan(&teamId, &createdBy)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		teams[teamId] = createdBy
+	}
+	rows.Close()
+
+	for teamId, createdBy := range teams {
+		tx, err := db.Conn.Begin()
+		if err != nil {
+			return err
+		}
+
+		_, err = models.CreateRootFolder(ctx, tx, teamId, createdBy)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Conn.Exec("UPDATE dashboard SET folder_id=(SELECT id FROM folder WHERE folder.team_id=dashboard.team_id AND folder.parent_id=0) WHERE folder_id=0 AND team_id IN (SELECT team_id FROM folder WHERE parent_id=0)")
+	return err
+}
+
+// numberDashboardHistories sets the dashboard version of the histories saved before it's stored in dashboard_history.
+// Every save increases the version and writes a history, so the histories of a dashboard are numbered backwards from
+// its current version, ordered by the time they are saved. When there are more histories than the current version,
+// the dashboard version is raised, so the versions of later saves never collide with the numbered ones.
+// Each dashboard is numbered in a transaction, so it can be resumed after failure
+func numberDashboardHistories() error {
+	rows, err := db.Conn.Query("SELECT DISTINCT dashboard_id FROM dashboard_history WHERE dashboard_version=0")
+	if err != nil {
+		return err
+	}
+	dashIds := make([]string, 0)
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		dashIds = append(dashIds, id)
+	}
+	rows.Close()
+
+	for _, id := range dashIds {
+		err := numberHistoriesOfDashboard(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func numberHistoriesOfDashboard(dashId string) error {
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current int64
+	err = tx.QueryRow("SELECT version FROM dashboard WHERE id=?", dashId).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT version FROM dashboard_history WHERE dashboard_id=? ORDER BY version DESC", dashId)
+	if err != nil {
+		return err
+	}
+	times := make([]time.Time, 0)
+	for rows.Next() {
+		var t time.Time
+		err := rows.Scan(&t)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		times = append(times, t)
+	}
+	rows.Close()
+
+	version := current
+	if int64(len(times)) > version {
+		version = int64(len(times))
+		_, err = tx.Exec("UPDATE dashboard SET version=? WHERE id=?", version, dashId)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range times {
+		_, err = tx.Exec("UPDATE dashboard_history SET dashboard_version=? WHERE dashboard_id=? AND version=?", version, dashId, t)
+		if err != nil {
+			return err
+		}
+		version--
+	}
+
+	return tx.Commit()
+}
@@ -30,6 +30,15 @@ func Init() {
 				logger.Info("Task: remove annotations", "count", n)
 			}
 
+			// delete expired snapshots
+			res, err = db.Conn.Exec("DELETE FROM snapshot WHERE expires IS NOT NULL AND expires < ?", now)
+			if err != nil {
+				logger.Error("task: clean snapshots", "error", err)
+			} else {
+				n, _ := res.RowsAffected()
+				logger.Info("Task: remove expired snapshots", "count", n)
+			}
+
 			expires = now.Add(-1 * time.Duration(config.Data.Task.DeleteAfterDays) * time.Second * 24)
 
 			// delete tenants
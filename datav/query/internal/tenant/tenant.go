@@ -3,11 +3,13 @@ package tenant
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -83,7 +85,14 @@ func CreateTenant(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	res, err := tx.ExecContext(c.Request.Context(), `INSERT INTO tenant (name,created,updated) VALUES (?,?,?)`, req.Name, now, now)
+	// telemetry tenants are stored explicitly, so renaming tenant won't change the telemetry data it can read
+	tenantData := &models.TenantData{TelemetryTenants: models.NormalizeTelemetryTenants(req.TelemetryTenants)}
+	if len(tenantData.TelemetryTenants) == 0 {
+		tenantData.TelemetryTenants = []string{req.Name}
+	}
+	data, _ := json.Marshal(tenantData)
+
+	res, err := tx.ExecContext(c.Request.Context(), `INSERT INTO tenant (name,data,created,updated) VALUES (?,?,?,?)`, req.Name, data, now, now)
 	if err != nil {
 		if e.IsErrUniqueConstraint(err) {
 			c.JSON(400, common.RespError("tenant already exist"))
@@ -460,8 +469,30 @@ func UpdateTenant(c *gin.Context) {
 		return
 	}
 
+	oldTelemetryTenants, err := models.QueryTelemetryTenants(c.Request.Context(), req.Id)
+	if err != nil {
+		logger.Warn("query telemetry tenants error", "error", err)
+		c.JSON(500, common.RespInternalError())
+		return
+	}
+
+	telemetryTenants := models.NormalizeTelemetryTenants(req.TelemetryTenants)
+	if len(telemetryTenants) == 0 {
+		telemetryTenants = oldTelemetryTenants
+	}
+
+	// telemetry tenants decide which customer's data can be read, only website admin can change them
+	if strings.Join(telemetryTenants, ",") != strings.Join(oldTelemetryTenants, ",") {
+		if err := acl.CanEditWebsite(u); err != nil {
+			c.JSON(http.StatusForbidden, common.RespError(err.Error()))
+			return
+		}
+	}
+
+	data, _ := json.Marshal(&models.TenantData{TelemetryTenants: telemetryTenants})
+
 	now := time.Now()
-	_, err := db.Conn.ExecContext(c.Request.Context(), "UPDATE tenant SET name=?,is_public=?, updated=? WHERE id=?", req.Name, req.IsPublic, now, req.Id)
+	_, err = db.Conn.ExecContext(c.Request.Context(), "UPDATE tenant SET name=?,is_public=?,data=?, updated=? WHERE id=?", req.Name, req.IsPublic, data, now, req.Id)
 	if err != nil {
 		if e.IsErrUniqueConstraint(err) {
 			c.JSON(400, common.RespError(fmt.Sprintf("tenant `%s` already exist", req.Name)))
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xObserve/xObserve/query/internal/acl"
 	"github.com/xObserve/xObserve/query/internal/datasource"
 	"github.com/xObserve/xObserve/query/internal/user"
 	"github.com/xObserve/xObserve/query/internal/variables"
@@ -120,31 +121,18 @@ func GetDashboardConfig(c *gin.Context) {
 		return
 	}
 
-	if dashboard.VisibleTo == models.TeamVisible {
-		// check user is in team
-		_, err := models.QueryTeamMember(c.Request.Context(), teamId, u.Id)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				c.JSON(403, common.RespError(e.NotTeamMember))
-				return
-			}
-			logger.Warn("query team member error", "error", err)
-			c.JSON(500, common.RespError(err.Error()))
-			return
-		}
-	} else if dashboard.VisibleTo == models.TenantVisible {
-		// check user is in tenant
-		in, err := models.IsUserInTenant(u.Id, tenantId)
-		if err != nil {
-			logger.Warn("check user in tenant error", "error", err)
-			c.JSON(500, common.RespError(err.Error()))
-			return
-		}
+	err := acl.CanViewDashboard(c.Request.Context(), dashboard, u)
+	if err != nil {
+		c.JSON(403, common.RespError(err.Error()))
+		return
+	}
+	dashboard.Editable = acl.CanEditDashboard(c.Request.Context(), dashboard, u) == nil
 
-		if !in {
-			c.JSON(403, common.RespError(e.NotTenantUser))
-			return
-		}
+	err = models.ResolveLibraryPanels(c.Request.Context(), dashboard.OwnedBy, dashboard.Data)
+	if err != nil {
+		logger.Warn("resolve library panels error", "error", err)
+		c.JSON(500, common.RespError(e.Internal))
+		return
 	}
 
 	vars, err := variables.GetTeamVariables(c.Request.Context(), dashboard.OwnedBy)
@@ -108,10 +108,27 @@ type Config struct {
 		Enable           bool `yaml:"enable"`
 		SyncWebsiteUsers bool `yaml:"sync_user_to_default"`
 	}
+
+	Provisioning struct {
+		// directory of provisioning files, datasources and dashboards are loaded from its `datasources` and `dashboards` sub directories
+		Path string `yaml:"path"`
+		// interval in seconds for checking the changes of provisioning files
+		PollInterval int `yaml:"poll_interval"`
+	}
 }
 
 type Observability struct {
-	Enable bool `yaml:"enable" json:"enable"`
+	Enable      bool        `yaml:"enable" json:"enable"`
+	LogPatterns LogPatterns `yaml:"log_patterns" json:"-"`
+}
+
+type LogPatterns struct {
+	// max number of logs read from clickhouse for clustering in one request
+	MaxSampleSize int `yaml:"max_sample_size"`
+	// max number of patterns kept in memory when clustering, logs can't fit into any of them are counted as unclustered
+	MaxPatterns int `yaml:"max_patterns"`
+	// two logs belong to the same pattern when the ratio of equal tokens is no less than this value
+	SimilarityThreshold float64 `yaml:"similarity_threshold"`
 }
 
 // Data ...
@@ -25,3 +25,7 @@ func IsErrUniqueConstraint(err error) bool {
 func IsErrNoColumn(err error) bool {
 	return strings.HasPrefix(err.Error(), "no such column") || strings.Contains(err.Error(), "Unknown column")
 }
+
+func IsErrNoTable(err error) bool {
+	return strings.HasPrefix(err.Error(), "no such table") || strings.Contains(err.Error(), "doesn't exist")
+}
@@ -31,6 +31,7 @@ const (
 	NeedWebsiteAdmin      = "only website admin can do this"
 	NeedWebsiteSuperAdmin = "only website super admin can do this"
 	NeedTeamAdmin         = "only team admin can do this"
+	NeedTeamEditor        = "only team editor can do this"
 	NeedTenantAdmin       = "only tenant admin can do this"
 	NotTeamMember         = "you are not a member of this team"
 	NotTenantUser         = "you are not a member of this tenant"
@@ -53,4 +54,8 @@ const (
 	// users
 	UsernameOrPasswordEmpty = "user name or password empty"
 	UserBeenDeleted         = "user has been logically deleted"
+
+	// Provisioning
+	ProvisionedDashboard  = "provisioned dashboard can't be changed, please update its provisioning file instead"
+	ProvisionedDatasource = "provisioned datasource can't be changed, please update its provisioning file instead"
 )
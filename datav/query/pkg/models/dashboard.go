@@ -39,9 +39,13 @@ type Dashboard struct {
 
 	Created *time.Time `json:"created,omitempty"`
 	Updated *time.Time `json:"updated,omitempty"`
+	// Version is increased on every update, it must be carried when updating a dashboard, to avoid overwriting others' changes
+	Version   int64 `json:"version"`
+	UpdatedBy int64 `json:"updatedBy,omitempty"`
 
 	CreatedBy int64            `json:"createdBy,omitempty"`
 	OwnedBy   int64            `json:"ownedBy,omitempty"` // team that ownes this dashboard
+	FolderId  int64            `json:"folderId,omitempty"`
 	OwnerName string           `json:"ownerName,omitempty"`
 	VisibleTo string           `json:"visibleTo"`
 	Tags      []string         `json:"tags,omitempty"`
@@ -49,6 +53,9 @@ type Dashboard struct {
 
 	SortWeight int         `json:"weight"`
 	Variables  []*Variable `json:"variables,omitempty"`
+
+	// Provisioned is true when the dashboard is loaded from provisioning files
+	Provisioned bool `json:"provisioned,omitempty"`
 }
 
 func QueryDashboard(ctx context.Context, id string) (*Dashboard, error) {
@@ -56,7 +63,7 @@ func QueryDashboard(ctx context.Context, id string) (*Dashboard, error) {
 
 	var rawJSON []byte
 	var rawTags []byte
-	err := db.Conn.QueryRowContext(ctx, "SELECT title,tags,data,team_id,visible_to,weight,updated FROM dashboard WHERE id = ?", id).Scan(&dash.Title, &rawTags, &rawJSON, &dash.OwnedBy, &dash.VisibleTo, &dash.SortWeight, &dash.Updated)
+	err := db.Conn.QueryRowContext(ctx, "SELECT title,tags,data,team_id,folder_id,visible_to,weight,updated,version,updated_by FROM dashboard WHERE id = ?", id).Scan(&dash.Title, &rawTags, &rawJSON, &dash.OwnedBy, &dash.FolderId, &dash.VisibleTo, &dash.SortWeight, &dash.Updated, &dash.Version, &dash.UpdatedBy)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +73,12 @@ func QueryDashboard(ctx context.Context, id string) (*Dashboard, error) {
 	if err != nil {
 		return nil, err
 	}
+	// dashboards stored in old structure are upgraded, those can't be upgraded are returned as they are,
+	// and they will be refused when saving
+	err = MigrateDashboardData(data)
+	if err != nil {
+		logger.Warn("migrate dashboard data error", "dashboard", id, "error", err)
+	}
 	dash.Data = data
 
 	tags := make([]string, 0)
@@ -117,6 +130,31 @@ func QueryDashboardsByTeamId(ctx context.Context, teamId int64) ([]*Dashboard, e
 	return dashboards, nil
 }
 
+// DashboardVersionConflict describes the newer version of a dashboard, which is returned when saving an outdated one
+type DashboardVersionConflict struct {
+	Version       int64      `json:"version"`
+	UpdatedBy     int64      `json:"updatedBy"`
+	UpdatedByName string     `json:"updatedByName"`
+	Updated       *time.Time `json:"updated"`
+}
+
+// QueryDashboardVersion returns the current version of a dashboard and who updated it
+func QueryDashboardVersion(ctx context.Context, id string) (version int64, updatedBy int64, updated *time.Time, err error) {
+	err = db.Conn.QueryRowContext(ctx, "SELECT version,updated_by,updated FROM dashboard WHERE id = ?", id).Scan(&version, &updatedBy, &updated)
+	return
+}
+
+// QueryDashboardFolder returns the team and folder which the dashboard is in
+func QueryDashboardFolder(ctx context.Context, id string) (int64, int64, error) {
+	var teamId, folderId int64
+	err := db.Conn.QueryRowContext(ctx, "SELECT team_id,folder_id FROM dashboard WHERE id = ?", id).Scan(&teamId, &folderId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return teamId, folderId, nil
+}
+
 func QueryDashboardBelongsTo(ctx context.Context, id string) (int64, error) {
 	var teamId int64
 	err := db.Conn.QueryRowContext(ctx, "SELECT team_id FROM dashboard WHERE id = ?", id).Scan(&teamId)
@@ -127,7 +165,7 @@ func QueryDashboardBelongsTo(ctx context.Context, id string) (int64, error) {
 	return teamId, nil
 }
 
-func ImportFromJSON(tx *sql.Tx, raw string, teamId int64, userId int64) (*Dashboard, error) {
+func ImportFromJSON(tx *sql.Tx, raw string, teamId int64, folderId int64, userId int64) (*Dashboard, error) {
 	var dash *Dashboard
 	err := json.Unmarshal([]byte(raw), &dash)
 	if err != nil {
@@ -142,6 +180,11 @@ func ImportFromJSON(tx *sql.Tx, raw string, teamId int64, userId int64) (*Dashbo
 	dash.Created = &now
 	dash.Updated = &now
 
+	err = CheckDashboardData(dash.Data)
+	if err != nil {
+		return nil, err
+	}
+
 	jsonData, err := dash.Data.Encode()
 	if err != nil {
 		return nil, err
@@ -152,8 +195,15 @@ func ImportFromJSON(tx *sql.Tx, raw string, teamId int64, userId int64) (*Dashbo
 		return nil, err
 	}
 
-	_, err = tx.Exec(`INSERT INTO dashboard (id,title, team_id, created_by,tags, data,created,updated) VALUES (?,?,?,?,?,?,?,?)`,
-		dash.Id, dash.Title, teamId, userId, tags, jsonData, dash.Created, dash.Updated)
+	dash.Version = 1
+	dash.FolderId = folderId
+	_, err = tx.Exec(`INSERT INTO dashboard (id,title, team_id,folder_id, created_by,tags, data,created,updated,version,updated_by) VALUES (?,?,?,?,?,?,?,?,?,?,?)`,
+		dash.Id, dash.Title, teamId, folderId, userId, tags, jsonData, dash.Created, dash.Updated, dash.Version, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = UpdateDashboardSearch(context.Background(), tx, dash)
 	if err != nil {
 		return nil, err
 	}
@@ -182,5 +232,30 @@ func DeleteDashboard(ctx context.Context, id string, tx *sql.Tx) error {
 		return fmt.Errorf("delete dashboard annotations error: %w", err)
 	}
 
+	_, err = tx.ExecContext(ctx, "DELETE FROM dashboard_acl WHERE dashboard_id=?", id)
+	if err != nil {
+		return fmt.Errorf("delete dashboard acl error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM dashboard_provisioning WHERE dashboard_id=?", id)
+	if err != nil {
+		return fmt.Errorf("delete dashboard provisioning error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM snapshot WHERE dashboard_id=?", id)
+	if err != nil {
+		return fmt.Errorf("delete dashboard snapshots error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM library_panel_dashboard WHERE dashboard_id=?", id)
+	if err != nil {
+		return fmt.Errorf("delete dashboard library panel connections error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM dashboard_search WHERE dashboard_id=?", id)
+	if err != nil {
+		return fmt.Errorf("delete dashboard search index error: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+)
+
+// DashboardAcl grants a user or a team to view, edit or admin a dashboard, in addition to the team role and folder permissions
+type DashboardAcl struct {
+	DashboardId string         `json:"dashboardId"`
+	SubjectType string         `json:"subjectType"`
+	SubjectId   int64          `json:"subjectId"`
+	SubjectName string         `json:"subjectName"`
+	Permission  PermissionType `json:"permission"`
+	Created     time.Time      `json:"created"`
+}
+
+func QueryDashboardAcl(ctx context.Context, dashboardId string) ([]*DashboardAcl, error) {
+	rows, err := db.Conn.QueryContext(ctx, "SELECT dashboard_id,subject_type,subject_id,permission,created FROM dashboard_acl WHERE dashboard_id=?", dashboardId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	acl := make([]*DashboardAcl, 0)
+	for rows.Next() {
+		a := &DashboardAcl{}
+		err := rows.Scan(&a.DashboardId, &a.SubjectType, &a.SubjectId, &a.Permission, &a.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		a.SubjectName = permissionSubjectName(ctx, a.SubjectType, a.SubjectId)
+		acl = append(acl, a)
+	}
+
+	return acl, nil
+}
+
+// QueryGrantedDashboards returns the highest permissions granted to the user on dashboards, directly or by the teams the user is in
+func QueryGrantedDashboards(ctx context.Context, userId int64) (map[string]PermissionType, error) {
+	where, args, err := grantedSubjectsQuery(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Conn.QueryContext(ctx, "SELECT dashboard_id,permission FROM dashboard_acl WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	granted := make(map[string]PermissionType)
+	for rows.Next() {
+		var dashboardId string
+		var permission PermissionType
+		err := rows.Scan(&dashboardId, &permission)
+		if err != nil {
+			return nil, err
+		}
+
+		if !granted[dashboardId].Contains(permission) {
+			granted[dashboardId] = permission
+		}
+	}
+
+	return granted, nil
+}
+
+// QueryDashboardAclPermission returns the highest permission granted to the user by the acl of a dashboard
+func QueryDashboardAclPermission(ctx context.Context, dashboardId string, userId int64) (PermissionType, error) {
+	where, args, err := grantedSubjectsQuery(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := db.Conn.QueryContext(ctx, "SELECT permission FROM dashboard_acl WHERE dashboard_id=? AND "+where, append([]interface{}{dashboardId}, args...)...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var permission PermissionType
+	for rows.Next() {
+		var p PermissionType
+		err := rows.Scan(&p)
+		if err != nil {
+			return "", err
+		}
+
+		if !permission.Contains(p) {
+			permission = p
+		}
+	}
+
+	return permission, nil
+}
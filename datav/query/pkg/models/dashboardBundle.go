@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// version of the bundle format, bundles with other versions are rejected when importing
+const DashboardBundleVersion = 1
+
+// DashboardBundle is the archive of exported dashboards, it contains the team variables and
+// datasources used by the dashboards, so they can be imported into another team or tenant
+type DashboardBundle struct {
+	Version    int                `json:"version"`
+	Exported   time.Time          `json:"exported"`
+	Dashboards []*BundleDashboard `json:"dashboards"`
+	// team variables referenced by the dashboards
+	Variables []*Variable `json:"variables"`
+	// datasources referenced by the dashboards and variables, they are matched by name when importing
+	Datasources []*BundleDatasource `json:"datasources"`
+}
+
+type BundleDashboard struct {
+	*Dashboard
+	// titles of the folders from the exported folder to the dashboard
+	FolderPath []string `json:"folderPath,omitempty"`
+}
+
+// BundleDatasource is the reference of a datasource, the url and secrets are not exported
+type BundleDatasource struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// conflict policies used when the id of an imported dashboard already exists
+const (
+	ImportConflictSkip      = "skip"
+	ImportConflictOverwrite = "overwrite"
+	ImportConflictNew       = "new"
+)
@@ -0,0 +1,364 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xObserve/xObserve/query/pkg/colorlog"
+	"github.com/xObserve/xObserve/query/pkg/utils/simplejson"
+)
+
+var logger = colorlog.RootLogger.New("logger", "models")
+
+// DashboardSchemaVersion is the version of the structure of dashboard data, it's stored in `data.schemaVersion`.
+// When the structure is changed, increase it and append a migration to dashboardMigrations,
+// so the dashboards stored in old structure are upgraded when they are loaded
+const DashboardSchemaVersion = 1
+
+// PanelTypeRow is the type of row panels, which can contain other panels when collapsed
+const PanelTypeRow = "row"
+
+// dashboardMigrations[i] upgrades dashboard data from schema version i to i+1,
+// dashboards saved before schema version is introduced are version 0
+var dashboardMigrations = []func(data map[string]interface{}){
+	migrateDashboardToV1,
+}
+
+// max number of errors reported when validating a dashboard
+const maxDashboardSchemaErrors = 10
+
+// DashboardSchemaError is an invalid field of dashboard, path is the location of the field in dashboard json,
+// e.g `data.panels[2].gridPos.w`
+type DashboardSchemaError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e *DashboardSchemaError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+type DashboardSchemaErrors []*DashboardSchemaError
+
+func (errs DashboardSchemaErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return "invalid dashboard, " + strings.Join(msgs, "; ")
+}
+
+// MigrateDashboardData upgrades dashboard data to current schema version in place, the returned error is DashboardSchemaErrors,
+// data saved by a newer version of xobserve is refused, because it may be broken by older code
+func MigrateDashboardData(data *simplejson.Json) error {
+	if data == nil {
+		return DashboardSchemaErrors{{Path: "data", Message: "is required"}}
+	}
+	m, err := data.Map()
+	if err != nil {
+		return DashboardSchemaErrors{{Path: "data", Message: "must be an object"}}
+	}
+
+	version := 0
+	if v, ok := m["schemaVersion"]; ok && v != nil {
+		n, ok := jsonInt(v)
+		if !ok || n < 0 {
+			return DashboardSchemaErrors{{Path: "data.schemaVersion", Message: "must be a non-negative integer"}}
+		}
+		version = int(n)
+	}
+
+	if version > DashboardSchemaVersion {
+		return DashboardSchemaErrors{{Path: "data.schemaVersion", Message: fmt.Sprintf("version %d is newer than the supported version %d, please upgrade xobserve", version, DashboardSchemaVersion)}}
+	}
+
+	for v := version; v < DashboardSchemaVersion; v++ {
+		dashboardMigrations[v](m)
+	}
+	m["schemaVersion"] = DashboardSchemaVersion
+
+	return nil
+}
+
+// migrateDashboardToV1 fills the fields which ui expects to always exist, and fixes the shapes written by old ui:
+// - panels, variables and hiddenPanels are arrays
+// - styles.bg is an object of url and color mode instead of a url string
+// - every panel has a unique id, and the queries of its datasource are an array
+func migrateDashboardToV1(data map[string]interface{}) {
+	for _, k := range []string{"panels", "variables", "hiddenPanels"} {
+		if _, ok := data[k].([]interface{}); !ok {
+			data[k] = []interface{}{}
+		}
+	}
+
+	if styles, ok := data["styles"].(map[string]interface{}); ok {
+		if url, ok := styles["bg"].(string); ok {
+			styles["bg"] = map[string]interface{}{
+				"url":       url,
+				"colorMode": "dark",
+			}
+		}
+	}
+
+	panels := data["panels"].([]interface{})
+	var maxId int64
+	walkPanelList(panels, func(panel map[string]interface{}) {
+		if id, ok := jsonInt(panel["id"]); ok && id > maxId {
+			maxId = id
+		}
+	})
+
+	seen := make(map[int64]bool)
+	walkPanelList(panels, func(panel map[string]interface{}) {
+		id, ok := jsonInt(panel["id"])
+		if !ok || id <= 0 || seen[id] {
+			maxId++
+			id = maxId
+			panel["id"] = id
+		}
+		seen[id] = true
+
+		if ds, ok := panel["datasource"].(map[string]interface{}); ok {
+			if _, ok := ds["queries"].([]interface{}); !ok {
+				ds["queries"] = []interface{}{}
+			}
+		}
+	})
+}
+
+// CheckDashboardData upgrades dashboard data to current schema version and validates it,
+// it should be called before saving dashboards
+func CheckDashboardData(data *simplejson.Json) error {
+	err := MigrateDashboardData(data)
+	if err != nil {
+		return err
+	}
+
+	return ValidateDashboardData(data)
+}
+
+// ValidateDashboardData checks the structure of dashboard data, it should be called after
+// MigrateDashboardData, the returned error is DashboardSchemaErrors when the structure is invalid
+func ValidateDashboardData(data *simplejson.Json) error {
+	v := &dashboardValidator{panelIds: make(map[int64]string)}
+	if data == nil {
+		v.fail("data", "is required")
+		return v.errs
+	}
+
+	m, err := data.Map()
+	if err != nil {
+		v.fail("data", "must be an object")
+		return v.errs
+	}
+
+	if n, ok := jsonInt(m["schemaVersion"]); !ok || n != DashboardSchemaVersion {
+		v.fail("data.schemaVersion", fmt.Sprintf("must be %d", DashboardSchemaVersion))
+	}
+
+	if panels, ok := v.array(m, "panels", "data.panels", true); ok {
+		v.panels(panels, "data.panels", false)
+	}
+
+	if vars, ok := v.array(m, "variables", "data.variables", true); ok {
+		names := make(map[string]bool)
+		for i, item := range vars {
+			path := fmt.Sprintf("data.variables[%d]", i)
+			variable, ok := item.(map[string]interface{})
+			if !ok {
+				v.fail(path, "must be an object")
+				continue
+			}
+			if name, ok := v.string(variable, "name", path+".name", true); ok {
+				if strings.TrimSpace(name) == "" {
+					v.fail(path+".name", "can not be empty")
+				} else if names[name] {
+					v.fail(path+".name", fmt.Sprintf("duplicated variable `%s`", name))
+				}
+				names[name] = true
+			}
+			v.string(variable, "type", path+".type", true)
+		}
+	}
+
+	if hidden, ok := v.array(m, "hiddenPanels", "data.hiddenPanels", false); ok {
+		for i, id := range hidden {
+			if _, ok := jsonInt(id); !ok {
+				v.fail(fmt.Sprintf("data.hiddenPanels[%d]", i), "must be a panel id")
+			}
+		}
+	}
+
+	if styles, ok := m["styles"]; ok && styles != nil {
+		if s, ok := styles.(map[string]interface{}); !ok {
+			v.fail("data.styles", "must be an object")
+		} else if bg, ok := s["bg"]; ok && bg != nil {
+			if _, ok := bg.(map[string]interface{}); !ok {
+				v.fail("data.styles.bg", "must be an object")
+			}
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+type dashboardValidator struct {
+	errs DashboardSchemaErrors
+	// panel ids and the paths of the panels using them
+	panelIds map[int64]string
+}
+
+func (v *dashboardValidator) fail(path string, msg string) {
+	if len(v.errs) < maxDashboardSchemaErrors {
+		v.errs = append(v.errs, &DashboardSchemaError{Path: path, Message: msg})
+	}
+}
+
+func (v *dashboardValidator) panels(panels []interface{}, path string, inRow bool) {
+	for i, item := range panels {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		panel, ok := item.(map[string]interface{})
+		if !ok {
+			v.fail(p, "must be an object")
+			continue
+		}
+
+		if id, ok := jsonInt(panel["id"]); !ok || id <= 0 {
+			v.fail(p+".id", "must be a positive integer")
+		} else if other, ok := v.panelIds[id]; ok {
+			v.fail(p+".id", fmt.Sprintf("duplicated with %s", other))
+		} else {
+			v.panelIds[id] = p
+		}
+
+		panelType, ok := v.string(panel, "type", p+".type", true)
+		if ok && panelType == "" {
+			v.fail(p+".type", "can not be empty")
+		}
+		v.string(panel, "title", p+".title", false)
+		v.string(panel, "desc", p+".desc", false)
+
+		if gridPos, ok := v.object(panel, "gridPos", p+".gridPos", true); ok {
+			for _, k := range []string{"x", "y", "w", "h"} {
+				n, ok := jsonFloat(gridPos[k])
+				if !ok {
+					v.fail(p+".gridPos."+k, "must be a number")
+				} else if n < 0 || ((k == "w" || k == "h") && n == 0) {
+					v.fail(p+".gridPos."+k, "out of range")
+				}
+			}
+		}
+
+		if ds, ok := v.object(panel, "datasource", p+".datasource", false); ok {
+			if id, ok := ds["id"]; ok && id != nil {
+				if _, ok := jsonInt(id); !ok {
+					v.fail(p+".datasource.id", "must be a datasource id")
+				}
+			}
+			if queries, ok := v.array(ds, "queries", p+".datasource.queries", false); ok {
+				for j, item := range queries {
+					qp := fmt.Sprintf("%s.datasource.queries[%d]", p, j)
+					query, ok := item.(map[string]interface{})
+					if !ok {
+						v.fail(qp, "must be an object")
+						continue
+					}
+					if _, ok := jsonInt(query["id"]); !ok {
+						v.fail(qp+".id", "must be an integer")
+					}
+					v.string(query, "metrics", qp+".metrics", false)
+				}
+			}
+		}
+
+		if ref, ok := v.object(panel, "libraryPanel", p+".libraryPanel", false); ok {
+			if id, ok := jsonInt(ref["id"]); !ok || id <= 0 {
+				v.fail(p+".libraryPanel.id", "must be a library panel id")
+			}
+		}
+
+		if children, ok := v.array(panel, "panels", p+".panels", false); ok && len(children) > 0 {
+			if panelType != PanelTypeRow || inRow {
+				v.fail(p+".panels", "only rows can contain panels")
+				continue
+			}
+			v.panels(children, p+".panels", true)
+		}
+	}
+}
+
+func (v *dashboardValidator) array(m map[string]interface{}, k string, path string, required bool) ([]interface{}, bool) {
+	value, ok := m[k]
+	if !ok || value == nil {
+		if required {
+			v.fail(path, "is required")
+		}
+		return nil, false
+	}
+
+	arr, ok := value.([]interface{})
+	if !ok {
+		v.fail(path, "must be an array")
+	}
+	return arr, ok
+}
+
+func (v *dashboardValidator) object(m map[string]interface{}, k string, path string, required bool) (map[string]interface{}, bool) {
+	value, ok := m[k]
+	if !ok || value == nil {
+		if required {
+			v.fail(path, "is required")
+		}
+		return nil, false
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(path, "must be an object")
+	}
+	return obj, ok
+}
+
+func (v *dashboardValidator) string(m map[string]interface{}, k string, path string, required bool) (string, bool) {
+	value, ok := m[k]
+	if !ok || value == nil {
+		if required {
+			v.fail(path, "is required")
+		}
+		return "", false
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		v.fail(path, "must be a string")
+	}
+	return s, ok
+}
+
+// numbers in dashboard data are json.Number when decoded by simplejson, and int64 when set by migrations
+func jsonFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func jsonInt(v interface{}) (int64, bool) {
+	f, ok := jsonFloat(v)
+	if !ok || f != float64(int64(f)) {
+		return 0, false
+	}
+	return int64(f), true
+}
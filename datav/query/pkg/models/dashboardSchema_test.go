@@ -0,0 +1,180 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/xObserve/xObserve/query/pkg/utils/simplejson"
+)
+
+func testDashboardData(t *testing.T, data string) *simplejson.Json {
+	t.Helper()
+	d, err := simplejson.NewJson([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestMigrateDashboardData(t *testing.T) {
+	data := testDashboardData(t, `{
+		"styles": {"bg": "/bg.png"},
+		"panels": [
+			{"id": 3, "type": "graph", "datasource": {"id": 1}},
+			{"id": 3, "type": "graph"},
+			{"type": "row", "panels": [{"id": 0, "type": "graph"}]}
+		]
+	}`)
+
+	err := MigrateDashboardData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := data.Get("schemaVersion").MustInt(); v != DashboardSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", DashboardSchemaVersion, v)
+	}
+	for _, k := range []string{"variables", "hiddenPanels"} {
+		if _, err := data.Get(k).Array(); err != nil {
+			t.Errorf("expected %s to be an array", k)
+		}
+	}
+	if url := data.GetPath("styles", "bg", "url").MustString(); url != "/bg.png" {
+		t.Errorf("expected bg url /bg.png, got %q", url)
+	}
+	if _, err := data.Get("panels").GetIndex(0).GetPath("datasource", "queries").Array(); err != nil {
+		t.Error("expected queries of datasource to be an array")
+	}
+
+	ids := []int64{
+		data.Get("panels").GetIndex(0).Get("id").MustInt64(),
+		data.Get("panels").GetIndex(1).Get("id").MustInt64(),
+		data.Get("panels").GetIndex(2).Get("id").MustInt64(),
+		data.Get("panels").GetIndex(2).Get("panels").GetIndex(0).Get("id").MustInt64(),
+	}
+	seen := make(map[int64]bool)
+	for _, id := range ids {
+		if id <= 0 || seen[id] {
+			t.Errorf("expected unique positive panel ids, got %v", ids)
+			break
+		}
+		seen[id] = true
+	}
+}
+
+func TestMigrateDashboardDataErrors(t *testing.T) {
+	cases := []struct {
+		desc string
+		data string
+		path string
+	}{
+		{"not an object", `[]`, "data"},
+		{"invalid version", `{"schemaVersion": "1"}`, "data.schemaVersion"},
+		{"negative version", `{"schemaVersion": -1}`, "data.schemaVersion"},
+		{"newer version", `{"schemaVersion": 1000}`, "data.schemaVersion"},
+	}
+
+	for _, c := range cases {
+		err := MigrateDashboardData(testDashboardData(t, c.data))
+		errs, ok := err.(DashboardSchemaErrors)
+		if !ok || len(errs) != 1 || errs[0].Path != c.path {
+			t.Errorf("%s: expected error of %s, got %v", c.desc, c.path, err)
+		}
+	}
+
+	errs, ok := MigrateDashboardData(nil).(DashboardSchemaErrors)
+	if !ok || len(errs) != 1 || errs[0].Path != "data" {
+		t.Errorf("nil data: expected error of data, got %v", errs)
+	}
+}
+
+func TestCheckDashboardData(t *testing.T) {
+	cases := []struct {
+		desc  string
+		data  string
+		paths []string
+	}{
+		{
+			desc:  "valid dashboard",
+			data:  `{"panels": [{"id": 1, "type": "graph", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}}], "variables": [{"name": "service", "type": "query"}]}`,
+			paths: nil,
+		},
+		{
+			desc:  "old dashboard is migrated before validating",
+			data:  `{"panels": [{"type": "graph", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}}]}`,
+			paths: nil,
+		},
+		{
+			desc:  "panels is not an array",
+			data:  `{"schemaVersion": 1, "panels": {}, "variables": []}`,
+			paths: []string{"data.panels"},
+		},
+		{
+			desc:  "invalid panel fields",
+			data:  `{"panels": [{"id": 1, "type": "", "gridPos": {"x": -1, "y": 0, "w": 0}, "title": 1}]}`,
+			paths: []string{"data.panels[0].type", "data.panels[0].title", "data.panels[0].gridPos.x", "data.panels[0].gridPos.w", "data.panels[0].gridPos.h"},
+		},
+		{
+			desc:  "duplicated panel ids",
+			data:  `{"schemaVersion": 1, "variables": [], "panels": [{"id": 1, "type": "graph", "gridPos": {"x": 0, "y": 0, "w": 1, "h": 1}}, {"id": 1, "type": "graph", "gridPos": {"x": 0, "y": 0, "w": 1, "h": 1}}]}`,
+			paths: []string{"data.panels[1].id"},
+		},
+		{
+			desc:  "only rows can contain panels",
+			data:  `{"panels": [{"id": 1, "type": "graph", "gridPos": {"x": 0, "y": 0, "w": 1, "h": 1}, "panels": [{"id": 2, "type": "graph"}]}]}`,
+			paths: []string{"data.panels[0].panels"},
+		},
+		{
+			desc:  "panels in rows are validated",
+			data:  `{"panels": [{"id": 1, "type": "row", "gridPos": {"x": 0, "y": 0, "w": 1, "h": 1}, "panels": [{"id": 2, "type": "graph"}]}]}`,
+			paths: []string{"data.panels[0].panels[0].gridPos"},
+		},
+		{
+			desc:  "invalid variables",
+			data:  `{"variables": [{"name": "a", "type": "query"}, {"name": "a", "type": "query"}, {"name": " ", "type": "query"}, {"type": "query"}]}`,
+			paths: []string{"data.variables[1].name", "data.variables[2].name", "data.variables[3].name"},
+		},
+		{
+			desc:  "invalid library panel",
+			data:  `{"panels": [{"id": 1, "type": "graph", "gridPos": {"x": 0, "y": 0, "w": 1, "h": 1}, "libraryPanel": {"id": "a"}}]}`,
+			paths: []string{"data.panels[0].libraryPanel.id"},
+		},
+	}
+
+	for _, c := range cases {
+		err := CheckDashboardData(testDashboardData(t, c.data))
+		if c.paths == nil {
+			if err != nil {
+				t.Errorf("%s: expected no error, got %v", c.desc, err)
+			}
+			continue
+		}
+
+		errs, ok := err.(DashboardSchemaErrors)
+		if !ok {
+			t.Errorf("%s: expected schema errors, got %v", c.desc, err)
+			continue
+		}
+		paths := make([]string, 0, len(errs))
+		for _, e := range errs {
+			paths = append(paths, e.Path)
+		}
+		if len(paths) != len(c.paths) {
+			t.Errorf("%s: expected errors of %v, got %v", c.desc, c.paths, paths)
+			continue
+		}
+		for i := range paths {
+			if paths[i] != c.paths[i] {
+				t.Errorf("%s: expected errors of %v, got %v", c.desc, c.paths, paths)
+				break
+			}
+		}
+	}
+}
+
+func TestValidateDashboardDataMaxErrors(t *testing.T) {
+	data := testDashboardData(t, `{"schemaVersion": 1, "variables": [], "panels": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12]}`)
+	errs, ok := ValidateDashboardData(data).(DashboardSchemaErrors)
+	if !ok || len(errs) != maxDashboardSchemaErrors {
+		t.Errorf("expected %d errors, got %d", maxDashboardSchemaErrors, len(errs))
+	}
+}
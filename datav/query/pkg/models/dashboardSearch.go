@@ -0,0 +1,435 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/utils/simplejson"
+)
+
+// engines used to search dashboards
+const (
+	// SQLite FTS5, it requires go-sqlite3 to be built with the `sqlite_fts5` tag
+	SearchEngineFTS5 = "fts5"
+	// MySQL FULLTEXT indexes
+	SearchEngineFulltext = "fulltext"
+	// LIKE queries, used when full-text search is not available
+	SearchEngineLike = "like"
+)
+
+// DashboardSearchEngine is decided when initializing storage
+var DashboardSearchEngine = SearchEngineLike
+
+// DashboardSearchDoc is the text of a dashboard indexed for searching, a row of dashboard_search,
+// panel titles, descriptions and queries are separated by newlines
+type DashboardSearchDoc struct {
+	DashboardId  string
+	Title        string
+	Tags         string
+	Panels       string
+	Descriptions string
+	Queries      string
+}
+
+// searchable fields of dashboards, in the order of their weights in ranking
+var DashboardSearchFields = []string{"title", "tags", "panels", "descriptions", "queries"}
+
+func (d *DashboardSearchDoc) Field(name string) string {
+	switch name {
+	case "title":
+		return d.Title
+	case "tags":
+		return d.Tags
+	case "panels":
+		return d.Panels
+	case "descriptions":
+		return d.Descriptions
+	case "queries":
+		return d.Queries
+	}
+	return ""
+}
+
+type DashboardSearchResult struct {
+	*Dashboard
+	Score      float64            `json:"score"`
+	Highlights []*SearchHighlight `json:"highlights,omitempty"`
+}
+
+// SearchHighlight is a fragment of a dashboard field matching the search,
+// the matches are wrapped in <em></em>, and the other text is html escaped
+type SearchHighlight struct {
+	Field    string `json:"field"`
+	Fragment string `json:"fragment"`
+}
+
+// NewDashboardSearchDoc extracts the searchable text from a dashboard
+func NewDashboardSearchDoc(dash *Dashboard) *DashboardSearchDoc {
+	doc := &DashboardSearchDoc{
+		DashboardId: dash.Id,
+		Title:       dash.Title,
+		Tags:        strings.Join(dash.Tags, " "),
+	}
+
+	panels := make([]string, 0)
+	descs := make([]string, 0)
+	queries := make([]string, 0)
+	walkPanels(dash.Data, func(panel map[string]interface{}) {
+		if title, ok := panel["title"].(string); ok && strings.TrimSpace(title) != "" {
+			panels = append(panels, title)
+		}
+		if desc, ok := panel["desc"].(string); ok && strings.TrimSpace(desc) != "" {
+			descs = append(descs, desc)
+		}
+
+		ds, ok := panel["datasource"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		qs, _ := ds["queries"].([]interface{})
+		for _, q := range qs {
+			query, ok := q.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if metrics, ok := query["metrics"].(string); ok && strings.TrimSpace(metrics) != "" {
+				queries = append(queries, metrics)
+			}
+		}
+	})
+
+	doc.Panels = strings.Join(panels, "\n")
+	doc.Descriptions = strings.Join(descs, "\n")
+	doc.Queries = strings.Join(queries, "\n")
+
+	return doc
+}
+
+// UpdateDashboardSearch indexes the dashboard for searching, it should be called whenever the dashboard is changed,
+// the full-text index of SQLite is kept in sync by triggers
+func UpdateDashboardSearch(ctx context.Context, tx execer, dash *Dashboard) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM dashboard_search WHERE dashboard_id=?", dash.Id)
+	if err != nil {
+		return err
+	}
+
+	doc := NewDashboardSearchDoc(dash)
+	_, err = tx.ExecContext(ctx, "INSERT INTO dashboard_search (dashboard_id,title,tags,panels,descriptions,queries,updated) VALUES (?,?,?,?,?,?,?)",
+		doc.DashboardId, doc.Title, doc.Tags, doc.Panels, doc.Descriptions, doc.Queries, time.Now())
+	return err
+}
+
+// UpdateDashboardSearchFromRaw is used when the dashboard is loaded from database rather than decoded from request
+func UpdateDashboardSearchFromRaw(ctx context.Context, tx execer, id string, title string, rawTags []byte, rawData []byte) error {
+	dash := &Dashboard{Id: id, Title: title}
+	if len(rawTags) > 0 {
+		json.Unmarshal(rawTags, &dash.Tags)
+	}
+
+	data, err := simplejson.NewJson(rawData)
+	if err == nil {
+		dash.Data = data
+	}
+
+	return UpdateDashboardSearch(ctx, tx, dash)
+}
+
+// max number of terms in a search query, the others are ignored
+const maxSearchTerms = 10
+
+// ParseSearchTerms splits a search query into terms, terms are matched case-insensitively
+// and a dashboard must match all of them
+func ParseSearchTerms(query string) []string {
+	terms := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, term := range strings.Fields(strings.ReplaceAll(query, `"`, " ")) {
+		term = strings.ToLower(term)
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+
+	return terms
+}
+
+// the search index is joined with dashboard and team, so the scope of a search can be restricted by their columns
+const dashboardSearchScopeJoin = " INNER JOIN dashboard ON dashboard.id = dashboard_search.dashboard_id INNER JOIN team ON dashboard.team_id = team.id"
+
+// SearchDashboardIds returns the ids of dashboards matching all the terms with their scores, higher is better,
+// the last term is matched as a prefix, so results can be shown while users are typing.
+// scope is a condition on the dashboard and team tables, only the dashboards in it are searched
+func SearchDashboardIds(ctx context.Context, terms []string, scope string, scopeArgs ...interface{}) (map[string]float64, error) {
+	if len(terms) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	switch DashboardSearchEngine {
+	case SearchEngineFTS5:
+		return searchDashboardIdsFTS5(ctx, terms, scope, scopeArgs)
+	case SearchEngineFulltext:
+		return searchDashboardIdsFulltext(ctx, terms, scope, scopeArgs)
+	default:
+		return searchDashboardIdsLike(ctx, terms, scope, scopeArgs)
+	}
+}
+
+func searchDashboardIdsFTS5(ctx context.Context, terms []string, scope string, scopeArgs []interface{}) (map[string]float64, error) {
+	// terms are quoted as strings, so they are not parsed as FTS5 operators
+	match := make([]string, 0, len(terms))
+	for i, term := range terms {
+		s := `"` + term + `"`
+		if i == len(terms)-1 {
+			s += "*"
+		}
+		match = append(match, s)
+	}
+
+	// bm25 is lower for better matches, the weights of columns are in the order of DashboardSearchFields
+	args := append([]interface{}{strings.Join(match, " ")}, scopeArgs...)
+	rows, err := db.Conn.QueryContext(ctx, `SELECT dashboard_search.dashboard_id, bm25(dashboard_fts, 10.0, 5.0, 3.0, 1.0, 1.0) FROM dashboard_fts
+		INNER JOIN dashboard_search ON dashboard_search.rowid = dashboard_fts.rowid`+dashboardSearchScopeJoin+` WHERE dashboard_fts MATCH ? AND `+scope, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]float64)
+	for rows.Next() {
+		var id string
+		var rank float64
+		err := rows.Scan(&id, &rank)
+		if err != nil {
+			return nil, err
+		}
+		res[id] = -rank
+	}
+
+	return res, nil
+}
+
+func searchDashboardIdsFulltext(ctx context.Context, terms []string, scope string, scopeArgs []interface{}) (map[string]float64, error) {
+	// every term is required, terms containing the characters treated as word separators by MySQL are searched as phrases
+	match := make([]string, 0, len(terms))
+	for i, term := range terms {
+		if isFulltextWord(term) {
+			s := "+" + term
+			if i == len(terms)-1 {
+				s += "*"
+			}
+			match = append(match, s)
+		} else {
+			match = append(match, `+"`+term+`"`)
+		}
+	}
+	against := strings.Join(match, " ")
+
+	args := append([]interface{}{against, against, against}, scopeArgs...)
+	rows, err := db.Conn.QueryContext(ctx, `SELECT dashboard_search.dashboard_id, MATCH (dashboard_search.title) AGAINST (? IN BOOLEAN MODE) * 3 + MATCH (dashboard_search.title,dashboard_search.tags,dashboard_search.panels,dashboard_search.descriptions,dashboard_search.queries) AGAINST (? IN BOOLEAN MODE)
+		FROM dashboard_search`+dashboardSearchScopeJoin+` WHERE MATCH (dashboard_search.title,dashboard_search.tags,dashboard_search.panels,dashboard_search.descriptions,dashboard_search.queries) AGAINST (? IN BOOLEAN MODE) AND `+scope, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]float64)
+	for rows.Next() {
+		var id string
+		var score float64
+		err := rows.Scan(&id, &score)
+		if err != nil {
+			return nil, err
+		}
+		res[id] = score
+	}
+
+	return res, nil
+}
+
+func isFulltextWord(term string) bool {
+	for _, r := range term {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// terms are matched literally in LIKE queries
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// weights of the fields when ranking with LIKE queries, in the order of DashboardSearchFields
+var searchFieldWeights = []float64{10, 5, 3, 1, 1}
+
+func searchDashboardIdsLike(ctx context.Context, terms []string, scope string, scopeArgs []interface{}) (map[string]float64, error) {
+	where := make([]string, 0, len(terms))
+	args := make([]interface{}, 0, len(terms)*len(DashboardSearchFields))
+	for _, term := range terms {
+		conds := make([]string, 0, len(DashboardSearchFields))
+		for _, field := range DashboardSearchFields {
+			conds = append(conds, fmt.Sprintf(`LOWER(dashboard_search.%s) LIKE ? ESCAPE '\'`, field))
+			args = append(args, "%"+likeEscaper.Replace(term)+"%")
+		}
+		where = append(where, "("+strings.Join(conds, " OR ")+")")
+	}
+
+	where = append(where, scope)
+	args = append(args, scopeArgs...)
+	docs, err := queryDashboardSearchDocs(ctx, dashboardSearchScopeJoin, strings.Join(where, " AND "), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]float64)
+	for _, doc := range docs {
+		var score float64
+		for i, field := range DashboardSearchFields {
+			text := strings.ToLower(doc.Field(field))
+			for _, term := range terms {
+				score += searchFieldWeights[i] * float64(strings.Count(text, term))
+			}
+		}
+		res[doc.DashboardId] = score
+	}
+
+	return res, nil
+}
+
+// QueryDashboardSearchDocs returns the indexed text of dashboards
+func QueryDashboardSearchDocs(ctx context.Context, ids []string) (map[string]*DashboardSearchDoc, error) {
+	res := make(map[string]*DashboardSearchDoc)
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	docs, err := queryDashboardSearchDocs(ctx, "", fmt.Sprintf("dashboard_id IN (%s)", strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		res[doc.DashboardId] = doc
+	}
+
+	return res, nil
+}
+
+func queryDashboardSearchDocs(ctx context.Context, join string, where string, args ...interface{}) ([]*DashboardSearchDoc, error) {
+	rows, err := db.Conn.QueryContext(ctx, "SELECT dashboard_search.dashboard_id,dashboard_search.title,dashboard_search.tags,dashboard_search.panels,dashboard_search.descriptions,dashboard_search.queries FROM dashboard_search"+join+" WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := make([]*DashboardSearchDoc, 0)
+	for rows.Next() {
+		doc := &DashboardSearchDoc{}
+		var tags, panels, descs, queries sql.NullString
+		err := rows.Scan(&doc.DashboardId, &doc.Title, &tags, &panels, &descs, &queries)
+		if err != nil {
+			return nil, err
+		}
+		doc.Tags = tags.String
+		doc.Panels = panels.String
+		doc.Descriptions = descs.String
+		doc.Queries = queries.String
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// max length of highlight fragments, longer lines are cut around the first match
+const maxHighlightLength = 160
+
+// HighlightDashboardSearch returns a fragment for each field of the dashboard matching the terms
+func HighlightDashboardSearch(doc *DashboardSearchDoc, terms []string) []*SearchHighlight {
+	if doc == nil || len(terms) == 0 {
+		return nil
+	}
+
+	// longer terms first, so they are preferred when terms overlap
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	highlights := make([]*SearchHighlight, 0)
+	for _, field := range DashboardSearchFields {
+		for _, line := range strings.Split(doc.Field(field), "\n") {
+			matches := re.FindAllStringIndex(line, -1)
+			if len(matches) == 0 {
+				continue
+			}
+			highlights = append(highlights, &SearchHighlight{
+				Field:    field,
+				Fragment: highlightFragment(line, matches),
+			})
+			break
+		}
+	}
+
+	return highlights
+}
+
+func highlightFragment(line string, matches [][]int) string {
+	start, end := 0, len(line)
+	if len(line) > maxHighlightLength {
+		start = matches[0][0] - maxHighlightLength/3
+		if start < 0 {
+			start = 0
+		}
+		end = start + maxHighlightLength
+		if end > len(line) {
+			end = len(line)
+		}
+		for start > 0 && !utf8.RuneStart(line[start]) {
+			start--
+		}
+		for end < len(line) && !utf8.RuneStart(line[end]) {
+			end--
+		}
+	}
+
+	b := strings.Builder{}
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m[0] < pos || m[1] > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(line[pos:m[0]]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(line[m[0]:m[1]]))
+		b.WriteString("</em>")
+		pos = m[1]
+	}
+	b.WriteString(html.EscapeString(line[pos:end]))
+	if end < len(line) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
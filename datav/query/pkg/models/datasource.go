@@ -15,14 +15,16 @@ package models
 import "time"
 
 type Datasource struct {
-	Id      int64             `json:"id"`
-	Name    string            `json:"name"`
-	Type    string            `json:"type"`
-	URL     string            `json:"url"`
-	Data    map[string]string `json:"data,omitempty"`
-	TeamId  int64             `json:"teamId"`
-	Created *time.Time        `json:"created,omitempty"`
-	Updated *time.Time        `json:"updated,omitempty"`
+	Id     int64             `json:"id"`
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	URL    string            `json:"url"`
+	Data   map[string]string `json:"data,omitempty"`
+	TeamId int64             `json:"teamId"`
+	// provisioned datasources are managed by provisioning files, they can't be changed in ui
+	Provisioned bool       `json:"provisioned,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	Updated     *time.Time `json:"updated,omitempty"`
 }
 
 const (
@@ -30,4 +32,5 @@ const (
 	DatasourceJaeger       = "jaeger"
 	DatasourceExternalHttp = "external-http"
 	DatasourceTestData     = "testdata"
+	DatasourceXobserve     = "xobserve"
 )
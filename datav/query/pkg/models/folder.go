@@ -0,0 +1,244 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+)
+
+// every team has a root folder, dashboards are created in it when no folder is specified
+const RootFolderTitle = "General"
+
+// Folder is used to organize the dashboards of a team, folders can be nested,
+// the root folder of a team has no parent(parent_id is 0)
+type Folder struct {
+	Id        int64     `json:"id"`
+	TeamId    int64     `json:"teamId"`
+	ParentId  int64     `json:"parentId"`
+	Title     string    `json:"title"`
+	CreatedBy int64     `json:"createdBy"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+}
+
+func (f *Folder) IsRoot() bool {
+	return f.ParentId == 0
+}
+
+// FolderPermission grants a user or a team to view, edit or admin the dashboards in a folder and its sub folders
+type FolderPermission struct {
+	FolderId    int64          `json:"folderId"`
+	SubjectType string         `json:"subjectType"`
+	SubjectId   int64          `json:"subjectId"`
+	SubjectName string         `json:"subjectName"`
+	Permission  PermissionType `json:"permission"`
+	Created     time.Time      `json:"created"`
+}
+
+const folderSelectSQL = "SELECT id,team_id,parent_id,title,created_by,created,updated FROM folder"
+
+type folderScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFolder(row folderScanner) (*Folder, error) {
+	f := &Folder{}
+	err := row.Scan(&f.Id, &f.TeamId, &f.ParentId, &f.Title, &f.CreatedBy, &f.Created, &f.Updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func QueryFolder(ctx context.Context, id int64) (*Folder, error) {
+	return scanFolder(db.Conn.QueryRowContext(ctx, folderSelectSQL+" WHERE id=?", id))
+}
+
+func QueryRootFolder(ctx context.Context, teamId int64) (*Folder, error) {
+	return scanFolder(db.Conn.QueryRowContext(ctx, folderSelectSQL+" WHERE team_id=? AND parent_id=0", teamId))
+}
+
+func QueryTeamFolders(ctx context.Context, teamId int64) ([]*Folder, error) {
+	rows, err := db.Conn.QueryContext(ctx, folderSelectSQL+" WHERE team_id=? ORDER BY parent_id,title", teamId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := make([]*Folder, 0)
+	for rows.Next() {
+		f, err := scanFolder(rows)
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, f)
+	}
+
+	return folders, nil
+}
+
+func CreateRootFolder(ctx context.Context, tx *sql.Tx, teamId int64, userId int64) (int64, error) {
+	now := time.Now()
+	res, err := tx.ExecContext(ctx, "INSERT INTO folder (team_id,parent_id,title,created_by,created,updated) VALUES (?,?,?,?,?,?)",
+		teamId, 0, RootFolderTitle, userId, now, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// QueryFolderPath returns the folder and its ancestors, the root folder is in front
+func QueryFolderPath(ctx context.Context, id int64) ([]*Folder, error) {
+	path := make([]*Folder, 0)
+	visited := make(map[int64]bool)
+	for id != 0 && !visited[id] {
+		visited[id] = true
+		f, err := QueryFolder(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		path = append([]*Folder{f}, path...)
+		id = f.ParentId
+	}
+
+	return path, nil
+}
+
+// FolderDescendants returns the id of the folder and all its sub folders
+func FolderDescendants(folders []*Folder, id int64) []int64 {
+	children := make(map[int64][]int64)
+	for _, f := range folders {
+		children[f.ParentId] = append(children[f.ParentId], f.Id)
+	}
+
+	ids := []int64{id}
+	visited := map[int64]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !visited[child] {
+				visited[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+
+	return ids
+}
+
+// EnsureFolderPath returns the folder at the given path under parent, the missing folders are created
+func EnsureFolderPath(ctx context.Context, parent *Folder, titles []string, userId int64) (*Folder, error) {
+	folder := parent
+	for _, title := range titles {
+		title = strings.TrimSpace(title)
+		if title == "" {
+			continue
+		}
+
+		var id int64
+		err := db.Conn.QueryRowContext(ctx, "SELECT id FROM folder WHERE team_id=? AND parent_id=? AND title=?", folder.TeamId, folder.Id, title).Scan(&id)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if id == 0 {
+			now := time.Now()
+			res, err := db.Conn.ExecContext(ctx, "INSERT INTO folder (team_id,parent_id,title,created_by,created,updated) VALUES (?,?,?,?,?,?)",
+				folder.TeamId, folder.Id, title, userId, now, now)
+			if err != nil {
+				return nil, err
+			}
+			id, _ = res.LastInsertId()
+		}
+
+		folder, err = QueryFolder(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return folder, nil
+}
+
+func QueryFolderPermissions(ctx context.Context, folderId int64) ([]*FolderPermission, error) {
+	rows, err := db.Conn.QueryContext(ctx, "SELECT folder_id,subject_type,subject_id,permission,created FROM folder_permission WHERE folder_id=?", folderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := make([]*FolderPermission, 0)
+	for rows.Next() {
+		p := &FolderPermission{}
+		err := rows.Scan(&p.FolderId, &p.SubjectType, &p.SubjectId, &p.Permission, &p.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		p.SubjectName = permissionSubjectName(ctx, p.SubjectType, p.SubjectId)
+
+		permissions = append(permissions, p)
+	}
+
+	return permissions, nil
+}
+
+// QueryGrantedFolders returns the folders granted to the user directly or to the teams the user is in,
+// when several permissions are granted on the same folder, the highest one is returned
+func QueryGrantedFolders(ctx context.Context, userId int64) (map[int64]PermissionType, error) {
+	where, args, err := grantedSubjectsQuery(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Conn.QueryContext(ctx, "SELECT folder_id,permission FROM folder_permission WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	granted := make(map[int64]PermissionType)
+	for rows.Next() {
+		var folderId int64
+		var permission PermissionType
+		err := rows.Scan(&folderId, &permission)
+		if err != nil {
+			return nil, err
+		}
+
+		if !granted[folderId].Contains(permission) {
+			granted[folderId] = permission
+		}
+	}
+
+	return granted, nil
+}
+
+func DeleteTeamFolders(ctx context.Context, teamId int64, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM folder_permission WHERE folder_id in (SELECT id FROM folder WHERE team_id=?)", teamId)
+	if err != nil {
+		return fmt.Errorf("delete folder permissions error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM folder_permission WHERE subject_type=? AND subject_id=?", PermissionSubjectTeam, teamId)
+	if err != nil {
+		return fmt.Errorf("delete team folder permissions error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM dashboard_acl WHERE subject_type=? AND subject_id=?", PermissionSubjectTeam, teamId)
+	if err != nil {
+		return fmt.Errorf("delete team dashboard acl error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM folder WHERE team_id=?", teamId)
+	if err != nil {
+		return fmt.Errorf("delete folders error: %w", err)
+	}
+
+	return nil
+}
@@ -12,7 +12,55 @@
 // limitations under the License.
 package models
 
+import "time"
+
 type DashboardHistory struct {
 	Dashboard *Dashboard `json:"dashboard"`
 	Changes   string     `json:"changes"` // describle what has been changed in this history
+	Author    int64      `json:"author,omitempty"`
+}
+
+// DashboardVersion is the metadata of a dashboard history, version is the version of dashboard when it's saved
+type DashboardVersion struct {
+	Version    int64      `json:"version"`
+	Created    *time.Time `json:"created"`
+	Changes    string     `json:"changes"`
+	Author     int64      `json:"author"`
+	AuthorName string     `json:"authorName,omitempty"`
+}
+
+type DashboardFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type DashboardPanelRef struct {
+	Id    interface{} `json:"id"`
+	Title string      `json:"title"`
+	Type  string      `json:"type"`
+	// top level fields of the panel which are changed
+	Fields []string `json:"fields,omitempty"`
+}
+
+type DashboardVariableRef struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DashboardDiff is the structural difference between two dashboard versions
+type DashboardDiff struct {
+	From   int64                   `json:"from"`
+	To     int64                   `json:"to"`
+	Fields []*DashboardFieldChange `json:"fields"`
+	Panels struct {
+		Added   []*DashboardPanelRef `json:"added"`
+		Removed []*DashboardPanelRef `json:"removed"`
+		Changed []*DashboardPanelRef `json:"changed"`
+	} `json:"panels"`
+	Variables struct {
+		Added   []*DashboardVariableRef `json:"added"`
+		Removed []*DashboardVariableRef `json:"removed"`
+		Changed []*DashboardVariableRef `json:"changed"`
+	} `json:"variables"`
 }
@@ -0,0 +1,289 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+	"github.com/xObserve/xObserve/query/pkg/utils/simplejson"
+)
+
+// LibraryPanel is a panel shared by the dashboards of a team, dashboards refer to it in the `libraryPanel`
+// field of their panels, e.g `"libraryPanel": {"id": 1}`, and it's resolved when loading the dashboards,
+// so the updates of a library panel are applied to all the dashboards using it
+type LibraryPanel struct {
+	Id          int64  `json:"id"`
+	TeamId      int64  `json:"teamId"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// panel json without id and gridPos, which are decided by the dashboards
+	Model     *simplejson.Json `json:"model"`
+	Version   int64            `json:"version"`
+	CreatedBy int64            `json:"createdBy"`
+	UpdatedBy int64            `json:"updatedBy"`
+	Created   time.Time        `json:"created"`
+	Updated   time.Time        `json:"updated"`
+	// number of dashboards using this panel
+	Connections int64 `json:"connections"`
+}
+
+// LibraryPanelConnection is a dashboard using a library panel
+type LibraryPanelConnection struct {
+	DashboardId string `json:"dashboardId"`
+	Title       string `json:"title"`
+	TeamId      int64  `json:"teamId"`
+	FolderId    int64  `json:"folderId"`
+	VisibleTo   string `json:"visibleTo"`
+}
+
+// panel fields which are owned by the dashboards rather than library panel
+var libraryPanelDashboardFields = []string{"id", "gridPos", "collapsed", "libraryPanel"}
+
+const libraryPanelSelectSQL = "SELECT id,team_id,title,description,model,version,created_by,updated_by,created,updated,(SELECT COUNT(*) FROM library_panel_dashboard WHERE library_panel_id=library_panel.id) FROM library_panel"
+
+type libraryPanelScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLibraryPanel(row libraryPanelScanner) (*LibraryPanel, error) {
+	p := &LibraryPanel{}
+	var desc sql.NullString
+	var model []byte
+	err := row.Scan(&p.Id, &p.TeamId, &p.Title, &desc, &model, &p.Version, &p.CreatedBy, &p.UpdatedBy, &p.Created, &p.Updated, &p.Connections)
+	if err != nil {
+		return nil, err
+	}
+	p.Description = desc.String
+
+	p.Model, err = simplejson.NewJson(model)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func QueryLibraryPanel(ctx context.Context, id int64) (*LibraryPanel, error) {
+	return scanLibraryPanel(db.Conn.QueryRowContext(ctx, libraryPanelSelectSQL+" WHERE id=?", id))
+}
+
+func QueryTeamLibraryPanels(ctx context.Context, teamId int64) ([]*LibraryPanel, error) {
+	rows, err := db.Conn.QueryContext(ctx, libraryPanelSelectSQL+" WHERE team_id=? ORDER BY title", teamId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	panels := make([]*LibraryPanel, 0)
+	for rows.Next() {
+		p, err := scanLibraryPanel(rows)
+		if err != nil {
+			return nil, err
+		}
+		panels = append(panels, p)
+	}
+
+	return panels, nil
+}
+
+func QueryLibraryPanelConnections(ctx context.Context, id int64) ([]*LibraryPanelConnection, error) {
+	rows, err := db.Conn.QueryContext(ctx, `SELECT dashboard.id,dashboard.title,dashboard.team_id,dashboard.folder_id,dashboard.visible_to FROM library_panel_dashboard
+		INNER JOIN dashboard ON dashboard.id=library_panel_dashboard.dashboard_id WHERE library_panel_dashboard.library_panel_id=? ORDER BY dashboard.title`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	connections := make([]*LibraryPanelConnection, 0)
+	for rows.Next() {
+		c := &LibraryPanelConnection{}
+		err := rows.Scan(&c.DashboardId, &c.Title, &c.TeamId, &c.FolderId, &c.VisibleTo)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, c)
+	}
+
+	return connections, nil
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// UpdateLibraryPanelConnections records the library panels used by a dashboard, it should be called
+// whenever the data of dashboard is changed
+func UpdateLibraryPanelConnections(ctx context.Context, tx execer, dashboardId string, data *simplejson.Json) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM library_panel_dashboard WHERE dashboard_id=?", dashboardId)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range LibraryPanelRefs(data) {
+		_, err := tx.ExecContext(ctx, "INSERT INTO library_panel_dashboard (library_panel_id,dashboard_id) VALUES (?,?)", id, dashboardId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LibraryPanelRefs returns the ids of library panels used in dashboard data, including the panels in rows
+func LibraryPanelRefs(data *simplejson.Json) []int64 {
+	ids := make([]int64, 0)
+	seen := make(map[int64]bool)
+	walkPanels(data, func(panel map[string]interface{}) {
+		id, ok := libraryPanelRef(panel)
+		if ok && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	})
+
+	return ids
+}
+
+// CheckLibraryPanelRefs checks that the library panels used in dashboard data exist and belong to the team of dashboard
+func CheckLibraryPanelRefs(ctx context.Context, teamId int64, data *simplejson.Json) error {
+	for _, id := range LibraryPanelRefs(data) {
+		p, err := QueryLibraryPanel(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("library panel `%d` not exist", id)
+			}
+			return err
+		}
+		if p.TeamId != teamId {
+			return fmt.Errorf("library panel `%s` doesn't belong to the team of dashboard", p.Title)
+		}
+	}
+
+	return nil
+}
+
+// ResolveLibraryPanels replaces the panels referring to library panels with the newest library panels,
+// the panels are kept as they are when their library panels are deleted or not belong to the team of dashboard
+func ResolveLibraryPanels(ctx context.Context, teamId int64, data *simplejson.Json) error {
+	if data == nil {
+		return nil
+	}
+
+	libraryPanels := make(map[int64]*LibraryPanel)
+	for _, id := range LibraryPanelRefs(data) {
+		p, err := QueryLibraryPanel(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return err
+		}
+		if p.TeamId == teamId {
+			libraryPanels[id] = p
+		}
+	}
+
+	if len(libraryPanels) == 0 {
+		return nil
+	}
+
+	walkPanels(data, func(panel map[string]interface{}) {
+		id, ok := libraryPanelRef(panel)
+		if !ok {
+			return
+		}
+		p, ok := libraryPanels[id]
+		if !ok {
+			return
+		}
+
+		// model is shared by the panels using it, so each panel gets its own copy
+		b, err := p.Model.Encode()
+		if err != nil {
+			return
+		}
+		model, err := simplejson.NewJson(b)
+		if err != nil {
+			return
+		}
+
+		kept := make(map[string]interface{})
+		for _, k := range libraryPanelDashboardFields {
+			if v, ok := panel[k]; ok {
+				kept[k] = v
+			}
+		}
+		for k := range panel {
+			delete(panel, k)
+		}
+		for k, v := range model.MustMap() {
+			panel[k] = v
+		}
+		for k, v := range kept {
+			panel[k] = v
+		}
+		panel["libraryPanel"] = map[string]interface{}{
+			"id":      p.Id,
+			"title":   p.Title,
+			"version": p.Version,
+		}
+	})
+
+	return nil
+}
+
+// UnlinkLibraryPanels removes the library panel references from dashboard data, the panels are kept as normal panels
+func UnlinkLibraryPanels(data *simplejson.Json) {
+	walkPanels(data, func(panel map[string]interface{}) {
+		delete(panel, "libraryPanel")
+	})
+}
+
+// walkPanels calls fn with every panel in dashboard data, including the panels in collapsed rows
+func walkPanels(data *simplejson.Json, fn func(panel map[string]interface{})) {
+	if data == nil {
+		return
+	}
+
+	panels, ok := data.Get("panels").Interface().([]interface{})
+	if !ok {
+		return
+	}
+	walkPanelList(panels, fn)
+}
+
+func walkPanelList(panels []interface{}, fn func(panel map[string]interface{})) {
+	for _, p := range panels {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fn(panel)
+		if children, ok := panel["panels"].([]interface{}); ok {
+			walkPanelList(children, fn)
+		}
+	}
+}
+
+func libraryPanelRef(panel map[string]interface{}) (int64, bool) {
+	ref, ok := panel["libraryPanel"].(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+
+	var id int64
+	switch v := ref["id"].(type) {
+	case json.Number:
+		id, _ = v.Int64()
+	case float64:
+		id = int64(v)
+	case int64:
+		id = v
+	}
+
+	return id, id > 0
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PermissionType is the permission granted to a user or team on a resource, e.g a folder or a dashboard
+type PermissionType string
+
+const (
+	PermissionView PermissionType = "view"
+	PermissionEdit PermissionType = "edit"
+	// admin permission can also manage the permissions of the resource
+	PermissionAdmin PermissionType = "admin"
+)
+
+const (
+	PermissionSubjectUser = "user"
+	PermissionSubjectTeam = "team"
+)
+
+func (p PermissionType) IsValid() bool {
+	return p == PermissionView || p == PermissionEdit || p == PermissionAdmin
+}
+
+// Contains returns whether the permission includes the other one, e.g edit permission contains view permission
+func (p PermissionType) Contains(other PermissionType) bool {
+	return permissionWeight(p) >= permissionWeight(other)
+}
+
+func permissionWeight(p PermissionType) int {
+	switch p {
+	case PermissionView:
+		return 1
+	case PermissionEdit:
+		return 2
+	case PermissionAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func IsValidPermissionSubject(subjectType string) bool {
+	return subjectType == PermissionSubjectUser || subjectType == PermissionSubjectTeam
+}
+
+// grantedSubjectsQuery builds the condition which matches the permissions granted to the user directly or to the teams the user is in
+func grantedSubjectsQuery(ctx context.Context, userId int64) (string, []interface{}, error) {
+	teamIds, err := QueryTeamsUserIn(ctx, userId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := "((subject_type=? AND subject_id=?)"
+	args := []interface{}{PermissionSubjectUser, userId}
+	if len(teamIds) > 0 {
+		query += fmt.Sprintf(" OR (subject_type=? AND subject_id in (%s))", strings.TrimSuffix(strings.Repeat("?,", len(teamIds)), ","))
+		args = append(args, PermissionSubjectTeam)
+		for _, id := range teamIds {
+			args = append(args, id)
+		}
+	}
+
+	return query + ")", args, nil
+}
+
+func permissionSubjectName(ctx context.Context, subjectType string, subjectId int64) string {
+	if subjectType == PermissionSubjectUser {
+		u, err := QueryUserById(ctx, subjectId)
+		if err != nil {
+			return ""
+		}
+		return u.Username
+	}
+
+	name, _ := QueryTeamNameById(ctx, subjectId)
+	return name
+}
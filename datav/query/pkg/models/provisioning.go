@@ -0,0 +1,70 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+)
+
+// DashboardProvisioning records which provisioning file a dashboard is loaded from
+type DashboardProvisioning struct {
+	DashboardId string `json:"dashboardId"`
+	Provider    string `json:"provider"`
+	File        string `json:"file"`
+	Checksum    string `json:"-"`
+	// when false, the dashboard can only be changed by updating its file
+	AllowUiUpdates bool      `json:"allowUiUpdates"`
+	Updated        time.Time `json:"updated"`
+}
+
+const dashboardProvisioningSelectSQL = "SELECT dashboard_id,provider,file,checksum,allow_ui_updates,updated FROM dashboard_provisioning"
+
+func scanDashboardProvisioning(row folderScanner) (*DashboardProvisioning, error) {
+	p := &DashboardProvisioning{}
+	err := row.Scan(&p.DashboardId, &p.Provider, &p.File, &p.Checksum, &p.AllowUiUpdates, &p.Updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// QueryDashboardProvisioning returns sql.ErrNoRows if the dashboard is not provisioned
+func QueryDashboardProvisioning(ctx context.Context, dashboardId string) (*DashboardProvisioning, error) {
+	return scanDashboardProvisioning(db.Conn.QueryRowContext(ctx, dashboardProvisioningSelectSQL+" WHERE dashboard_id=?", dashboardId))
+}
+
+// QueryAllDashboardProvisioning returns the provisioning records of all providers
+func QueryAllDashboardProvisioning(ctx context.Context) ([]*DashboardProvisioning, error) {
+	rows, err := db.Conn.QueryContext(ctx, dashboardProvisioningSelectSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*DashboardProvisioning, 0)
+	for rows.Next() {
+		p, err := scanDashboardProvisioning(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+
+	return res, nil
+}
+
+// IsDashboardReadonly returns true when the dashboard is provisioned and can't be changed in ui
+func IsDashboardReadonly(ctx context.Context, dashboardId string) (bool, error) {
+	p, err := QueryDashboardProvisioning(ctx, dashboardId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return !p.AllowUiUpdates, nil
+}
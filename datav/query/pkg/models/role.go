@@ -16,12 +16,13 @@ type RoleType string
 
 const (
 	ROLE_VIEWER      = "Viewer"
+	ROLE_EDITOR      = "Editor"
 	ROLE_ADMIN       = "Admin"
 	ROLE_SUPER_ADMIN = "SuperAdmin"
 )
 
 func (r RoleType) IsValid() bool {
-	return r == ROLE_VIEWER || r == ROLE_ADMIN
+	return r == ROLE_VIEWER || r == ROLE_EDITOR || r == ROLE_ADMIN
 }
 
 func (r RoleType) IsSuperAdmin() bool {
@@ -32,8 +33,23 @@ func (r RoleType) IsAdmin() bool {
 	return r == ROLE_ADMIN || r == ROLE_SUPER_ADMIN
 }
 
+// IsEditor returns whether the role can edit dashboards, admins are also editors
 func (r RoleType) IsEditor() bool {
-	return r == ROLE_ADMIN
+	return r == ROLE_EDITOR || r.IsAdmin()
+}
+
+// RolePermission returns the permission of a team role on the dashboards and folders of the team
+func RolePermission(r RoleType) PermissionType {
+	switch {
+	case r.IsAdmin():
+		return PermissionAdmin
+	case r.IsEditor():
+		return PermissionEdit
+	case r == ROLE_VIEWER:
+		return PermissionView
+	default:
+		return ""
+	}
 }
 
 func IsAdmin(r RoleType) bool {
@@ -44,6 +60,8 @@ func RoleSortWeight(role RoleType) int {
 	switch role {
 	case ROLE_VIEWER:
 		return 0
+	case ROLE_EDITOR:
+		return 1
 	case ROLE_ADMIN:
 		return 2
 	case ROLE_SUPER_ADMIN:
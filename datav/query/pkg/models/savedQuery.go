@@ -0,0 +1,114 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+)
+
+const (
+	SavedQueryVisibleToOwner = "owner"
+	SavedQueryVisibleToTeam  = "team"
+)
+
+// SavedQuery is a log or trace search saved by user, which can be reused in the explorers and shared by url
+type SavedQuery struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// datasource type, e.g xobserve
+	DatasourceType string `json:"datasourceType"`
+	// the explorer which the query is used in, e.g logs, traces
+	Scope  string                 `json:"scope"`
+	Query  string                 `json:"query"`
+	Params map[string]interface{} `json:"params"`
+	Tags   []string               `json:"tags"`
+
+	OwnerId   int64  `json:"ownerId"`
+	TeamId    int64  `json:"teamId"`
+	VisibleTo string `json:"visibleTo"`
+
+	UseCount int64      `json:"useCount"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+	Created  time.Time  `json:"created"`
+	Updated  time.Time  `json:"updated"`
+}
+
+// CanView returns whether the user can view the saved query, team members can only view the team visible queries
+func (q *SavedQuery) CanView(ctx context.Context, userId int64) bool {
+	if q.OwnerId == userId {
+		return true
+	}
+
+	if q.VisibleTo != SavedQueryVisibleToTeam {
+		return false
+	}
+
+	_, err := QueryTeamMember(ctx, q.TeamId, userId)
+	return err == nil
+}
+
+const savedQuerySelectSQL = "SELECT id,name,description,ds_type,scope,query,params,tags,owner_id,team_id,visible_to,use_count,last_used,created,updated FROM saved_query"
+
+type savedQueryScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSavedQuery(row savedQueryScanner) (*SavedQuery, error) {
+	q := &SavedQuery{}
+	var rawParams, rawTags []byte
+	err := row.Scan(&q.Id, &q.Name, &q.Description, &q.DatasourceType, &q.Scope, &q.Query, &rawParams, &rawTags, &q.OwnerId, &q.TeamId, &q.VisibleTo, &q.UseCount, &q.LastUsed, &q.Created, &q.Updated)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawParams != nil {
+		err = json.Unmarshal(rawParams, &q.Params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	q.Tags = make([]string, 0)
+	if rawTags != nil {
+		err = json.Unmarshal(rawTags, &q.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+func QuerySavedQuery(ctx context.Context, id int64) (*SavedQuery, error) {
+	return scanSavedQuery(db.Conn.QueryRowContext(ctx, savedQuerySelectSQL+" WHERE id=?", id))
+}
+
+// QueryTeamSavedQueries returns the saved queries in a team which the user can view, the recently used ones are in front
+func QueryTeamSavedQueries(ctx context.Context, teamId int64, userId int64, scope string) ([]*SavedQuery, error) {
+	query := savedQuerySelectSQL + " WHERE team_id=? AND (visible_to=? OR owner_id=?)"
+	args := []interface{}{teamId, SavedQueryVisibleToTeam, userId}
+	if scope != "" {
+		query += " AND scope=?"
+		args = append(args, scope)
+	}
+
+	rows, err := db.Conn.QueryContext(ctx, query+" ORDER BY last_used DESC, updated DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queries := make([]*SavedQuery, 0)
+	for rows.Next() {
+		q, err := scanSavedQuery(rows)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+
+	return queries, nil
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/xObserve/xObserve/query/pkg/db"
+)
+
+// Snapshot is a point-in-time view of a dashboard, it can be viewed by anyone who knows the key,
+// the query results of panels are stored in it, so datasources are never queried when viewing
+type Snapshot struct {
+	Key         string        `json:"key"`
+	DashboardId string        `json:"dashboardId"`
+	TeamId      int64         `json:"teamId"`
+	Title       string        `json:"title"`
+	Data        *SnapshotData `json:"data,omitempty"`
+	CreatedBy   int64         `json:"createdBy"`
+	// nil means never expire
+	Expires *time.Time `json:"expires"`
+	Created time.Time  `json:"created"`
+}
+
+type SnapshotData struct {
+	Dashboard *Dashboard `json:"dashboard"`
+	// panel id -> query results of the panel
+	PanelData map[string]json.RawMessage `json:"panelData"`
+	TimeRange struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+	} `json:"timeRange"`
+	// variables with the selected values
+	Variables json.RawMessage `json:"variables"`
+	// only the id, name and type of datasources are kept, they are used to render panels
+	Datasources []*BundleDatasource `json:"datasources"`
+}
+
+func (s *Snapshot) IsExpired() bool {
+	return s.Expires != nil && s.Expires.Before(time.Now())
+}
+
+func QuerySnapshot(ctx context.Context, key string) (*Snapshot, error) {
+	s := &Snapshot{Key: key}
+	var rawData []byte
+	err := db.Conn.QueryRowContext(ctx, "SELECT dashboard_id,team_id,title,data,created_by,expires,created FROM snapshot WHERE id=?", key).Scan(
+		&s.DashboardId, &s.TeamId, &s.Title, &rawData, &s.CreatedBy, &s.Expires, &s.Created)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(rawData, &s.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// QueryDashboardSnapshots returns the snapshots of a dashboard without data
+func QueryDashboardSnapshots(ctx context.Context, dashboardId string) ([]*Snapshot, error) {
+	rows, err := db.Conn.QueryContext(ctx, "SELECT id,dashboard_id,team_id,title,created_by,expires,created FROM snapshot WHERE dashboard_id=? ORDER BY created DESC", dashboardId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make([]*Snapshot, 0)
+	for rows.Next() {
+		s := &Snapshot{}
+		err := rows.Scan(&s.Key, &s.DashboardId, &s.TeamId, &s.Title, &s.CreatedBy, &s.Expires, &s.Created)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, nil
+}
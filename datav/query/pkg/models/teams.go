@@ -279,8 +279,14 @@ func CreateTeam(ctx context.Context, tx *sql.Tx, tenantId int64, userId int64, n
 		return 0, err
 	}
 
+	// create root folder
+	folderId, err := CreateRootFolder(ctx, tx, id, userId)
+	if err != nil {
+		return 0, fmt.Errorf("create root folder error: %w", err)
+	}
+
 	// insert home dashboard
-	d, err := ImportFromJSON(tx, storageData.HomeDashboard, id, userId)
+	d, err := ImportFromJSON(tx, storageData.HomeDashboard, id, folderId, userId)
 	if err != nil && !e.IsErrUniqueConstraint(err) {
 		return 0, fmt.Errorf("init home dashboard error: %w", err)
 	}
@@ -380,6 +386,18 @@ func DeleteTeam(ctx context.Context, teamId int64, tx *sql.Tx) error {
 		return errors.New("delete team dashboards error:" + err.Error())
 	}
 
+	// delete team library panels, their connections have been deleted with dashboards
+	_, err = tx.ExecContext(ctx, "DELETE FROM library_panel WHERE team_id=?", teamId)
+	if err != nil {
+		return errors.New("delete team library panels error:" + err.Error())
+	}
+
+	// delete team folders
+	err = DeleteTeamFolders(ctx, teamId, tx)
+	if err != nil {
+		return err
+	}
+
 	// delete team datasources
 	_, err = tx.ExecContext(ctx, "DELETE FROM datasource WHERE team_id=?", teamId)
 	if err != nil {
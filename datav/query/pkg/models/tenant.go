@@ -3,6 +3,8 @@ package models
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +15,12 @@ import (
 const DefaultTenant = "default"
 const DefaultTenantId = 1
 
+// key of the telemetry tenants in gin context, set by datasource plugins before querying
+const TelemetryTenantsKey = "telemetryTenants"
+
+// AllTelemetryTenants can only be set by website admin apis, e.g usage explorer, it can't be configured in tenant setting
+const AllTelemetryTenants = "*"
+
 type Tenant struct {
 	Id          int64     `json:"id"`
 	Name        string    `json:"name"`
@@ -24,6 +32,13 @@ type Tenant struct {
 	Created     time.Time `json:"created"`
 	Status      int       `json:"status"`
 	CurrentRole RoleType  `json:"-"`
+	// tenant ids written by collector(xobserve.tenant), which the tenant can read from observability datasources
+	TelemetryTenants []string `json:"telemetryTenants"`
+}
+
+// stored in the data column of tenant table
+type TenantData struct {
+	TelemetryTenants []string `json:"telemetryTenants,omitempty"`
 }
 
 type Tenants []*Tenant
@@ -34,8 +49,14 @@ func (s Tenants) Less(i, j int) bool {
 	return s[i].Created.Unix() > s[j].Created.Unix()
 }
 
-func GetTenant(c *gin.Context) string {
-	return DefaultTenant
+// GetTelemetryTenants returns the telemetry tenants which current request can read
+func GetTelemetryTenants(c *gin.Context) []string {
+	tenants, ok := c.Get(TelemetryTenantsKey)
+	if ok {
+		return tenants.([]string)
+	}
+
+	return []string{DefaultTenant}
 }
 
 type TenantUser struct {
@@ -74,14 +95,66 @@ func QueryTenant(ctx context.Context, tenantId int64) (*Tenant, error) {
 	tenant := &Tenant{
 		Id: tenantId,
 	}
-	err := db.Conn.QueryRowContext(ctx, `SELECT  name,is_public,created FROM tenant WHERE id=? and status!=?`, tenantId, common.StatusDeleted).Scan(&tenant.Name, &tenant.IsPublic, &tenant.Created)
+	var data []byte
+	err := db.Conn.QueryRowContext(ctx, `SELECT  name,is_public,data,created FROM tenant WHERE id=? and status!=?`, tenantId, common.StatusDeleted).Scan(&tenant.Name, &tenant.IsPublic, &data, &tenant.Created)
 	if err != nil {
 		return nil, err
 	}
 
+	tenant.TelemetryTenants = getTelemetryTenants(tenant, data)
+
 	return tenant, nil
 }
 
+// QueryTelemetryTenants returns the telemetry tenants which the tenant can read
+func QueryTelemetryTenants(ctx context.Context, tenantId int64) ([]string, error) {
+	tenant := &Tenant{
+		Id: tenantId,
+	}
+	var data []byte
+	err := db.Conn.QueryRowContext(ctx, `SELECT name,data FROM tenant WHERE id=?`, tenantId).Scan(&tenant.Name, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return getTelemetryTenants(tenant, data), nil
+}
+
+// when not configured, the default tenant reads the default telemetry tenant,
+// and other tenants read the telemetry tenant with the same name
+func getTelemetryTenants(tenant *Tenant, data []byte) []string {
+	tenantData := &TenantData{}
+	if len(data) > 0 {
+		json.Unmarshal(data, &tenantData)
+	}
+
+	if len(tenantData.TelemetryTenants) > 0 {
+		return tenantData.TelemetryTenants
+	}
+
+	if tenant.Id == DefaultTenantId {
+		return []string{DefaultTenant}
+	}
+
+	return []string{tenant.Name}
+}
+
+// NormalizeTelemetryTenants trims the tenant ids and removes the empty and duplicated ones
+func NormalizeTelemetryTenants(tenants []string) []string {
+	res := make([]string, 0, len(tenants))
+	exist := make(map[string]bool)
+	for _, t := range tenants {
+		t = strings.TrimSpace(t)
+		if t == "" || t == AllTelemetryTenants || exist[t] {
+			continue
+		}
+		exist[t] = true
+		res = append(res, t)
+	}
+
+	return res
+}
+
 func QueryTenantIdByTeamId(ctx context.Context, teamId int64) (int64, error) {
 	var tenantId int64
 	err := db.Conn.QueryRowContext(ctx, `SELECT  tenant_id FROM team WHERE id=?`, teamId).Scan(&tenantId)
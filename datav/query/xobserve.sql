@@ -130,7 +130,10 @@ CREATE TABLE IF NOT EXISTS dashboard (
     data MEDIUMTEXT NOT NULL,
     weight TINYINT DEFAULT 0,
     created DATETIME NOT NULL,
-    updated DATETIME NOT NULL
+    updated DATETIME NOT NULL,
+    version INTEGER DEFAULT 1,
+    updated_by INTEGER DEFAULT 0,
+    folder_id INTEGER DEFAULT 0
 );
 
 
@@ -141,11 +144,14 @@ CREATE TABLE IF NOT EXISTS dashboard_history (
     dashboard_id VARCHAR(40),
     version DATETIME,
     changes TEXT,
-    history MEDIUMTEXT
+    history MEDIUMTEXT,
+    author INTEGER DEFAULT 0,
+    dashboard_version INTEGER DEFAULT 0
 );
 
 
 CREATE UNIQUE INDEX  dashboard_id_version ON dashboard_history (dashboard_id,version);
+CREATE INDEX  dashboard_history_dashboard_version ON dashboard_history (dashboard_id,dashboard_version);
 
 
 CREATE TABLE IF NOT EXISTS datasource (
@@ -155,6 +161,7 @@ CREATE TABLE IF NOT EXISTS datasource (
     url VARCHAR(255),
     data MEDIUMTEXT,
     team_id INTEGER NOT NULL,
+    provisioned BOOL DEFAULT false,
     created DATETIME NOT NULL,
     updated DATETIME NOT NULL
 );
@@ -200,3 +207,112 @@ CREATE TABLE IF NOT EXISTS annotation (
 
 CREATE INDEX  annotation_npid ON annotation (namespace_id);
 CREATE UNIQUE INDEX  annotation_time_ng ON annotation (namespace_id,group_id,time);
+
+CREATE TABLE IF NOT EXISTS saved_query (
+    id  INTEGER PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    ds_type VARCHAR(32) NOT NULL,
+    scope VARCHAR(32) NOT NULL,
+    query TEXT,
+    params MEDIUMTEXT,
+    tags TEXT,
+    owner_id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    visible_to VARCHAR(32) DEFAULT 'owner',
+    use_count INTEGER DEFAULT 0,
+    last_used DATETIME,
+    created DATETIME NOT NULL,
+    updated DATETIME NOT NULL
+);
+
+-- when upgrading from a version without folders, create folder and folder_permission tables and
+-- their indexes, the dashboards are moved to the root folders of their teams on next startup
+CREATE TABLE IF NOT EXISTS folder (
+    id  INTEGER PRIMARY KEY AUTO_INCREMENT,
+    team_id INTEGER NOT NULL,
+    parent_id INTEGER DEFAULT 0,
+    title VARCHAR(255) NOT NULL,
+    created_by INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    updated DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS folder_permission (
+    folder_id INTEGER NOT NULL,
+    subject_type VARCHAR(32) NOT NULL,
+    subject_id INTEGER NOT NULL,
+    permission VARCHAR(32) NOT NULL,
+    created DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dashboard_acl (
+    dashboard_id VARCHAR(40) NOT NULL,
+    subject_type VARCHAR(32) NOT NULL,
+    subject_id INTEGER NOT NULL,
+    permission VARCHAR(32) NOT NULL,
+    created DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dashboard_provisioning (
+    dashboard_id VARCHAR(40) PRIMARY KEY NOT NULL,
+    provider VARCHAR(255) NOT NULL,
+    file VARCHAR(1024) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    allow_ui_updates BOOL NOT NULL DEFAULT false,
+    updated DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS snapshot (
+    id VARCHAR(64) PRIMARY KEY NOT NULL,
+    dashboard_id VARCHAR(40) NOT NULL,
+    team_id INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    data MEDIUMTEXT NOT NULL,
+    created_by INTEGER NOT NULL,
+    expires DATETIME,
+    created DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS library_panel (
+    id  INTEGER PRIMARY KEY AUTO_INCREMENT,
+    team_id INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    model MEDIUMTEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_by INTEGER NOT NULL,
+    updated_by INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    updated DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS library_panel_dashboard (
+    library_panel_id INTEGER NOT NULL,
+    dashboard_id VARCHAR(40) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dashboard_search (
+    dashboard_id VARCHAR(40) PRIMARY KEY NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    tags TEXT,
+    panels TEXT,
+    descriptions TEXT,
+    queries MEDIUMTEXT,
+    updated DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX  saved_query_name ON saved_query (team_id,owner_id,name);
+CREATE INDEX  saved_query_team ON saved_query (team_id);
+
+CREATE INDEX  dashboard_folder_id ON dashboard (folder_id);
+CREATE UNIQUE INDEX  folder_title ON folder (team_id,parent_id,title);
+CREATE UNIQUE INDEX  folder_permission_subject ON folder_permission (folder_id,subject_type,subject_id);
+CREATE UNIQUE INDEX  dashboard_acl_subject ON dashboard_acl (dashboard_id,subject_type,subject_id);
+CREATE INDEX  dashboard_provisioning_provider ON dashboard_provisioning (provider);
+CREATE INDEX  snapshot_dashboard ON snapshot (dashboard_id);
+CREATE UNIQUE INDEX  library_panel_title ON library_panel (team_id,title);
+CREATE UNIQUE INDEX  library_panel_dashboard_id ON library_panel_dashboard (library_panel_id,dashboard_id);
+CREATE INDEX  library_panel_dashboard_dashboard ON library_panel_dashboard (dashboard_id);
+CREATE FULLTEXT INDEX  dashboard_search_title ON dashboard_search (title);
+CREATE FULLTEXT INDEX  dashboard_search_fulltext ON dashboard_search (title,tags,panels,descriptions,queries);
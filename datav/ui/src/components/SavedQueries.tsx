@@ -0,0 +1,190 @@
+// Copyright 2023 xObserve.io Team
+
+// Saved queries of log and trace explorers, a saved query can be shared by url with `savedQuery` param
+
+import {
+  Box,
+  Button,
+  Checkbox,
+  Flex,
+  HStack,
+  IconButton,
+  Input,
+  Popover,
+  PopoverBody,
+  PopoverContent,
+  PopoverTrigger,
+  Portal,
+  Text,
+  VStack,
+  useToast,
+} from '@chakra-ui/react'
+import { useStore } from '@nanostores/react'
+import React, { useEffect, useState } from 'react'
+import { FaRegBookmark, FaTrashAlt } from 'react-icons/fa'
+import { useSearchParam } from 'react-use'
+import CopyToClipboard from 'src/components/CopyToClipboard'
+import { $config } from 'src/data/configs/config'
+import { SavedQuery } from 'types/savedQuery'
+import { requestApi } from 'utils/axios/request'
+import { isEmpty } from 'utils/validate'
+
+interface Props {
+  scope: string
+  datasourceType: string
+  query: string
+  params?: Record<string, any>
+  onSelect: (q: SavedQuery) => void
+}
+
+const SavedQueries = ({
+  scope,
+  datasourceType,
+  query,
+  params,
+  onSelect,
+}: Props) => {
+  const toast = useToast()
+  const config = useStore($config)
+  const savedQueryId = useSearchParam('savedQuery')
+  const [queries, setQueries] = useState<SavedQuery[]>([])
+  const [name, setName] = useState('')
+  const [shareWithTeam, setShareWithTeam] = useState(false)
+
+  useEffect(() => {
+    if (!isEmpty(savedQueryId)) {
+      loadSharedQuery(savedQueryId)
+    }
+  }, [savedQueryId])
+
+  const loadQueries = async () => {
+    const res = await requestApi.get(
+      `/savedQuery/team/${config.currentTeam}?scope=${scope}&datasourceType=${datasourceType}`,
+    )
+    setQueries(res.data)
+  }
+
+  const loadSharedQuery = async (id) => {
+    const res = await requestApi.get(`/savedQuery/byId/${id}`)
+    applyQuery(res.data)
+  }
+
+  const applyQuery = (q: SavedQuery) => {
+    onSelect(q)
+    requestApi.post(`/savedQuery/used/${q.id}`)
+  }
+
+  const saveQuery = async () => {
+    if (isEmpty(name)) {
+      return
+    }
+
+    await requestApi.post('/savedQuery/new', {
+      name,
+      datasourceType,
+      scope,
+      query,
+      params,
+      teamId: config.currentTeam,
+      visibleTo: shareWithTeam ? 'team' : 'owner',
+    })
+    toast({
+      title: 'Query saved',
+      status: 'success',
+      duration: 3000,
+      isClosable: true,
+    })
+    setName('')
+    loadQueries()
+  }
+
+  const deleteQuery = async (id) => {
+    await requestApi.delete(`/savedQuery/${id}`)
+    loadQueries()
+  }
+
+  const getShareUrl = (id) => {
+    const url = new URL(window.location.href)
+    url.searchParams.set('savedQuery', id)
+    return url.toString()
+  }
+
+  return (
+    <Popover placement='bottom-end' onOpen={loadQueries} isLazy>
+      <PopoverTrigger>
+        <IconButton
+          aria-label='saved queries'
+          icon={<FaRegBookmark />}
+          size='sm'
+          variant='ghost'
+        />
+      </PopoverTrigger>
+      <Portal>
+        <PopoverContent width='400px'>
+          <PopoverBody>
+            <VStack alignItems='left' spacing={1}>
+              {queries.length == 0 && (
+                <Text className='color-text' fontSize='0.9rem'>
+                  No saved queries
+                </Text>
+              )}
+              {queries.map((q) => (
+                <Flex
+                  key={q.id}
+                  justifyContent='space-between'
+                  alignItems='center'
+                  className='hover-bg'
+                  px='1'
+                >
+                  <Box
+                    cursor='pointer'
+                    flex='1'
+                    onClick={() => applyQuery(q)}
+                  >
+                    <Text fontSize='0.9rem'>{q.name}</Text>
+                    <Text fontSize='0.8rem' className='color-text' noOfLines={1}>
+                      {q.query}
+                    </Text>
+                  </Box>
+                  <HStack spacing={2}>
+                    <CopyToClipboard
+                      copyText={getShareUrl(q.id)}
+                      tooltipTitle='Copy share link'
+                      fontSize='0.8rem'
+                    />
+                    <FaTrashAlt
+                      fontSize='0.8rem'
+                      cursor='pointer'
+                      onClick={() => deleteQuery(q.id)}
+                    />
+                  </HStack>
+                </Flex>
+              ))}
+            </VStack>
+            <HStack mt='3'>
+              <Input
+                size='sm'
+                placeholder='Save current query as...'
+                value={name}
+                onChange={(e) => setName(e.currentTarget.value)}
+              />
+              <Button size='sm' onClick={saveQuery} isDisabled={isEmpty(query) && isEmpty(params)}>
+                Save
+              </Button>
+            </HStack>
+            <Checkbox
+              mt='2'
+              size='sm'
+              isChecked={shareWithTeam}
+              onChange={(e) => setShareWithTeam(e.currentTarget.checked)}
+            >
+              Share with team
+            </Checkbox>
+          </PopoverBody>
+        </PopoverContent>
+      </Portal>
+    </Popover>
+  )
+}
+
+export default SavedQueries
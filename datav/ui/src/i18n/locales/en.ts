@@ -53,6 +53,7 @@ export const commonMsg = i18n('common', {
   custom: 'Custom',
   createdBy: 'Created By',
   Viewer: 'Viewer',
+  Editor: 'Editor',
   Admin: 'Admin',
   SuperAdmin: 'Super Admin',
   password: 'Password',
@@ -295,6 +296,17 @@ export const newMsg = i18n('new', {
   jsonInvalid: 'Meta json is not valid',
   dsToast: 'Datasource added, redirecting...',
   testDsFailed: 'Test failed',
+  bundleTips: params(
+    'Bundle with {dashboards} dashboards, {variables} variables and {datasources} datasources',
+  ),
+  onConflict: 'On conflict',
+  conflictFail: 'Fail',
+  conflictSkip: 'Skip',
+  conflictOverwrite: 'Overwrite',
+  conflictNew: 'Create new',
+  bundleImported: params('{count} dashboards imported'),
+  provisionedDsTips:
+    'This datasource is provisioned from files, it can only be changed by updating the provisioning file',
 })
 
 export const dashboardMsg = i18n('dashboard', {
@@ -317,6 +329,17 @@ export const dashboardMsg = i18n('dashboard', {
   starTips: 'Mark as favourite',
   unstarTips: 'Unmark as favourite',
   shareDashboard: 'Share dashboard',
+  snapshot: 'Snapshot',
+  snapshotHelp:
+    'Create a snapshot with the current data of panels, anyone with the link can view it without login, datasources are never queried.',
+  snapshotExpires: 'Expires',
+  createSnapshot: 'Create snapshot',
+  snapshotExpiresAt: params('expires at {time}'),
+  snapshotNeverExpires: 'never expires',
+  snapshotNotFound: 'Snapshot not found or has expired',
+  addLibraryPanel: 'Add library panel',
+  noLibraryPanel: 'There is no library panel in this team',
+  libraryPanelUsedBy: params('used by {count} dashboards'),
 })
 
 export const dashboardSaveMsg = i18n('dashboardSave', {
@@ -345,6 +368,11 @@ export const dashboardSaveMsg = i18n('dashboardSave', {
     'click here to continue use current dashboard, and stop previewing',
   current: 'Currrent',
   preview: 'Preview',
+  conflictTitle: 'Dashboard has been changed',
+  conflictTips: params(
+    'This dashboard has been saved by {name} at {time}, saving it will overwrite their changes.',
+  ),
+  forceOverwrite: 'Overwrite',
 })
 
 export const dashboardSettingMsg = i18n('dashboardSetting', {
@@ -353,6 +381,16 @@ export const dashboardSettingMsg = i18n('dashboardSetting', {
   tootip: 'Shared tooltip',
   visibleTo: 'Visible to',
   visibleToTips: 'Controls who can view this dashboard',
+  permissions: 'Permissions',
+  permissionsTips:
+    'Grant users or teams to view, edit or admin this dashboard, in addition to their team roles and folder permissions',
+  subjectType: 'Type',
+  subjectId: 'User or team id',
+  permission: 'Permission',
+  addPermission: 'Add permission',
+  folder: 'Folder',
+  folderTips: 'Dashboard inherits the permissions of its folder',
+  movedToFolder: 'Dashboard moved',
   tootipTips:
     'Show tooltips at the same timeline position across all panels, need reload page to take effect',
   hideVars: 'Hide global variables',
@@ -403,6 +441,7 @@ export const dashboardSettingMsg = i18n('dashboardSetting', {
   lazyRender: 'Lazy render panels',
   lazyRenderTips:
     'A panle will be renderting only when our screen has scrolled to this panel',
+  exportBundle: 'Export with dependencies',
 })
 
 export const timePickerMsg = i18n('timePicker', {
@@ -427,6 +466,17 @@ export const variableMsg = i18n('variable', {
 
 export const panelMsg = i18n('panel', {
   hidePanel: 'Hide panel',
+  saveAsLibraryPanel: 'Save as library panel',
+  updateLibraryPanel: 'Update library panel',
+  unlinkLibraryPanel: 'Unlink library panel',
+  libraryPanelSaved:
+    'Saved as library panel, save the dashboard to keep the link',
+  libraryPanelUpdated: params(
+    'Library panel updated, {count} dashboards use it',
+  ),
+  libraryPanelTips: params(
+    'Linked to library panel {name}, changes are shared only after updating the library panel',
+  ),
   viewPanel: 'View Panel',
   exitlView: 'Exit View',
   debugPanel: 'Debug Panel',
@@ -723,7 +773,13 @@ export const alertMsg = i18n('alert', {
 export const searchMsg = i18n('search', {
   searchDashboards: 'Search dashboards',
   searchDashboardsTips: 'Find the dashboards you are interested in',
-  searchInput: 'search dashboard by name or id',
+  searchInput:
+    'search dashboard by name, id, panel titles, descriptions or queries',
+  highlightTitle: 'Title',
+  highlightTags: 'Tags',
+  highlightPanels: 'Panel',
+  highlightDescriptions: 'Description',
+  highlightQueries: 'Query',
   filterTeams: 'Filter by teams',
   teamsView: 'Teams view',
   listView: 'List view',
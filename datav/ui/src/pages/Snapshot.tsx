@@ -0,0 +1,74 @@
+// Copyright 2023 xObserve.io Team
+
+import React, { useEffect, useState } from 'react'
+import { useParams } from 'react-router-dom'
+import { Box } from '@chakra-ui/react'
+import DashboardWrapper from 'src/views/dashboard/Dashboard'
+import NotFoundPage from './NotFound'
+import Loading from 'components/loading/Loading'
+import { requestApi } from 'utils/axios/request'
+import { Snapshot } from 'types/snapshot'
+import { $snapshot } from 'src/views/dashboard/store/snapshot'
+import { $datasources } from 'src/views/datasource/store'
+import { $time } from 'components/DatePicker/store'
+import { dateTimeFormat } from 'utils/datetime/formatter'
+import { useStore } from '@nanostores/react'
+import { dashboardMsg } from 'src/i18n/locales/en'
+
+// SnapshotPage renders a dashboard snapshot with the data captured in it,
+// it can be viewed without login
+const SnapshotPage = () => {
+  const t = useStore(dashboardMsg)
+  const key = useParams().key
+  const [snapshot, setSnapshot] = useState<Snapshot>(null)
+  const [error, setError] = useState<string>(null)
+
+  useEffect(() => {
+    loadSnapshot()
+    return () => {
+      $snapshot.set(null)
+    }
+  }, [key])
+
+  const loadSnapshot = async () => {
+    try {
+      const res = await requestApi.get(`/snapshot/${key}`)
+      const s: Snapshot = res.data
+      const start = new Date(s.data.timeRange.start)
+      const end = new Date(s.data.timeRange.end)
+      $time.set({
+        start,
+        end,
+        startRaw: dateTimeFormat(start),
+        endRaw: dateTimeFormat(end),
+        sub: 0,
+      })
+      $datasources.set(s.data.datasources as any)
+      $snapshot.set(s)
+      setSnapshot(s)
+    } catch (err) {
+      // the detail of error has been shown by requestApi
+      setError(t.snapshotNotFound)
+    }
+  }
+
+  return (
+    <>
+      {snapshot && (
+        <DashboardWrapper
+          key={snapshot.key}
+          sideWidth={0}
+          rawDashboard={snapshot.data.dashboard}
+        />
+      )}
+      {error && <NotFoundPage message={error} />}
+      {!snapshot && !error && (
+        <Box position='fixed' top='50vh' left='50vw'>
+          <Loading />
+        </Box>
+      )}
+    </>
+  )
+}
+
+export default SnapshotPage
@@ -219,6 +219,7 @@ const TeamMembers = ({ team }: { team: Team }) => {
               >
                 <Stack direction='row'>
                   <Radio value={Role.Viewer}>{Role.Viewer}</Radio>
+                  <Radio value={Role.EDITOR}>{Role.EDITOR}</Radio>
                   <Radio value={Role.ADMIN}>{Role.ADMIN}</Radio>
                   {memberInEdit.role == Role.SUPER_ADMIN && (
                     <Radio value={Role.SUPER_ADMIN}>{Role.SUPER_ADMIN}</Radio>
@@ -283,6 +284,7 @@ const TeamMembers = ({ team }: { team: Team }) => {
                 >
                   <Stack direction='row'>
                     <Radio value={Role.Viewer}>{t[Role.Viewer]}</Radio>
+                    <Radio value={Role.EDITOR}>{t[Role.EDITOR]}</Radio>
                     <Radio value={Role.ADMIN}>{t[Role.ADMIN]}</Radio>
                   </Stack>
                 </RadioGroup>
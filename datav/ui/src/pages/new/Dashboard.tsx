@@ -38,7 +38,7 @@ const NewDashboardPage = () => {
     })
 
     setTimeout(() => {
-      navigate(`/${teamId}/${res.data}`)
+      navigate(`/${teamId}/${res.data.id}`)
     }, 1000)
   }
 
@@ -22,6 +22,11 @@ import { useNavigate, useParams } from 'react-router-dom'
 import { commonMsg, newMsg } from 'src/i18n/locales/en'
 import { useStore } from '@nanostores/react'
 import { getNewLinks } from './links'
+import {
+  DashboardBundle,
+  ImportConflict,
+  isDashboardBundle,
+} from 'types/dashboardBundle'
 
 const ImportDashboardPage = () => {
   const t = useStore(commonMsg)
@@ -29,6 +34,8 @@ const ImportDashboardPage = () => {
   const toast = useToast()
   const navigate = useNavigate()
   const [dashboard, setDashboard] = useState<Dashboard>(null)
+  const [bundle, setBundle] = useState<DashboardBundle>(null)
+  const [onConflict, setOnConflict] = useState<ImportConflict>('')
 
   const teamId = useParams().teamId
   const newLinks = getNewLinks(teamId)
@@ -48,10 +55,26 @@ const ImportDashboardPage = () => {
     })
 
     setTimeout(() => {
-      navigate(`/${teamId}/${res.data}`)
+      navigate(`/${teamId}/${res.data.id}`)
     }, 1000)
   }
 
+  const importBundle = async () => {
+    const res = await requestApi.post('/dashboard/import', {
+      teamId: Number(teamId),
+      bundle,
+      onConflict,
+    })
+    toast({
+      title: t1.bundleImported({
+        count: Object.keys(res.data.imported).length,
+      }),
+      status: 'success',
+      duration: 3000,
+      isClosable: true,
+    })
+  }
+
   const onMetaChange = (meta) => {
     if (!isJSON(meta)) {
       toast({
@@ -63,7 +86,14 @@ const ImportDashboardPage = () => {
       return
     }
 
-    const dash: Dashboard = JSON.parse(meta)
+    const raw = JSON.parse(meta)
+    if (isDashboardBundle(raw)) {
+      setDashboard(null)
+      setBundle(raw)
+      return
+    }
+
+    const dash: Dashboard = raw
     if (isEmpty(dash.id) || isEmpty(dash.data)) {
       toast({
         title: t1.jsonInvalid,
@@ -76,6 +106,7 @@ const ImportDashboardPage = () => {
 
     delete dash.id
     delete dash.ownedBy
+    setBundle(null)
     setDashboard(dash)
   }
 
@@ -107,7 +138,36 @@ const ImportDashboardPage = () => {
                         </Select>
                     </Box>
                 </FormItem>} */}
-          <Button width='fit-content' onClick={importDashboard} size='sm'>
+          {bundle && (
+            <>
+              <Text textStyle='annotation'>
+                {t1.bundleTips({
+                  dashboards: bundle.dashboards.length,
+                  variables: bundle.variables?.length ?? 0,
+                  datasources: bundle.datasources?.length ?? 0,
+                })}
+              </Text>
+              <FormItem title={t1.onConflict}>
+                <Select
+                  width='fit-content'
+                  value={onConflict}
+                  onChange={(e) =>
+                    setOnConflict(e.currentTarget.value as ImportConflict)
+                  }
+                >
+                  <option value=''>{t1.conflictFail}</option>
+                  <option value='skip'>{t1.conflictSkip}</option>
+                  <option value='overwrite'>{t1.conflictOverwrite}</option>
+                  <option value='new'>{t1.conflictNew}</option>
+                </Select>
+              </FormItem>
+            </>
+          )}
+          <Button
+            width='fit-content'
+            onClick={bundle ? importBundle : importDashboard}
+            size='sm'
+          >
             {t.submit}
           </Button>
         </VStack>
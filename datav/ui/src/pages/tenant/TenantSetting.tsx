@@ -172,6 +172,21 @@ export const TenantSetting = memo(() => {
                     }}
                   />
                 </FormItem>
+                <FormItem
+                  title='Telemetry tenants'
+                  desc='Tenants of the observability data this tenant can read, separated by comma'
+                  labelWidth='150px'
+                >
+                  <Input
+                    placeholder='default'
+                    value={tenant.telemetryTenants?.join(',') ?? ''}
+                    onChange={(e) => {
+                      // empty and duplicated items are removed by the server
+                      tenant.telemetryTenants = e.currentTarget.value.split(',')
+                      setTenant(cloneDeep(tenant))
+                    }}
+                  />
+                </FormItem>
                 {/* <FormItem title={t1.isPublic} desc={t1.isPublicTips} labelWidth="150px" alignItems="center">
                             <Switch isChecked={tenant.isPublic} onChange={e => { tenant.isPublic = e.currentTarget.checked; setTenant(cloneDeep(tenant)) }} />
                         </FormItem> */}
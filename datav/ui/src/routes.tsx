@@ -30,6 +30,7 @@ import BasicConfig from 'components/configloader/BasicConfig'
 
 const DashboardPage = loadable(() => import('src/pages/dashboard/index'))
 const TracePage = loadable(() => import('src/pages/dashboard/Trace'))
+const SnapshotPage = loadable(() => import('src/pages/Snapshot'))
 
 const commonConfig = (ele) => {
   return <CommonConfig>{ele}</CommonConfig>
@@ -187,6 +188,14 @@ export const getRoutes = (enableTenant = false) => {
         </BasicConfig>
       ),
     },
+    {
+      path: '/snapshot/:key',
+      element: (
+        <BasicConfig>
+          <SnapshotPage />
+        </BasicConfig>
+      ),
+    },
     {
       path: '/login/github',
       element: (
@@ -4,24 +4,44 @@ import { PanelStyles } from './panel/styles'
 import { Role } from './role'
 import { TimeRange } from './time'
 import { Variable } from './variable'
+import { LibraryPanelRef } from './libraryPanel'
 
 export interface Dashboard {
   id: string
   title: string
   ownedBy?: number
+  folderId?: number
   visibleTo: 'team' | 'all'
   ownerName?: string
   data: DashboardData
   weight: number
   tags?: string[]
   editable?: boolean
+  // loaded from provisioning files, not editable unless ui updates are allowed
+  provisioned?: boolean
   createdBy?: string
   created?: string
   updated?: string
+  // increased on every save, used to detect concurrent changes
+  version?: number
+  updatedBy?: number
   updateChanges?: string
+  // returned when searching dashboards by content
+  score?: number
+  highlights?: SearchHighlight[]
+}
+
+// fragment of a dashboard field matching the search query, matches are
+// wrapped in <em></em> and the other text is html escaped
+export interface SearchHighlight {
+  field: 'title' | 'tags' | 'panels' | 'descriptions' | 'queries'
+  fragment: string
 }
 
 export interface DashboardData {
+  // version of the data structure, dashboards are upgraded to the newest
+  // version by the server when loading
+  schemaVersion?: number
   description: string
   panels: Panel[]
   variables: Variable[]
@@ -79,6 +99,8 @@ export interface Panel {
   enableScopeTime?: boolean
   scopeTime?: TimeRange
   panels?: Panel[]
+  // the library panel this panel is linked to, it's replaced with the library panel when loading dashboard
+  libraryPanel?: LibraryPanelRef
 }
 
 export interface ValueMappingItem {
@@ -0,0 +1,13 @@
+// Copyright 2023 xObserve.io Team
+
+export type Permission = 'view' | 'edit' | 'admin'
+
+// grants a user or a team to view, edit or admin a dashboard
+export interface DashboardAcl {
+  dashboardId?: string
+  subjectType: 'user' | 'team'
+  subjectId: number
+  subjectName?: string
+  permission: Permission
+  created?: string
+}
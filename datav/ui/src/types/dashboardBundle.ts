@@ -0,0 +1,18 @@
+// Copyright 2023 xObserve.io Team
+
+import { Dashboard } from './dashboard'
+import { Variable } from './variable'
+
+// exported dashboards together with the team variables and datasources they use
+export interface DashboardBundle {
+  version: number
+  exported: string
+  dashboards: (Dashboard & { folderPath?: string[] })[]
+  variables: Variable[]
+  datasources: { id: number; name: string; type: string }[]
+}
+
+export type ImportConflict = '' | 'skip' | 'overwrite' | 'new'
+
+export const isDashboardBundle = (v: any): v is DashboardBundle =>
+  Array.isArray(v?.dashboards) && typeof v?.version == 'number'
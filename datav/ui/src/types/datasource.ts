@@ -12,6 +12,7 @@ export interface Datasource {
   url: string
   data?: { [key: string]: any }
   teamId?: number
+  provisioned?: boolean
   created?: string
   updated?: string
 }
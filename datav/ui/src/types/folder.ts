@@ -0,0 +1,22 @@
+// Copyright 2023 xObserve.io Team
+
+import { Permission } from './dashboardAcl'
+
+// the root folder of a team has no parent, its parentId is 0
+export interface Folder {
+  id: number
+  teamId: number
+  parentId: number
+  title: string
+  createdBy?: number
+  created?: string
+  updated?: string
+}
+
+export interface FolderPermission {
+  folderId?: number
+  subjectType: 'user' | 'team'
+  subjectId: number
+  subjectName?: string
+  permission: Permission
+}
@@ -0,0 +1,33 @@
+// Copyright 2023 xObserve.io Team
+
+import { Panel } from './dashboard'
+
+// panel shared by the dashboards of a team, dashboards refer to it in panel.libraryPanel
+export interface LibraryPanel {
+  id: number
+  teamId: number
+  title: string
+  description: string
+  // panel without id and gridPos
+  model: Panel
+  version: number
+  createdBy: number
+  updatedBy: number
+  created: string
+  updated: string
+  // number of dashboards using it
+  connections: number
+}
+
+export interface LibraryPanelRef {
+  id: number
+  title?: string
+  version?: number
+}
+
+export interface LibraryPanelConnection {
+  dashboardId: string
+  title: string
+  teamId: number
+  folderId: number
+}
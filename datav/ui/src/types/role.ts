@@ -2,6 +2,7 @@
 
 export enum Role {
   Viewer = 'Viewer',
+  EDITOR = 'Editor',
   ADMIN = 'Admin',
   SUPER_ADMIN = 'SuperAdmin',
 }
@@ -12,6 +13,11 @@ export function isAdmin(role) {
   return role === Role.ADMIN || role === Role.SUPER_ADMIN
 }
 
+// editors can edit the dashboards of their team, but can't manage the team
+export function isEditor(role) {
+  return role === Role.EDITOR || isAdmin(role)
+}
+
 export function isSuperAdmin(role) {
   return role === Role.SUPER_ADMIN
 }
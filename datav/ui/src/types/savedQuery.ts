@@ -0,0 +1,20 @@
+// Copyright 2023 xObserve.io Team
+
+export interface SavedQuery {
+  id?: number
+  name: string
+  description?: string
+  datasourceType: string
+  // the explorer which the query is used in, e.g logs, traces
+  scope: string
+  query: string
+  params?: Record<string, any>
+  tags?: string[]
+  ownerId?: number
+  teamId: number
+  visibleTo: 'owner' | 'team'
+  useCount?: number
+  lastUsed?: string
+  created?: string
+  updated?: string
+}
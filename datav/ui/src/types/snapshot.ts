@@ -0,0 +1,25 @@
+// Copyright 2023 xObserve.io Team
+
+import { Dashboard } from './dashboard'
+import { Variable } from './variable'
+
+// a dashboard with the query results of its panels, it can be viewed without login
+export interface Snapshot {
+  key: string
+  dashboardId: string
+  teamId: number
+  title: string
+  data?: SnapshotData
+  createdBy: number
+  expires: string
+  created: string
+}
+
+export interface SnapshotData {
+  dashboard: Dashboard
+  // panel id -> panel data
+  panelData: Record<string, any[]>
+  timeRange: { start: string; end: string }
+  variables: Variable[]
+  datasources: { id: number; name: string; type: string }[]
+}
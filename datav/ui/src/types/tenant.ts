@@ -12,5 +12,7 @@ export interface Tenant {
   numTeams: number
   teams: Team[]
   status: AvailableStatus
+  // the tenants of telemetry data in clickhouse which this tenant can read
+  telemetryTenants?: string[]
   created: string
 }
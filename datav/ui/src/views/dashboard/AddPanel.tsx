@@ -1,22 +1,36 @@
 // Copyright 2023 xObserve.io Team
 
 import {
+  Box,
+  HStack,
   Menu,
   MenuButton,
   MenuItem,
   MenuList,
+  Modal,
+  ModalBody,
+  ModalCloseButton,
+  ModalContent,
+  ModalHeader,
+  ModalOverlay,
+  Text,
   useColorModeValue,
+  useDisclosure,
   useToast,
+  VStack,
 } from '@chakra-ui/react'
 import IconButton from 'src/components/button/IconButton'
 import { PanelAdd } from 'src/components/icons/PanelAdd'
 import { initPanel, initRowPanel } from 'src/data/panel/initPanel'
 import { Dashboard, Panel } from 'types/dashboard'
-import React from 'react'
+import React, { useState } from 'react'
 import { useStore } from '@nanostores/react'
 import { dashboardMsg } from 'src/i18n/locales/en'
 import { $copiedPanel } from './store/dashboard'
 import { isEmpty } from 'utils/validate'
+import { requestApi } from 'utils/axios/request'
+import { LibraryPanel } from 'types/libraryPanel'
+import { newPanelFromLibrary } from './utils/libraryPanel'
 
 interface Props {
   dashboard: Dashboard
@@ -27,6 +41,8 @@ const AddPanel = ({ dashboard, onChange }: Props) => {
   const t1 = useStore(dashboardMsg)
   const copiedPanel = useStore($copiedPanel)
   const toast = useToast()
+  const { isOpen, onOpen, onClose } = useDisclosure()
+  const [libraryPanels, setLibraryPanels] = useState<LibraryPanel[]>([])
 
   const onAddPanel = (isRow) => {
     if (!dashboard.data.panels) {
@@ -73,6 +89,26 @@ const AddPanel = ({ dashboard, onChange }: Props) => {
     }
   }
 
+  const onOpenLibraryPanels = async () => {
+    const res = await requestApi.get(`/libraryPanel/team/${dashboard.ownedBy}`)
+    setLibraryPanels(res.data)
+    onOpen()
+  }
+
+  const onAddLibraryPanel = (p: LibraryPanel) => {
+    const newPanel = newPanelFromLibrary(
+      p,
+      getNextPanelId(dashboard),
+      initPanel().gridPos,
+    )
+    onChange((dashboard) => {
+      dashboard.data.panels.unshift(newPanel)
+    })
+    onClose()
+    const dashGrid = document.getElementById('dashboard-scroll-top')
+    dashGrid.scrollIntoView({ behavior: 'smooth', block: 'center' })
+  }
+
   return (
     <>
       <Menu>
@@ -98,8 +134,48 @@ const AddPanel = ({ dashboard, onChange }: Props) => {
           >
             {t1.pastePanel}
           </MenuItem>
+          <MenuItem onClick={onOpenLibraryPanels}>
+            {t1.addLibraryPanel}
+          </MenuItem>
         </MenuList>
       </Menu>
+      <Modal isOpen={isOpen} onClose={onClose}>
+        <ModalOverlay />
+        <ModalContent>
+          <ModalHeader>{t1.addLibraryPanel}</ModalHeader>
+          <ModalCloseButton />
+          <ModalBody pb='4'>
+            {libraryPanels.length == 0 ? (
+              <Text opacity={0.7}>{t1.noLibraryPanel}</Text>
+            ) : (
+              <VStack alignItems='inherit' spacing={1}>
+                {libraryPanels.map((p) => (
+                  <HStack
+                    key={p.id}
+                    justifyContent='space-between'
+                    cursor='pointer'
+                    className='hover-text'
+                    onClick={() => onAddLibraryPanel(p)}
+                  >
+                    <Box>
+                      <Text>{p.title}</Text>
+                      {p.description && (
+                        <Text fontSize='0.8rem' opacity={0.7}>
+                          {p.description}
+                        </Text>
+                      )}
+                    </Box>
+                    <Text fontSize='0.8rem' opacity={0.7}>
+                      {p.model.type} /{' '}
+                      {t1.libraryPanelUsedBy({ count: p.connections })}
+                    </Text>
+                  </HStack>
+                ))}
+              </VStack>
+            )}
+          </ModalBody>
+        </ModalContent>
+      </Modal>
     </>
   )
 }
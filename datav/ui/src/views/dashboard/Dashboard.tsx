@@ -58,6 +58,7 @@ import { useStore } from '@nanostores/react'
 import { VarialbeAllOption } from 'src/data/variable'
 import EditPanel from './edit-panel/EditPanel'
 import { $dashboard } from './store/dashboard'
+import { $snapshot, clearCapturedPanelData } from './store/snapshot'
 import DashboardAnnotations from './DashboardAnnotations'
 import { clearPanelRealTime } from './store/panelRealtime'
 import storage from 'utils/localStorage'
@@ -111,6 +112,13 @@ const DashboardWrapper = ({ rawDashboard, sideWidth }) => {
 
   // combine variables which defined separately in dashboard and global
   const setDashboardVariables = (dash: Dashboard, team: Team) => {
+    const snapshot = $snapshot.get()
+    if (snapshot) {
+      // use the variables and their selected values when snapshot was created
+      $variables.set(snapshot.data.variables ?? [])
+      return
+    }
+
     const dashVars = cloneDeep(dash.data.variables)
     initVariableSelected(dashVars)
 
@@ -122,7 +130,10 @@ const DashboardWrapper = ({ rawDashboard, sideWidth }) => {
   }
 
   useLayoutEffect(() => {
-    updateTimeToNewest()
+    // time range of snapshot is fixed
+    if (!$snapshot.get()) {
+      updateTimeToNewest()
+    }
     const dash: Dashboard = initDash(rawDashboard)
     const team = $teams.get().find((t) => t.id == dash.ownedBy)
     unstable_batchedUpdates(() => {
@@ -136,6 +147,7 @@ const DashboardWrapper = ({ rawDashboard, sideWidth }) => {
       // }
       prevQueries.clear()
       prevQueryData.clear()
+      clearCapturedPanelData()
       clearPanelRealTime()
       const previousColorMode = storage.get(PreviousColorModeKey)
       if (previousColorMode) {
@@ -84,6 +84,7 @@ const DashboardSave = ({ dashboard }: Props) => {
   const [pageChanged, setPageChanged] = useState(false)
   const [inPreview, setInPreview] = useState(false)
   const [updateChanges, setUpdateChanges] = useState('')
+  const [conflict, setConflict] = useState(null)
   const [pressed] = useKeyboardJs('ctrl+s')
   const embed = useEmbed()
   useLeavePageConfirm(
@@ -143,7 +144,7 @@ const DashboardSave = ({ dashboard }: Props) => {
   }, [dashboard, inPreview, edit])
 
   const toast = useToast()
-  const onSave = async (autoSave) => {
+  const onSave = async (autoSave, force = false) => {
     const changeMsg = autoSave ? 'Auto save' : updateChanges
     if (inPreview && autoSave) {
       toast({
@@ -165,7 +166,25 @@ const DashboardSave = ({ dashboard }: Props) => {
       return
     }
 
-    await requestApi.post('/dashboard/save', { dashboard, changes: changeMsg })
+    // in history preview mode, the previewed dashboard carries an old version,
+    // so the version of current dashboard is used
+    const version = saved?.version ?? dashboard.version
+    let res
+    try {
+      res = await requestApi.post(`/dashboard/save?force=${force}`, {
+        dashboard: { ...dashboard, version },
+        changes: changeMsg,
+      })
+    } catch (err) {
+      const resp = typeof err == 'string' ? JSON.parse(err) : null
+      if (resp?.data?.version && !autoSave) {
+        setConflict(resp.data)
+      }
+      return
+    }
+
+    dashboard.version = res.data.version
+    setConflict(null)
     toast({
       title: t1.savedMsg({ name: autoSave ? t.auto : '' }),
       status: 'success',
@@ -303,6 +322,28 @@ const DashboardSave = ({ dashboard }: Props) => {
           </ModalFooter>
         </ModalContent>
       </Modal>
+      <Modal isOpen={conflict != null} onClose={() => setConflict(null)}>
+        <ModalOverlay />
+        <ModalContent>
+          <ModalHeader>{t1.conflictTitle}</ModalHeader>
+          <ModalBody>
+            <Text>
+              {t1.conflictTips({
+                name: conflict?.updatedByName,
+                time: dateTimeFormat(conflict?.updated),
+              })}
+            </Text>
+          </ModalBody>
+          <ModalFooter>
+            <Button mr={3} onClick={() => setConflict(null)}>
+              {t.cancel}
+            </Button>
+            <Button colorScheme='red' onClick={() => onSave(false, true)}>
+              {t1.forceOverwrite}
+            </Button>
+          </ModalFooter>
+        </ModalContent>
+      </Modal>
       <Modal isOpen={isViewOpen} onClose={onViewClose} size='full'>
         <ModalOverlay />
         <ModalContent>
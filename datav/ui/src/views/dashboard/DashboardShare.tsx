@@ -25,6 +25,7 @@ import {
   ModalFooter,
   Textarea,
   Tooltip,
+  Link,
 } from '@chakra-ui/react'
 import React, { useEffect, useState } from 'react'
 import { BsShare } from 'react-icons/bs'
@@ -32,7 +33,14 @@ import { Dashboard } from 'types/dashboard'
 import { parseVariableFormat } from 'utils/format'
 import { getCurrentTimeRange } from 'src/components/DatePicker/TimePicker'
 import queryString from 'query-string'
-import { FaFileDownload, FaRegCopy, FaShare, FaRegEye } from 'react-icons/fa'
+import {
+  FaFileDownload,
+  FaRegCopy,
+  FaShare,
+  FaRegEye,
+  FaCamera,
+  FaTrashAlt,
+} from 'react-icons/fa'
 import { useStore } from '@nanostores/react'
 import { commonMsg, dashboardMsg } from 'src/i18n/locales/en'
 import { dispatch } from 'use-bus'
@@ -47,6 +55,10 @@ import { REFRESH_OFF } from './DashboardRefresh'
 import { Select } from 'antd'
 import { concat } from 'lodash'
 import RadionButtons from 'components/RadioButtons'
+import { requestApi } from 'utils/axios/request'
+import { Snapshot } from 'types/snapshot'
+import { capturedPanelData } from './store/snapshot'
+import { dateTimeFormat } from 'utils/datetime/formatter'
 
 interface Props extends StyleProps {
   dashboard: Dashboard
@@ -178,6 +190,7 @@ const DashboardShare = ({ dashboard, ...rest }: Props) => {
                   <Tab>{t.link}</Tab>
                   <Tab>{t.export}</Tab>
                   <Tab>{t.embedding}</Tab>
+                  <Tab>{t1.snapshot}</Tab>
                 </TabList>
               </HStack>
             </ModalHeader>
@@ -374,6 +387,9 @@ const DashboardShare = ({ dashboard, ...rest }: Props) => {
                     </HStack>
                   </VStack>
                 </TabPanel>
+                <TabPanel>
+                  <SnapshotComponent dashboard={dashboard} />
+                </TabPanel>
               </TabPanels>
             </ModalBody>
           </Tabs>
@@ -456,4 +472,127 @@ function ExportComponent({ dashboard }: { dashboard: Dashboard }) {
   )
 }
 
+const snapshotExpires = [
+  { label: 'Never', value: 0 },
+  { label: '1h', value: 3600 },
+  { label: '1d', value: 86400 },
+  { label: '7d', value: 7 * 86400 },
+  { label: '30d', value: 30 * 86400 },
+]
+
+function SnapshotComponent({ dashboard }: { dashboard: Dashboard }) {
+  const t = useStore(commonMsg)
+  const t1 = useStore(dashboardMsg)
+  const [expires, setExpires] = useState(0)
+  const [creating, setCreating] = useState(false)
+  const [snapshots, setSnapshots] = useState<Snapshot[]>([])
+  const [snapshotUrl, setSnapshotUrl] = useState<string>(null)
+  const { onCopy, setValue, hasCopied } = useClipboard('', 5000)
+
+  useEffect(() => {
+    loadSnapshots()
+  }, [])
+
+  const loadSnapshots = async () => {
+    const res = await requestApi.get(`/snapshot/dashboard/${dashboard.id}`)
+    setSnapshots(res.data)
+  }
+
+  const createSnapshot = async () => {
+    setCreating(true)
+    try {
+      const timeRange = getCurrentTimeRange()
+      const res = await requestApi.post('/snapshot', {
+        expires,
+        data: {
+          // the saved dashboard is stored in snapshot by server
+          dashboard: { id: dashboard.id },
+          panelData: capturedPanelData,
+          timeRange: { start: timeRange.start, end: timeRange.end },
+          variables: $variables.get(),
+        },
+      })
+      const url = getSnapshotUrl(res.data.key)
+      setSnapshotUrl(url)
+      setValue(url)
+      loadSnapshots()
+    } finally {
+      setCreating(false)
+    }
+  }
+
+  const deleteSnapshot = async (key: string) => {
+    await requestApi.delete(`/snapshot/${key}`)
+    setSnapshots(snapshots.filter((s) => s.key != key))
+  }
+
+  return (
+    <VStack spacing={3} alignItems='inherit' justify='flex-start'>
+      <Text fontSize='0.9em'>{t1.snapshotHelp}</Text>
+      <Form>
+        <FormItem title={t1.snapshotExpires} size='sm' alignItems='center'>
+          <Select
+            popupMatchSelectWidth={false}
+            bordered={false}
+            value={expires}
+            onChange={(v) => setExpires(v)}
+            options={snapshotExpires}
+          />
+        </FormItem>
+      </Form>
+      <Box>
+        <Button
+          size='sm'
+          variant='outline'
+          leftIcon={<FaCamera />}
+          isLoading={creating}
+          onClick={createSnapshot}
+        >
+          {t1.createSnapshot}
+        </Button>
+      </Box>
+      {snapshotUrl && (
+        <HStack spacing={1}>
+          <Input size='sm' value={snapshotUrl} readOnly />
+          <Button
+            size='sm'
+            leftIcon={<FaRegCopy />}
+            onClick={onCopy}
+            variant={hasCopied ? 'solid' : 'outline'}
+          >
+            {hasCopied ? t.copied : t.copy}
+          </Button>
+        </HStack>
+      )}
+      {snapshots.length > 0 && (
+        <VStack alignItems='inherit' spacing={1} fontSize='0.9em'>
+          {snapshots.map((s) => (
+            <HStack key={s.key} justifyContent='space-between'>
+              <Link href={getSnapshotUrl(s.key)} isExternal>
+                {dateTimeFormat(s.created)}
+              </Link>
+              <HStack>
+                <Text opacity={0.7}>
+                  {s.expires
+                    ? t1.snapshotExpiresAt({ time: dateTimeFormat(s.expires) })
+                    : t1.snapshotNeverExpires}
+                </Text>
+                <Box
+                  cursor='pointer'
+                  className='hover-text'
+                  onClick={() => deleteSnapshot(s.key)}
+                >
+                  <FaTrashAlt />
+                </Box>
+              </HStack>
+            </HStack>
+          ))}
+        </VStack>
+      )}
+    </VStack>
+  )
+}
+
+const getSnapshotUrl = (key: string) => `${window.origin}/snapshot/${key}`
+
 export default DashboardShare
@@ -13,7 +13,7 @@ import ColorTag from 'src/components/ColorTag'
 import CopyToClipboard from 'src/components/CopyToClipboard'
 import React, { useRef, useState } from 'react'
 import { useNavigate, useParams } from 'react-router-dom'
-import { Dashboard } from 'types/dashboard'
+import { Dashboard, SearchHighlight } from 'types/dashboard'
 import { Team } from 'types/teams'
 import { getDashboardLink } from 'utils/dashboard/dashboard'
 import DashboardStar from './DashboardStar'
@@ -23,6 +23,9 @@ import { dispatch } from 'use-bus'
 import { OnDashboardWeightChangeEvent } from 'src/data/bus-events'
 import { Session } from 'types/user'
 import { isAdmin } from 'types/role'
+import { useStore } from '@nanostores/react'
+import { searchMsg } from 'src/i18n/locales/en'
+import { isEmpty } from 'utils/validate'
 
 interface Props {
   dashboard: Dashboard
@@ -105,6 +108,9 @@ const DashboardCard = ({
             </Flex>
           )}
         </Flex>
+        {!isEmpty(dashboard.highlights) && (
+          <SearchHighlights highlights={dashboard.highlights} />
+        )}
         <HStack alignItems='center' mt='2' spacing={1}>
           <Text minWidth='fit-content' textStyle='annotation'>
             {owner?.name}
@@ -166,3 +172,54 @@ const DashboardCard = ({
 }
 
 export default DashboardCard
+
+interface HighlightsProps {
+  highlights: SearchHighlight[]
+}
+
+const SearchHighlights = ({ highlights }: HighlightsProps) => {
+  const t1 = useStore(searchMsg)
+  const fieldNames = {
+    title: t1.highlightTitle,
+    tags: t1.highlightTags,
+    panels: t1.highlightPanels,
+    descriptions: t1.highlightDescriptions,
+    queries: t1.highlightQueries,
+  }
+
+  return (
+    <Box mt='1'>
+      {highlights
+        .filter((h) => h.field != 'title')
+        .map((h) => (
+          <Text key={h.field} textStyle='annotation' noOfLines={1}>
+            <Text as='span' fontWeight={500} mr='1'>
+              {fieldNames[h.field]}:
+            </Text>
+            {h.fragment.split(/(<em>.*?<\/em>)/).map((part, i) =>
+              part.startsWith('<em>') ? (
+                <Text
+                  as='span'
+                  key={i}
+                  bg='var(--chakra-colors-brand-100)'
+                  color='black'
+                >
+                  {unescapeHtml(part.slice(4, -5))}
+                </Text>
+              ) : (
+                unescapeHtml(part)
+              ),
+            )}
+          </Text>
+        ))}
+    </Box>
+  )
+}
+
+const unescapeHtml = (s: string) =>
+  s
+    .replace(/&lt;/g, '<')
+    .replace(/&gt;/g, '>')
+    .replace(/&#34;/g, '"')
+    .replace(/&#39;/g, "'")
+    .replace(/&amp;/g, '&')
@@ -20,6 +20,7 @@ import {
   useToast,
 } from '@chakra-ui/react'
 import {
+  FaBook,
   FaBug,
   FaEdit,
   FaEllipsisV,
@@ -30,6 +31,7 @@ import {
   FaRegEye,
   FaRegEyeSlash,
   FaTrashAlt,
+  FaUnlink,
 } from 'react-icons/fa'
 import { IoMdInformation } from 'react-icons/io'
 import { memo, useCallback, useEffect, useMemo, useRef, useState } from 'react'
@@ -88,7 +90,7 @@ import {
   externalDatasourcePlugins,
   externalPanelPlugins,
 } from '../../plugins/external/plugins'
-import { $copiedPanel } from '../../store/dashboard'
+import { $copiedPanel, $dashboard } from '../../store/dashboard'
 import {
   builtinDatasourcePlugins,
   builtinPanelPlugins,
@@ -101,6 +103,11 @@ import useEmbed from 'hooks/useEmbed'
 import { Dropdown, MenuProps } from 'antd'
 import { locale } from 'src/i18n/i18n'
 import { Lang } from 'types/misc'
+import { $snapshot, capturedPanelData } from '../../store/snapshot'
+import {
+  saveAsLibraryPanel,
+  updateLibraryPanel,
+} from '../../utils/libraryPanel'
 
 interface PanelGridProps {
   dashboard: Dashboard
@@ -278,6 +285,13 @@ export const PanelComponent = ({
   ])
 
   const queryData = async (panel: Panel, dashboardId: string) => {
+    const snapshot = $snapshot.get()
+    if (snapshot) {
+      // datasources are never queried when viewing a snapshot
+      setPanelData(snapshot.data.panelData?.[panel.id] ?? [])
+      return
+    }
+
     const panelPlugin =
       builtinPanelPlugins[panel.type] ?? externalPanelPlugins[panel.type]
     if (panelPlugin?.settings.disableAutoQuery) {
@@ -407,6 +421,7 @@ export const PanelComponent = ({
       }
     }
 
+    capturedPanelData[panel.id] = data
     if (needUpdate) {
       console.log('query data and set panel data:', panel.id, data)
       setPanelData(data)
@@ -601,6 +616,45 @@ const PanelHeader = ({
 
   const { colorMode } = useColorMode()
   const embed = useEmbed()
+  const toast = useToast()
+
+  const onSaveLibraryPanel = async () => {
+    const ref = await saveAsLibraryPanel(panel, $dashboard.get().ownedBy)
+    dispatch({
+      type: UpdatePanelEvent,
+      data: { ...cloneDeep(panel), libraryPanel: ref },
+    })
+    toast({
+      title: t1.libraryPanelSaved,
+      status: 'success',
+      duration: 3000,
+      isClosable: true,
+    })
+  }
+
+  const onUpdateLibraryPanel = async () => {
+    const res = await updateLibraryPanel(panel)
+    dispatch({
+      type: UpdatePanelEvent,
+      data: {
+        ...cloneDeep(panel),
+        libraryPanel: { ...panel.libraryPanel, version: res.version },
+      },
+    })
+    toast({
+      title: t1.libraryPanelUpdated({ count: res.dashboards.length }),
+      status: 'success',
+      duration: 3000,
+      isClosable: true,
+    })
+  }
+
+  const onUnlinkLibraryPanel = () => {
+    const p = cloneDeep(panel)
+    delete p.libraryPanel
+    dispatch({ type: UpdatePanelEvent, data: p })
+  }
+
   const menuItems: MenuProps['items'] = [
     {
       key: 'edit',
@@ -646,6 +700,24 @@ const PanelHeader = ({
           icon: <FaRegEyeSlash />,
           onClick: () => onHidePanel(panel),
         },
+        !panel.libraryPanel && {
+          key: 'saveLibraryPanel',
+          label: t1.saveAsLibraryPanel,
+          icon: <FaBook />,
+          onClick: onSaveLibraryPanel,
+        },
+        panel.libraryPanel && {
+          key: 'updateLibraryPanel',
+          label: t1.updateLibraryPanel,
+          icon: <FaBook />,
+          onClick: onUpdateLibraryPanel,
+        },
+        panel.libraryPanel && {
+          key: 'unlinkLibraryPanel',
+          label: t1.unlinkLibraryPanel,
+          icon: <FaUnlink />,
+          onClick: onUnlinkLibraryPanel,
+        },
       ],
     },
     !viewPanel && {
@@ -696,6 +768,17 @@ const PanelHeader = ({
                   <Text noOfLines={1}>{title}</Text>
                 </TitleDecoration>
               </Box>
+              {panel.libraryPanel && (
+                <Tooltip
+                  label={t1.libraryPanelTips({
+                    name: panel.libraryPanel.title,
+                  })}
+                >
+                  <Box pl='1' opacity='0.6' fontSize='0.8rem'>
+                    <FaBook />
+                  </Box>
+                </Tooltip>
+              )}
               {(queryError || panel.desc) && (
                 <Box
                   color={useColorModeValue(
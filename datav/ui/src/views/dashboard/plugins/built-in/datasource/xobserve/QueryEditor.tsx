@@ -186,6 +186,16 @@ const domainParams = [
   ['gropu', 'group name, logical group of services', 'default', ''],
 ]
 
+const discoveryParams = [
+  ['search', 'only return the values with this prefix'],
+  ['limit', 'max number of values to return', '1000'],
+  [
+    'withCounts',
+    'return the number of spans or logs of each value in count column',
+    'false',
+  ],
+]
+
 export const apiList = [
   {
     name: 'getServiceInfoList',
@@ -201,26 +211,53 @@ export const apiList = [
 
   {
     name: 'getNamespaces',
-    desc: 'get namespace list',
+    desc: 'get namespaces which have reported data in the time range',
+    params: `{
+}`,
+    paramsDesc: [...discoveryParams],
+    format: DataFormat.ValueList,
+  },
+  {
+    name: 'getGroups',
+    desc: 'get groups which have reported data in the time range',
     params: `{
 }`,
-    paramsDesc: [],
+    paramsDesc: [
+      ['namespace', 'filter by namespaces, default to all namespaces'],
+      ...discoveryParams,
+    ],
     format: DataFormat.ValueList,
   },
   {
     name: 'getServiceNames',
-    desc: 'get service names, can be used in variable values',
+    desc: 'get service names which have reported data in the time range, can be used in variable values',
     params: `{
 }`,
-    paramsDesc: [...domainParams],
+    paramsDesc: [...domainParams, ...discoveryParams],
     format: DataFormat.ValueList,
   },
   {
     name: 'getServiceOperations',
-    desc: 'get service operations',
+    desc: 'get service operations which have reported data in the time range',
     params: `{
 }`,
-    paramsDesc: [...domainParams, ['service', 'service name']],
+    paramsDesc: [
+      ...domainParams,
+      ['service', 'service name'],
+      ...discoveryParams,
+    ],
+    format: DataFormat.ValueList,
+  },
+  {
+    name: 'getHosts',
+    desc: 'get hosts which have reported logs in the time range',
+    params: `{
+}`,
+    paramsDesc: [
+      ...domainParams,
+      ['service', 'service name'],
+      ...discoveryParams,
+    ],
     format: DataFormat.ValueList,
   },
   {
@@ -265,6 +302,13 @@ export const apiList = [
       ...domainParams,
       ['source', 'service name list, e.g xobserve|mysql', '', ''],
       ['target', 'service name list, e.g xobserve|mysql', '', ''],
+      ['types', 'edge types, e.g service|db', '', 'service | db | messaging'],
+      [
+        'breakdown',
+        'break edges down by namespace or instance, e.g namespace|instance',
+        '',
+        'namespace | instance',
+      ],
     ],
     format: DataFormat.NodeGraph,
   },
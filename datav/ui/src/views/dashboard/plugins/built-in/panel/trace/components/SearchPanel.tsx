@@ -45,6 +45,8 @@ import { builtinDatasourcePlugins } from '../../../plugins'
 import { externalDatasourcePlugins } from 'src/views/dashboard/plugins/external/plugins'
 import { TraceTagKey } from '../../xobserveTrace/Trace'
 import { replaceWithVariables } from 'utils/variable'
+import SavedQueries from 'src/components/SavedQueries'
+import { DatasourceTypexobserve } from '../../../datasource/xobserve/types'
 
 interface Props {
   panel: Panel
@@ -201,6 +203,26 @@ const TraceSearchPanel = ({
         spacing={isLargeScreen ? 4 : 2}
         fontSize={isLargeScreen ? '0.8rem' : '0.7rem'}
       >
+        {ds.type == DatasourceTypexobserve && (
+          <Flex justifyContent='flex-end'>
+            <SavedQueries
+              scope='traces'
+              datasourceType={DatasourceTypexobserve}
+              query={tags}
+              params={{ service, operation, min, max, limit }}
+              onSelect={(q) => {
+                setTags(q.query)
+                if (q.params) {
+                  setService(q.params.service ?? service)
+                  setOperation(q.params.operation ?? operation)
+                  setMin(q.params.min ?? min)
+                  setMax(q.params.max ?? max)
+                  setLimit(q.params.limit ?? limit)
+                }
+              }}
+            />
+          </Flex>
+        )}
         <FormSection title='Service' spacing={1}>
           {panel.plugins[panel.type].enableEditService ? (
             <EditorInputItem
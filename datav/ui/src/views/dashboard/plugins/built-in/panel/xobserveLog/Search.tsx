@@ -19,6 +19,8 @@ import { Panel } from 'types/dashboard'
 import useBus, { dispatch } from 'use-bus'
 import { isEmpty } from 'utils/validate'
 import { $xobserveQueryParams } from '../../datasource/xobserve/store'
+import SavedQueries from 'components/SavedQueries'
+import { DatasourceTypexobserve } from '../../datasource/xobserve/types'
 
 interface Props {
   panel: Panel
@@ -152,6 +154,15 @@ const Search = ({ panel }: Props) => {
           </VStack>
         </Box>
       </SearchInput>
+      <SavedQueries
+        scope='logs'
+        datasourceType={DatasourceTypexobserve}
+        query={query}
+        onSelect={(q) => {
+          setQuery(q.query)
+          onSearch(q.query)
+        }}
+      />
     </Box>
   )
 }
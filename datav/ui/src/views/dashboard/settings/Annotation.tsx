@@ -63,6 +63,7 @@ const AnnotationSettings = ({ dashboard, onChange }: Props) => {
           <RadionButtons
             options={[
               { label: Role.Viewer, value: Role.Viewer },
+              { label: Role.EDITOR, value: Role.EDITOR },
               { label: Role.ADMIN, value: Role.ADMIN },
             ]}
             value={dashboard.data.annotation.enableRole}
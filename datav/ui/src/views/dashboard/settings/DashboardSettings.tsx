@@ -32,6 +32,7 @@ import React from 'react'
 import { useStore } from '@nanostores/react'
 import { commonMsg, dashboardSettingMsg } from 'src/i18n/locales/en'
 import AnnotationSettings from './Annotation'
+import PermissionSettings from './Permissions'
 import { MobileBreakpoint } from 'src/data/constants'
 
 interface Props {
@@ -45,6 +46,7 @@ enum DashboardSettingType {
   Variables = 2,
   MetaData = 3,
   Annotation = 4,
+  Permissions = 5,
 }
 // color-scheme: dark;height: 100%;background-image: url(http://xobserve-react.jiaminghi.com/demo/manage-desk/static/media/bg.110420cf.png);background-size: auto;
 const DashboardSettings = ({ dashboard, onChange }: Props) => {
@@ -103,6 +105,7 @@ const DashboardSettings = ({ dashboard, onChange }: Props) => {
                 <Tab>{t.variable}</Tab>
                 <Tab>{t.annotation}</Tab>
                 <Tab>{t1.metaData}</Tab>
+                <Tab>{t1.permissions}</Tab>
               </TabList>
 
               <TabPanels p={isLargeScreen ? 2 : 0}>
@@ -124,6 +127,9 @@ const DashboardSettings = ({ dashboard, onChange }: Props) => {
                 <TabPanel py='0' tabIndex={DashboardSettingType.MetaData}>
                   <MetaSettings dashboard={dashboard} onChange={onChange} />
                 </TabPanel>
+                <TabPanel py='0' tabIndex={DashboardSettingType.Permissions}>
+                  <PermissionSettings dashboard={dashboard} />
+                </TabPanel>
               </TabPanels>
             </Tabs>
           </ModalBody>
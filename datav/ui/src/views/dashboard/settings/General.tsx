@@ -24,8 +24,9 @@ import {
 import { EditorNumberItem } from 'src/components/editor/EditorItem'
 import { Form, FormSection } from 'src/components/form/Form'
 import FormItem from 'src/components/form/Item'
-import { useState } from 'react'
+import { useEffect, useState } from 'react'
 import { Dashboard, DashboardLayout } from 'types/dashboard'
+import { Folder } from 'types/folder'
 import React from 'react'
 import { useStore } from '@nanostores/react'
 import { commonMsg, dashboardSettingMsg } from 'src/i18n/locales/en'
@@ -53,7 +54,30 @@ const GeneralSettings = ({ dashboard, onChange }: Props) => {
   const [desc, setDesc] = useState(dashboard.data.description)
   const [hidingVars, setHidingVars] = useState(dashboard.data.hidingVars)
   const [tag, setTag] = useState('')
+  const [folders, setFolders] = useState<Folder[]>([])
   const teamId = useParams().teamId
+
+  useEffect(() => {
+    loadFolders()
+  }, [])
+
+  const loadFolders = async () => {
+    const res = await requestApi.get(`/folder/team/${dashboard.ownedBy}`)
+    setFolders(sortFolders(res.data))
+  }
+
+  const onMove = async (folderId: number) => {
+    await requestApi.post('/dashboard/move', { id: dashboard.id, folderId })
+    toast({
+      title: t1.movedToFolder,
+      status: 'success',
+      duration: 2000,
+      isClosable: true,
+    })
+    onChange((draft: Dashboard) => {
+      draft.folderId = folderId
+    })
+  }
   const addTag = () => {
     if (dashboard.tags?.length >= 5) {
       toast({
@@ -146,6 +170,18 @@ const GeneralSettings = ({ dashboard, onChange }: Props) => {
               }
             />
           </FormItem>
+          <FormItem title={t1.folder} desc={t1.folderTips}>
+            <Select
+              value={dashboard.folderId}
+              onChange={(e) => onMove(Number(e.currentTarget.value))}
+            >
+              {folders.map((f) => (
+                <option key={f.id} value={f.id}>
+                  {'\u00a0'.repeat(f['depth'] * 4) + f.title}
+                </option>
+              ))}
+            </Select>
+          </FormItem>
           {/* <Box>
                 <Text textStyle="title">Editable</Text>
                 <Text textStyle="annotation">Make this dashboard editable to anyone who has edit permissions. </Text>
@@ -364,3 +400,18 @@ const GeneralSettings = ({ dashboard, onChange }: Props) => {
 }
 
 export default GeneralSettings
+
+// sort folders in tree order, sub folders are placed after their parent
+const sortFolders = (folders: Folder[]) => {
+  const result = []
+  const walk = (parentId: number, depth: number) => {
+    folders
+      .filter((f) => f.parentId == parentId)
+      .forEach((f) => {
+        result.push({ ...f, depth })
+        walk(f.id, depth + 1)
+      })
+  }
+  walk(0, 0)
+  return result
+}
@@ -13,6 +13,7 @@ import {
   AlertTitle,
   Box,
   Button,
+  HStack,
   Text,
   Textarea,
   useDisclosure,
@@ -58,6 +59,18 @@ const MetaSettings = ({ dashboard }: Props) => {
     window.location.reload()
   }
 
+  const onExportBundle = async () => {
+    const res = await requestApi.get(`/dashboard/export?id=${dashboard.id}`)
+    const blob = new Blob([JSON.stringify(res.data, null, 2)], {
+      type: 'application/json',
+    })
+    const link = document.createElement('a')
+    link.href = URL.createObjectURL(blob)
+    link.download = `${dashboard.title}.bundle.json`
+    link.click()
+    URL.revokeObjectURL(link.href)
+  }
+
   return (
     <>
       <Box width='100%' height='500px' className='bordered' mb='3'>
@@ -71,9 +84,14 @@ const MetaSettings = ({ dashboard }: Props) => {
         <AlertDescription maxWidth='sm'></AlertDescription>
       </DetailAlert>
 
-      <Button mt='2' isDisabled={meta == rawMeta.current} onClick={onOpen}>
-        {t.submit}
-      </Button>
+      <HStack mt='2'>
+        <Button isDisabled={meta == rawMeta.current} onClick={onOpen}>
+          {t.submit}
+        </Button>
+        <Button variant='outline' onClick={onExportBundle}>
+          {t1.exportBundle}
+        </Button>
+      </HStack>
 
       <AlertDialog
         isOpen={isOpen}
@@ -0,0 +1,146 @@
+// Copyright 2023 xObserve.io Team
+
+import {
+  Button,
+  HStack,
+  NumberInput,
+  NumberInputField,
+  Select,
+  Text,
+  useToast,
+  VStack,
+} from '@chakra-ui/react'
+import { useStore } from '@nanostores/react'
+import { cloneDeep } from 'lodash'
+import React, { useEffect, useState } from 'react'
+import { FaTrashAlt } from 'react-icons/fa'
+import { Form, FormSection } from 'src/components/form/Form'
+import { commonMsg, dashboardSettingMsg } from 'src/i18n/locales/en'
+import { Dashboard } from 'types/dashboard'
+import { DashboardAcl, Permission } from 'types/dashboardAcl'
+import { requestApi } from 'utils/axios/request'
+
+interface Props {
+  dashboard: Dashboard
+}
+
+const PermissionSettings = ({ dashboard }: Props) => {
+  const t = useStore(commonMsg)
+  const t1 = useStore(dashboardSettingMsg)
+  const toast = useToast()
+  const [acl, setAcl] = useState<DashboardAcl[]>([])
+  const [permission, setPermission] = useState<Permission>(null)
+
+  useEffect(() => {
+    load()
+  }, [])
+
+  const load = async () => {
+    const res = await requestApi.get(`/dashboard/acl/${dashboard.id}`)
+    setAcl(res.data.acl)
+    setPermission(res.data.permission)
+  }
+
+  const onSubmit = async () => {
+    await requestApi.post(`/dashboard/acl/${dashboard.id}`, acl)
+    toast({
+      title: t.isUpdated({ name: t1.permissions }),
+      status: 'success',
+      duration: 2000,
+      isClosable: true,
+    })
+    load()
+  }
+
+  const updateEntry = (i: number, f: (entry: DashboardAcl) => void) => {
+    const newAcl = cloneDeep(acl)
+    f(newAcl[i])
+    setAcl(newAcl)
+  }
+
+  const editable = permission == 'admin'
+  return (
+    <Form maxW='600px'>
+      <FormSection title={t1.permissions} desc={t1.permissionsTips}>
+        <VStack alignItems='left'>
+          {acl.map((entry, i) => (
+            <HStack key={i}>
+              <Select
+                size='sm'
+                width='100px'
+                value={entry.subjectType}
+                isDisabled={!editable}
+                onChange={(e) =>
+                  updateEntry(i, (entry) => {
+                    entry.subjectType = e.currentTarget.value as any
+                  })
+                }
+              >
+                <option value='user'>{t.user}</option>
+                <option value='team'>{t.team}</option>
+              </Select>
+              <NumberInput
+                size='sm'
+                width='120px'
+                value={entry.subjectId}
+                isDisabled={!editable}
+                onChange={(_, v) =>
+                  updateEntry(i, (entry) => {
+                    entry.subjectId = v
+                  })
+                }
+              >
+                <NumberInputField placeholder={t1.subjectId} />
+              </NumberInput>
+              <Text width='120px' fontSize='0.9rem' noOfLines={1}>
+                {entry.subjectName}
+              </Text>
+              <Select
+                size='sm'
+                width='100px'
+                value={entry.permission}
+                isDisabled={!editable}
+                onChange={(e) =>
+                  updateEntry(i, (entry) => {
+                    entry.permission = e.currentTarget.value as Permission
+                  })
+                }
+              >
+                <option value='view'>view</option>
+                <option value='edit'>edit</option>
+                <option value='admin'>admin</option>
+              </Select>
+              {editable && (
+                <FaTrashAlt
+                  cursor='pointer'
+                  onClick={() => setAcl(acl.filter((_, j) => j != i))}
+                />
+              )}
+            </HStack>
+          ))}
+        </VStack>
+        {editable && (
+          <HStack mt='3'>
+            <Button
+              size='sm'
+              variant='outline'
+              onClick={() =>
+                setAcl([
+                  ...acl,
+                  { subjectType: 'user', subjectId: 0, permission: 'view' },
+                ])
+              }
+            >
+              {t1.addPermission}
+            </Button>
+            <Button size='sm' onClick={onSubmit}>
+              {t.submit}
+            </Button>
+          </HStack>
+        )}
+      </FormSection>
+    </Form>
+  )
+}
+
+export default PermissionSettings
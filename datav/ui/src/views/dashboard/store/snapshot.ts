@@ -0,0 +1,14 @@
+import { atom } from 'nanostores'
+import { Snapshot } from 'types/snapshot'
+
+// snapshot being viewed, panels use its data instead of querying datasources
+export const $snapshot = atom<Snapshot>(null)
+
+// data of the panels in current dashboard, it's captured when creating a snapshot
+export const capturedPanelData: Record<string, any[]> = {}
+
+export const clearCapturedPanelData = () => {
+  for (const k of Object.keys(capturedPanelData)) {
+    delete capturedPanelData[k]
+  }
+}
@@ -0,0 +1,53 @@
+import { cloneDeep } from 'lodash'
+import { Panel } from 'types/dashboard'
+import { LibraryPanel, LibraryPanelConnection } from 'types/libraryPanel'
+import { requestApi } from 'utils/axios/request'
+
+// libraryPanelModel returns the panel without the fields decided by dashboards
+export const libraryPanelModel = (panel: Panel) => {
+  const model = cloneDeep(panel)
+  delete model.id
+  delete model.gridPos
+  delete model.libraryPanel
+  return model
+}
+
+export const saveAsLibraryPanel = async (panel: Panel, teamId: number) => {
+  const res = await requestApi.post('/libraryPanel/new', {
+    teamId,
+    title: panel.title,
+    description: panel.desc,
+    model: libraryPanelModel(panel),
+  })
+  const p: LibraryPanel = res.data
+  return { id: p.id, title: p.title, version: p.version }
+}
+
+// updateLibraryPanel saves the panel to its library panel, and returns the dashboards using it
+export const updateLibraryPanel = async (panel: Panel) => {
+  const res = await requestApi.post('/libraryPanel/update', {
+    id: panel.libraryPanel.id,
+    title: panel.libraryPanel.title,
+    description: panel.desc,
+    model: libraryPanelModel(panel),
+    version: panel.libraryPanel.version,
+  })
+  return res.data as {
+    version: number
+    dashboards: LibraryPanelConnection[]
+  }
+}
+
+// newPanelFromLibrary creates a dashboard panel linked to the library panel
+export const newPanelFromLibrary = (
+  p: LibraryPanel,
+  id: number,
+  gridPos: Panel['gridPos'],
+): Panel => {
+  return {
+    ...cloneDeep(p.model),
+    id,
+    gridPos,
+    libraryPanel: { id: p.id, title: p.title, version: p.version },
+  }
+}
@@ -203,7 +203,17 @@ const DatasourceEditor = ({
         {EditorPlugin && (
           <EditorPlugin datasource={datasource} onChange={setDatasource} />
         )}
-        <Button onClick={testDatasource} size='sm' mt='4'>
+        {datasource.provisioned && (
+          <Text textStyle='annotation' mt='4'>
+            {t1.provisionedDsTips}
+          </Text>
+        )}
+        <Button
+          onClick={testDatasource}
+          size='sm'
+          mt='4'
+          isDisabled={datasource.provisioned}
+        >
           {t.test} & {t.save}
         </Button>
       </FormSection>
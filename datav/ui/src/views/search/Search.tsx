@@ -79,6 +79,9 @@ const Search = memo((props: Props) => {
   const [filterStarred, setFilterStarred] = useState<boolean>(false)
   const [starredDashIds, setStarredDashIds] = useState<Set<string>>(new Set())
   const [layout, setLayout] = useState<'teams' | 'list' | 'tags'>('teams')
+  // dashboards whose panels, descriptions or queries match the query
+  const [contentMatches, setContentMatches] =
+    useState<Map<string, Dashboard>>(null)
 
   useBus(
     OnDashboardWeightChangeEvent,
@@ -96,6 +99,35 @@ const Search = memo((props: Props) => {
     onClose()
   }, [location])
 
+  useEffect(() => {
+    if (!isOpen || isEmpty(query?.trim())) {
+      setContentMatches(null)
+      return
+    }
+
+    let canceled = false
+    const timer = setTimeout(async () => {
+      const res = await requestApi.get(
+        `/dashboard/search/${config.currentTenant}?query=${encodeURIComponent(
+          query,
+        )}`,
+      )
+      if (canceled) {
+        return
+      }
+      const matches = new Map<string, Dashboard>()
+      for (const dash of res.data) {
+        matches.set(dash.id, dash)
+      }
+      setContentMatches(matches)
+    }, 300)
+
+    return () => {
+      canceled = true
+      clearTimeout(timer)
+    }
+  }, [query, isOpen])
+
   const onSearchOpen = async () => {
     if (isOpen) {
       onClose()
@@ -160,17 +192,26 @@ const Search = memo((props: Props) => {
       return [result, null, null]
     }
 
-    for (const dash of rawDashboards) {
+    for (let dash of rawDashboards) {
       if (filterStarred && !starredDashIds.has(dash.id)) {
         continue
       }
 
+      const contentMatch = contentMatches?.get(dash.id)
+      if (contentMatch) {
+        dash = {
+          ...dash,
+          score: contentMatch.score,
+          highlights: contentMatch.highlights,
+        }
+      }
+
       const id = caseSensitive
         ? dash.id.toString()
         : dash.id.toString().toLowerCase()
       const title = caseSensitive ? dash.title : dash.title.toLowerCase()
       const q = caseSensitive ? query : query?.toLowerCase()
-      if (isEmpty(q) || id.includes(q) || title.includes(q)) {
+      if (isEmpty(q) || id.includes(q) || title.includes(q) || contentMatch) {
         if (selectedTags.includes('untagged') && isEmpty(dash.tags)) {
           result.push(dash)
           continue
@@ -196,6 +237,11 @@ const Search = memo((props: Props) => {
       return selectedTeams.some((t) => t == dash.ownedBy)
     })
 
+    if (contentMatches) {
+      // more relevant dashboards first
+      result.sort((a, b) => (b.score ?? 0) - (a.score ?? 0))
+    }
+
     const tagCount = new Map()
     const teamCount = new Map()
 
@@ -219,6 +265,7 @@ const Search = memo((props: Props) => {
     selectedTeams,
     filterStarred,
     starredDashIds,
+    contentMatches,
   ])
 
   const dashboards: Dashboard[] | Map<string, Dashboard[]> = useMemo(() => {
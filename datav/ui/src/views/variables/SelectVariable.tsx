@@ -25,6 +25,7 @@ import { EditorInputItem } from 'components/editor/EditorItem'
 import { $teams } from '../team/store'
 import { externalDatasourcePlugins } from '../dashboard/plugins/external/plugins'
 import { builtinDatasourcePlugins } from '../dashboard/plugins/built-in/plugins'
+import { $snapshot } from '../dashboard/store/snapshot'
 
 interface Props {
   variables: Variable[]
@@ -114,6 +115,12 @@ const SelectVariable = memo(({ v }: { v: Variable }) => {
       return
     }
 
+    if ($snapshot.get()) {
+      // values are captured in snapshot, datasources are not queried
+      setValues(v.values ?? [])
+      return
+    }
+
     let result = []
     if (v.enableAll) {
       result.push(VarialbeAllOption)
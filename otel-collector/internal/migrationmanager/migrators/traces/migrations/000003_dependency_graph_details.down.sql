@@ -0,0 +1,57 @@
+DROP VIEW IF EXISTS xobserve_traces.dependency_graph_minutes_service_calls_mv ON CLUSTER cluster;
+DROP VIEW IF EXISTS xobserve_traces.dependency_graph_minutes_db_calls_mv ON CLUSTER cluster;
+DROP VIEW IF EXISTS xobserve_traces.dependency_graph_minutes_messaging_calls_mv ON CLUSTER cluster;
+
+-- dest_type, src_instance and dest_instance are kept in dependency_graph_minutes, columns in sorting key can't be dropped
+ALTER TABLE xobserve_traces.distributed_dependency_graph_minutes ON CLUSTER cluster
+    DROP COLUMN IF EXISTS dest_type,
+    DROP COLUMN IF EXISTS src_instance,
+    DROP COLUMN IF EXISTS dest_instance;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.dependency_graph_minutes_service_calls_mv ON CLUSTER cluster
+TO xobserve_traces.dependency_graph_minutes AS
+SELECT
+    A.serviceName as src,
+    B.serviceName as dest,
+    quantilesState(0.5, 0.75, 0.9, 0.95, 0.99)(toFloat64(B.duration)) as duration_quantiles_state,
+    countIf(B.statusCode=2) as error_count,
+    count(*) as total_count,
+    toStartOfMinute(fromUnixTimestamp64Nano(B.startTime)) as timestamp,
+    B.tenant as tenant,
+    B.namespace as namespace,
+    B.group as group
+FROM xobserve_traces.trace_index AS A, xobserve_traces.trace_index AS B
+WHERE (A.serviceName != B.serviceName) AND (A.spanId = B.parentId)
+GROUP BY timestamp,tenant, namespace, group, src, dest;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.dependency_graph_minutes_db_calls_mv ON CLUSTER cluster
+TO xobserve_traces.dependency_graph_minutes AS
+SELECT
+    serviceName as src,
+    attributesMap['db.system'] as dest,
+    quantilesState(0.5, 0.75, 0.9, 0.95, 0.99)(toFloat64(duration)) as duration_quantiles_state,
+    countIf(statusCode=2) as error_count,
+    count(*) as total_count,
+    toStartOfMinute(fromUnixTimestamp64Nano(startTime)) as timestamp,
+    tenant,
+    namespace,
+    group
+FROM xobserve_traces.trace_index
+WHERE dest != '' and kind != 2
+GROUP BY timestamp,tenant, namespace, group, src, dest;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.dependency_graph_minutes_messaging_calls_mv ON CLUSTER cluster
+TO xobserve_traces.dependency_graph_minutes AS
+SELECT
+    serviceName as src,
+    attributesMap['messaging.system'] as dest,
+    quantilesState(0.5, 0.75, 0.9, 0.95, 0.99)(toFloat64(duration)) as duration_quantiles_state,
+    countIf(statusCode=2) as error_count,
+    count(*) as total_count,
+    toStartOfMinute(fromUnixTimestamp64Nano(startTime)) as timestamp,
+    tenant,
+    namespace,
+    group
+FROM xobserve_traces.trace_index
+WHERE dest != '' and kind != 2
+GROUP BY timestamp,tenant, namespace, group, src, dest;
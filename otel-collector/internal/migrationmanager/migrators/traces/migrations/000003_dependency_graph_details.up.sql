@@ -0,0 +1,71 @@
+ALTER TABLE xobserve_traces.dependency_graph_minutes ON CLUSTER cluster
+    ADD COLUMN IF NOT EXISTS dest_type LowCardinality(String) CODEC(ZSTD(1)),
+    ADD COLUMN IF NOT EXISTS src_instance LowCardinality(String) CODEC(ZSTD(1)),
+    ADD COLUMN IF NOT EXISTS dest_instance LowCardinality(String) CODEC(ZSTD(1)),
+    MODIFY ORDER BY (timestamp, tenant, namespace, group, src, dest, dest_type, src_instance, dest_instance);
+
+ALTER TABLE xobserve_traces.distributed_dependency_graph_minutes ON CLUSTER cluster
+    ADD COLUMN IF NOT EXISTS dest_type LowCardinality(String) CODEC(ZSTD(1)),
+    ADD COLUMN IF NOT EXISTS src_instance LowCardinality(String) CODEC(ZSTD(1)),
+    ADD COLUMN IF NOT EXISTS dest_instance LowCardinality(String) CODEC(ZSTD(1));
+
+DROP VIEW IF EXISTS xobserve_traces.dependency_graph_minutes_service_calls_mv ON CLUSTER cluster;
+DROP VIEW IF EXISTS xobserve_traces.dependency_graph_minutes_db_calls_mv ON CLUSTER cluster;
+DROP VIEW IF EXISTS xobserve_traces.dependency_graph_minutes_messaging_calls_mv ON CLUSTER cluster;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.dependency_graph_minutes_service_calls_mv ON CLUSTER cluster
+TO xobserve_traces.dependency_graph_minutes AS
+SELECT
+    A.serviceName as src,
+    B.serviceName as dest,
+    'service' as dest_type,
+    if(A.resourcesMap['service.instance.id'] != '', A.resourcesMap['service.instance.id'], A.resourcesMap['host.name']) as src_instance,
+    if(B.resourcesMap['service.instance.id'] != '', B.resourcesMap['service.instance.id'], B.resourcesMap['host.name']) as dest_instance,
+    quantilesState(0.5, 0.75, 0.9, 0.95, 0.99)(toFloat64(B.duration)) as duration_quantiles_state,
+    countIf(B.statusCode=2) as error_count,
+    count(*) as total_count,
+    toStartOfMinute(fromUnixTimestamp64Nano(B.startTime)) as timestamp,
+    B.tenant as tenant,
+    B.namespace as namespace,
+    B.group as group
+FROM xobserve_traces.trace_index AS A, xobserve_traces.trace_index AS B
+WHERE (A.serviceName != B.serviceName) AND (A.spanId = B.parentId)
+GROUP BY timestamp,tenant, namespace, group, src, dest, dest_type, src_instance, dest_instance;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.dependency_graph_minutes_db_calls_mv ON CLUSTER cluster
+TO xobserve_traces.dependency_graph_minutes AS
+SELECT
+    serviceName as src,
+    attributesMap['db.system'] as dest,
+    'db' as dest_type,
+    if(resourcesMap['service.instance.id'] != '', resourcesMap['service.instance.id'], resourcesMap['host.name']) as src_instance,
+    if(attributesMap['net.peer.name'] != '', attributesMap['net.peer.name'], attributesMap['server.address']) as dest_instance,
+    quantilesState(0.5, 0.75, 0.9, 0.95, 0.99)(toFloat64(duration)) as duration_quantiles_state,
+    countIf(statusCode=2) as error_count,
+    count(*) as total_count,
+    toStartOfMinute(fromUnixTimestamp64Nano(startTime)) as timestamp,
+    tenant,
+    namespace,
+    group
+FROM xobserve_traces.trace_index
+WHERE dest != '' and kind != 2
+GROUP BY timestamp,tenant, namespace, group, src, dest, dest_type, src_instance, dest_instance;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.dependency_graph_minutes_messaging_calls_mv ON CLUSTER cluster
+TO xobserve_traces.dependency_graph_minutes AS
+SELECT
+    serviceName as src,
+    attributesMap['messaging.system'] as dest,
+    'messaging' as dest_type,
+    if(resourcesMap['service.instance.id'] != '', resourcesMap['service.instance.id'], resourcesMap['host.name']) as src_instance,
+    if(attributesMap['net.peer.name'] != '', attributesMap['net.peer.name'], attributesMap['server.address']) as dest_instance,
+    quantilesState(0.5, 0.75, 0.9, 0.95, 0.99)(toFloat64(duration)) as duration_quantiles_state,
+    countIf(statusCode=2) as error_count,
+    count(*) as total_count,
+    toStartOfMinute(fromUnixTimestamp64Nano(startTime)) as timestamp,
+    tenant,
+    namespace,
+    group
+FROM xobserve_traces.trace_index
+WHERE dest != '' and kind != 2
+GROUP BY timestamp,tenant, namespace, group, src, dest, dest_type, src_instance, dest_instance;
@@ -0,0 +1,11 @@
+DROP VIEW IF EXISTS xobserve_traces.usage_explorer_mv ON CLUSTER cluster;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.usage_explorer_mv ON CLUSTER cluster
+TO xobserve_traces.usage_explorer
+AS SELECT
+  toStartOfHour(toDateTime(startTime)) as timestamp,
+  tenant, namespace, group,
+  serviceName as service_name,
+  count() as count
+FROM xobserve_traces.trace_index
+GROUP BY timestamp, tenant, namespace, group, serviceName;
@@ -0,0 +1,14 @@
+-- startTime of trace_index is in nanoseconds, usage_explorer_mv converted it with toDateTime, so the timestamps of the
+-- rows written by the old view are wrong. Only the view is replaced, the existing rows are kept as they are and never
+-- match a query time range, the rows written from now on are counted in the right hour
+DROP VIEW IF EXISTS xobserve_traces.usage_explorer_mv ON CLUSTER cluster;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.usage_explorer_mv ON CLUSTER cluster
+TO xobserve_traces.usage_explorer
+AS SELECT
+  toStartOfHour(fromUnixTimestamp64Nano(startTime)) as timestamp,
+  tenant, namespace, group,
+  serviceName as service_name,
+  count() as count
+FROM xobserve_traces.trace_index
+GROUP BY timestamp, tenant, namespace, group, serviceName;
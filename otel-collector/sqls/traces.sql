@@ -170,7 +170,7 @@ ENGINE = Distributed("cluster", "xobserve_traces", usage_explorer, cityHash64(ra
 CREATE MATERIALIZED VIEW IF NOT EXISTS xobserve_traces.usage_explorer_mv ON CLUSTER cluster
 TO xobserve_traces.usage_explorer
 AS SELECT
-  toStartOfHour(toDateTime(startTime)) as timestamp,
+  toStartOfHour(fromUnixTimestamp64Nano(startTime)) as timestamp,
   tenant, namespace, group,
   serviceName as service_name,
   count() as count
@@ -8,9 +8,9 @@ mkdir release/darwin release/linux release/windows
 
 ## build query 
 cd query
-GOOS=darwin GOARCH=amd64 go build -o ../release/darwin/xobserve
-GOOS=linux GOARCH=amd64 go build -o ../release/linux/xobserve
-GOOS=windows GOARCH=amd64 go build -o ../release/windows/xobserve.exe
+GOOS=darwin GOARCH=amd64 go build -tags sqlite_fts5 -o ../release/darwin/xobserve
+GOOS=linux GOARCH=amd64 go build -tags sqlite_fts5 -o ../release/linux/xobserve
+GOOS=windows GOARCH=amd64 go build -tags sqlite_fts5 -o ../release/windows/xobserve.exe
 
 ## copy files
 cp xobserve.yaml ../release/darwin/xobserve.yaml